@@ -5,9 +5,18 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"runtime"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/ctreminiom/go-atlassian/confluence"
 	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
 	"github.com/joho/godotenv"
 	"github.com/nguyenvanduocit/confluence-mcp/services"
@@ -22,16 +31,46 @@ func main() {
 	switch os.Args[1] {
 	case "search-page":
 		runSearchPage(os.Args[2:])
+	case "lint-cql":
+		runLintCQL(os.Args[2:])
 	case "get-page":
 		runGetPage(os.Args[2:])
 	case "create-page":
 		runCreatePage(os.Args[2:])
+	case "create-from-template":
+		runCreateFromTemplate(os.Args[2:])
 	case "update-page":
 		runUpdatePage(os.Args[2:])
 	case "get-comments":
 		runGetComments(os.Args[2:])
 	case "list-spaces":
 		runListSpaces(os.Args[2:])
+	case "get-space-permissions":
+		runGetSpacePermissions(os.Args[2:])
+	case "page-properties":
+		runPagePropertiesReport(os.Args[2:])
+	case "check-links":
+		runCheckLinks(os.Args[2:])
+	case "content-health":
+		runContentHealth(os.Args[2:])
+	case "pull":
+		runPull(os.Args[2:])
+	case "push":
+		runPush(os.Args[2:])
+	case "export":
+		runExport(os.Args[2:])
+	case "import":
+		runImport(os.Args[2:])
+	case "index":
+		runIndex(os.Args[2:])
+	case "local-search":
+		runLocalSearch(os.Args[2:])
+	case "login":
+		runLogin(os.Args[2:])
+	case "logout":
+		runLogout(os.Args[2:])
+	case "config":
+		runConfig(os.Args[2:])
 	case "help", "--help", "-h":
 		printUsage()
 	default:
@@ -49,15 +88,34 @@ Usage:
 
 Commands:
   search-page    Search Confluence pages using CQL
+  lint-cql       Check a CQL query without running it
   get-page       Get a Confluence page by ID
   create-page    Create a new Confluence page
+  create-from-template
+                 Create a page from a local template file or a Confluence template
   update-page    Update an existing Confluence page
   get-comments   Get comments for a Confluence page
   list-spaces    List Confluence spaces
+  get-space-permissions
+                 Show the permission matrix of a space, or diff two spaces
+  page-properties
+                 Report the Page Properties macros of labelled pages as a table
+  check-links    Report broken page links, missing attachments and dead URLs of a space
+  content-health Report stale, orphaned, empty and duplicated pages of a space
+  pull           Mirror a page tree into a directory of Markdown files
+  push           Create and update pages from a directory of Markdown files
+  export         Export a space to a tar.gz or zip archive
+  import         Create pages from a directory of Markdown or HTML files
+  index          Build or refresh the local search index of spaces
+  local-search   Search the local index
+  login          Log in with OAuth 2.0 and store the token
+  logout         Remove the stored OAuth token
+  config         Manage configuration profiles (list|use|show)
 
 Global Flags:
-  --env string     Path to .env file
-  --output string  Output format: text|json (default "text")
+  --env string      Path to .env file
+  --profile string  Configuration profile to use (default: active profile)
+  --output string   Output format: text|json (default "text", or the profile's output)
 
 Run 'confluence-cli <command> --help' for command-specific flags.
 `)
@@ -71,8 +129,60 @@ func loadEnv(envFile string) {
 	}
 }
 
+// resolveOutput returns the --output flag, falling back to the profile's output format when it is not set
+func resolveOutput(fs *flag.FlagSet, output, profile string) string {
+	if isFlagSet(fs, "output") {
+		return output
+	}
+	if cfg, err := services.ConfigFor(profile); err == nil && cfg.Output != "" {
+		return cfg.Output
+	}
+	return output
+}
+
+// splitList splits a comma-separated flag into trimmed, non-empty values
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// resolvePage turns a page reference (ID, URL, tiny link or title) into a page ID, exiting when it cannot
+func resolvePage(client *confluence.Client, profile, reference string) string {
+	if reference == "" {
+		return ""
+	}
+	cfg, err := services.ConfigFor(profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	id, err := services.ResolvePageID(context.Background(), client, cfg, reference)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	return id
+}
+
 func outputResult(v interface{}, format string) {
 	switch format {
+	case "csv":
+		renderer, ok := v.(interface{ CSV() (string, error) })
+		if !ok {
+			fmt.Fprintln(os.Stderr, "csv output is not supported by this command")
+			os.Exit(1)
+		}
+		data, err := renderer.CSV()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to encode CSV: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(data)
 	case "json":
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
@@ -93,6 +203,7 @@ func outputResult(v interface{}, format string) {
 func runSearchPage(args []string) {
 	fs := flag.NewFlagSet("search-page", flag.ExitOnError)
 	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
 	query := fs.String("query", "", "CQL query (required)")
 	output := fs.String("output", "text", "Output format: text|json")
 	fs.Parse(args)
@@ -105,17 +216,33 @@ func runSearchPage(args []string) {
 		os.Exit(1)
 	}
 
-	client, err := services.ConfluenceClient()
+	lint := services.LintCQL(*query)
+	for _, warning := range lint.Warnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if !lint.Valid {
+		fmt.Fprintf(os.Stderr, "invalid CQL query:\n%s\n", strings.Join(lint.Errors(), "\n"))
+		os.Exit(1)
+	}
+
+	client, err := services.ConfluenceClientFor(*profile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
 	options := &models.SearchContentOptions{
-		Limit: 5,
+		Limit:  5,
+		Expand: services.SearchExpand,
+	}
+
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
 
-	contents, response, err := client.Search.Content(context.Background(), *query, options)
+	contents, response, err := services.SearchContent(context.Background(), client, cfg, *query, options)
 	if err != nil {
 		if response != nil {
 			fmt.Fprintf(os.Stderr, "search failed: %s (endpoint: %s)\n", response.Bytes.String(), response.Endpoint)
@@ -132,6 +259,8 @@ func runSearchPage(args []string) {
 		Link         string `json:"link" yaml:"link"`
 		LastModified string `json:"last_modified" yaml:"last_modified"`
 		Excerpt      string `json:"excerpt" yaml:"excerpt"`
+
+		services.ResultDetails `yaml:",inline"`
 	}
 	type SearchPageOutput struct {
 		Query       string         `json:"query" yaml:"query"`
@@ -150,25 +279,55 @@ func runSearchPage(args []string) {
 		out.Message = "No results found for the search query"
 	} else {
 		for _, content := range contents.Results {
-			out.Results = append(out.Results, SearchResult{
-				Title:        content.Content.Title,
-				ID:           content.Content.ID,
-				Type:         content.Content.Type,
-				Link:         content.Content.Links.Self,
+			result := SearchResult{
+				Title:        content.Title,
+				Type:         content.EntityType,
+				Link:         services.ResultLink(cfg, content),
 				LastModified: content.LastModified,
 				Excerpt:      content.Excerpt,
-			})
+
+				ResultDetails: services.SearchResultDetails(cfg, content),
+			}
+			if content.Content != nil {
+				result.Title = content.Content.Title
+				result.ID = content.Content.ID
+				result.Type = content.Content.Type
+			}
+			out.Results = append(out.Results, result)
 		}
 		out.Message = fmt.Sprintf("Found %d results for query: %s", len(contents.Results), *query)
 	}
 
-	outputResult(out, *output)
+	outputResult(out, resolveOutput(fs, *output, *profile))
+}
+
+func runLintCQL(args []string) {
+	fs := flag.NewFlagSet("lint-cql", flag.ExitOnError)
+	query := fs.String("query", "", "CQL query to check (required)")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args)
+
+	if *query == "" {
+		fmt.Fprintln(os.Stderr, "Error: --query is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	lint := services.LintCQL(*query)
+	outputResult(lint, *output)
+	if !lint.Valid {
+		os.Exit(1)
+	}
 }
 
 func runGetPage(args []string) {
 	fs := flag.NewFlagSet("get-page", flag.ExitOnError)
 	env := fs.String("env", "", "Path to .env file")
-	id := fs.String("id", "", "Confluence page ID (required)")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	id := fs.String("id", "", "Page ID, URL, tiny link or SPACE:Title (required)")
+	outline := fs.Bool("outline", false, "Print the heading tree with section sizes instead of the content")
+	maxChars := fs.Int("max-chars", 0, "Maximum number of characters of content to print")
+	offset := fs.Int("offset", 0, "Character offset to start from (the next_offset of a previous call)")
 	output := fs.String("output", "text", "Output format: text|json")
 	fs.Parse(args)
 
@@ -180,11 +339,12 @@ func runGetPage(args []string) {
 		os.Exit(1)
 	}
 
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(*profile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	*id = resolvePage(client, *profile, *id)
 
 	ctx := context.Background()
 	expandParams := []string{"body.storage", "body.view", "body"}
@@ -220,22 +380,38 @@ func runGetPage(args []string) {
 		Version int    `json:"version" yaml:"version"`
 	}
 	type GetPageOutput struct {
-		Title          string     `json:"title" yaml:"title"`
-		ID             string     `json:"id" yaml:"id"`
-		Version        int        `json:"version" yaml:"version"`
-		Type           string     `json:"type" yaml:"type"`
-		Content        string     `json:"content" yaml:"content"`
-		DirectChildren []PageInfo `json:"direct_children,omitempty" yaml:"direct_children,omitempty"`
-		AllDescendants []PageInfo `json:"all_descendants,omitempty" yaml:"all_descendants,omitempty"`
-		Message        string     `json:"message" yaml:"message"`
+		Title          string                     `json:"title" yaml:"title"`
+		ID             string                     `json:"id" yaml:"id"`
+		Version        int                        `json:"version" yaml:"version"`
+		Type           string                     `json:"type" yaml:"type"`
+		Content        string                     `json:"content,omitempty" yaml:"content,omitempty"`
+		Outline        []*services.OutlineSection `json:"outline,omitempty" yaml:"outline,omitempty"`
+		TotalChars     int                        `json:"total_chars" yaml:"total_chars"`
+		NextOffset     int                        `json:"next_offset,omitempty" yaml:"next_offset,omitempty"`
+		DirectChildren []PageInfo                 `json:"direct_children,omitempty" yaml:"direct_children,omitempty"`
+		AllDescendants []PageInfo                 `json:"all_descendants,omitempty" yaml:"all_descendants,omitempty"`
+		Message        string                     `json:"message" yaml:"message"`
 	}
 
 	out := GetPageOutput{
-		Title:   content.Title,
-		ID:      content.ID,
-		Version: versionNumber,
-		Type:    content.Type,
-		Content: htmlContent,
+		Title:      content.Title,
+		ID:         content.ID,
+		Version:    versionNumber,
+		Type:       content.Type,
+		Content:    htmlContent,
+		TotalChars: utf8.RuneCountInString(htmlContent),
+	}
+	if *outline {
+		out.Content = ""
+		out.Outline = services.OutlineStorage(htmlContent)
+	} else if *maxChars > 0 || *offset > 0 {
+		window, err := services.WindowContent(htmlContent, *offset, *maxChars)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		out.Content = window.Content
+		out.NextOffset = window.NextOffset
 	}
 
 	childPages, childResponse, err := client.Content.ChildrenDescendant.ChildrenByType(
@@ -291,32 +467,40 @@ func runGetPage(args []string) {
 	out.Message = fmt.Sprintf("Page retrieved successfully with %d direct children and %d other descendants",
 		len(out.DirectChildren), len(out.AllDescendants))
 
-	outputResult(out, *output)
+	outputResult(out, resolveOutput(fs, *output, *profile))
 }
 
 func runCreatePage(args []string) {
 	fs := flag.NewFlagSet("create-page", flag.ExitOnError)
 	env := fs.String("env", "", "Path to .env file")
-	space := fs.String("space", "", "Space key (required)")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	space := fs.String("space", "", "Space key (required unless the profile has a default space)")
 	title := fs.String("title", "", "Page title (required)")
 	content := fs.String("content", "", "Page content in storage format XHTML (required)")
-	parentID := fs.String("parent-id", "", "Parent page ID (optional)")
+	parentID := fs.String("parent-id", "", "Parent page ID, URL, tiny link or SPACE:Title (optional)")
 	output := fs.String("output", "text", "Output format: text|json")
 	fs.Parse(args)
 
 	loadEnv(*env)
 
+	if *space == "" {
+		if cfg, err := services.ConfigFor(*profile); err == nil {
+			*space = cfg.DefaultSpace
+		}
+	}
+
 	if *space == "" || *title == "" || *content == "" {
 		fmt.Fprintln(os.Stderr, "Error: --space, --title, and --content are required")
 		fs.Usage()
 		os.Exit(1)
 	}
 
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(*profile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	*parentID = resolvePage(client, *profile, *parentID)
 
 	payload := &models.ContentScheme{
 		Type:  "page",
@@ -374,13 +558,190 @@ func runCreatePage(args []string) {
 			newPage.Title, newPage.ID, versionNumber, selfLink),
 	}
 
-	outputResult(out, *output)
+	outputResult(out, resolveOutput(fs, *output, *profile))
+}
+
+// varFlags collects repeated --var key=value flags
+type varFlags map[string]interface{}
+
+func (v varFlags) String() string { return "" }
+
+func (v varFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	v[strings.TrimSpace(key)] = val
+	return nil
+}
+
+// templateValues reads --vars, a JSON object given inline or as a file path, and overlays the --var flags
+func templateValues(vars string, overrides varFlags) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if vars != "" {
+		data := []byte(vars)
+		if !strings.HasPrefix(strings.TrimSpace(vars), "{") {
+			var err error
+			if data, err = os.ReadFile(vars); err != nil {
+				return nil, err
+			}
+		}
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("--vars must be a JSON object: %w", err)
+		}
+	}
+	for key, value := range overrides {
+		values[key] = value
+	}
+	return values, nil
+}
+
+func runCreateFromTemplate(args []string) {
+	fs := flag.NewFlagSet("create-from-template", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	templateFile := fs.String("template", "", "Local template file with Go text/template placeholders (.md, .markdown or storage format)")
+	templateID := fs.String("template-id", "", "Confluence template ID or name")
+	space := fs.String("space", "", "Space key (required unless the profile has a default space)")
+	title := fs.String("title", "", "Page title, which may use placeholders too (required)")
+	parentID := fs.String("parent-id", "", "Parent page ID, URL, tiny link or SPACE:Title (optional)")
+	vars := fs.String("vars", "", "Variable values as a JSON object, inline or the path of a JSON file")
+	overrides := varFlags{}
+	fs.Var(overrides, "var", "Variable value as key=value (repeatable)")
+	allowMissing := fs.Bool("allow-missing", false, "Leave variables without a value empty instead of failing")
+	dryRun := fs.Bool("dry-run", false, "Print the rendered page instead of creating it")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	if *space == "" {
+		if cfg, err := services.ConfigFor(*profile); err == nil {
+			*space = cfg.DefaultSpace
+		}
+	}
+
+	if (*templateFile == "") == (*templateID == "") || *title == "" || (*space == "" && !*dryRun) {
+		fmt.Fprintln(os.Stderr, "Error: one of --template or --template-id, and --space and --title are required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	values, err := templateValues(*vars, overrides)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	pageTitle, err := services.ExecuteTemplate("title", *title, values, *allowMissing)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to render title: %v\n", err)
+		os.Exit(1)
+	}
+
+	type CreateFromTemplateOutput struct {
+		Success  bool   `json:"success" yaml:"success"`
+		Title    string `json:"title" yaml:"title"`
+		ID       string `json:"id,omitempty" yaml:"id,omitempty"`
+		Version  int    `json:"version,omitempty" yaml:"version,omitempty"`
+		Link     string `json:"link,omitempty" yaml:"link,omitempty"`
+		Template string `json:"template" yaml:"template"`
+		Content  string `json:"content,omitempty" yaml:"content,omitempty"`
+	}
+	out := CreateFromTemplateOutput{Success: true, Title: pageTitle, Template: *templateFile}
+
+	var client *confluence.Client
+	if !*dryRun || *templateID != "" {
+		if client, err = services.ConfluenceClientFor(*profile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	ctx := context.Background()
+
+	var page *models.ContentScheme
+	if *templateID != "" {
+		out.Template = *templateID
+		if *dryRun {
+			t, err := services.FindTemplate(ctx, client, *space, *templateID)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			if out.Content, err = services.FillTemplate(t.Body, values, *allowMissing); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			out.Template = t.Name
+			outputResult(out, resolveOutput(fs, *output, *profile))
+			return
+		}
+		var t *services.Template
+		page, t, err = services.CreatePageFromTemplate(ctx, client, services.TemplatePageOptions{
+			Template:     *templateID,
+			SpaceKey:     *space,
+			Title:        pageTitle,
+			ParentID:     resolvePage(client, *profile, *parentID),
+			Values:       values,
+			AllowMissing: *allowMissing,
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		out.Template = t.Name
+	} else {
+		content, err := services.RenderTemplateFile(*templateFile, values, *allowMissing)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to render template: %v\n", err)
+			os.Exit(1)
+		}
+		if *dryRun {
+			out.Content = content
+			outputResult(out, resolveOutput(fs, *output, *profile))
+			return
+		}
+
+		payload := &models.ContentScheme{
+			Type:  "page",
+			Title: pageTitle,
+			Space: &models.SpaceScheme{Key: *space},
+			Body: &models.BodyScheme{
+				Storage: &models.BodyNodeScheme{Value: content, Representation: "storage"},
+			},
+		}
+		if id := resolvePage(client, *profile, *parentID); id != "" {
+			payload.Ancestors = []*models.ContentScheme{{ID: id}}
+		}
+		var response *models.ResponseScheme
+		page, response, err = client.Content.Create(ctx, payload)
+		if err != nil {
+			if response != nil {
+				fmt.Fprintf(os.Stderr, "failed to create page: %s (endpoint: %s)\n", response.Bytes.String(), response.Endpoint)
+			} else {
+				fmt.Fprintf(os.Stderr, "failed to create page: %v\n", err)
+			}
+			os.Exit(1)
+		}
+	}
+
+	out.ID = page.ID
+	out.Title = page.Title
+	if page.Version != nil {
+		out.Version = page.Version.Number
+	}
+	if page.Links != nil {
+		if cfg, err := services.ConfigFor(*profile); err == nil {
+			out.Link = cfg.WebURL(page.Links.Webui)
+		}
+	}
+	outputResult(out, resolveOutput(fs, *output, *profile))
 }
 
 func runUpdatePage(args []string) {
 	fs := flag.NewFlagSet("update-page", flag.ExitOnError)
 	env := fs.String("env", "", "Path to .env file")
-	id := fs.String("id", "", "Page ID (required)")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	id := fs.String("id", "", "Page ID, URL, tiny link or SPACE:Title (required)")
 	title := fs.String("title", "", "New page title (required)")
 	content := fs.String("content", "", "New page content in storage format XHTML (required)")
 	version := fs.String("version", "", "Version number override (optional)")
@@ -395,11 +756,12 @@ func runUpdatePage(args []string) {
 		os.Exit(1)
 	}
 
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(*profile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	*id = resolvePage(client, *profile, *id)
 
 	ctx := context.Background()
 
@@ -487,13 +849,14 @@ func runUpdatePage(args []string) {
 			updatedPage.Title, updatedPage.ID, versionNumber, selfLink),
 	}
 
-	outputResult(out, *output)
+	outputResult(out, resolveOutput(fs, *output, *profile))
 }
 
 func runGetComments(args []string) {
 	fs := flag.NewFlagSet("get-comments", flag.ExitOnError)
 	env := fs.String("env", "", "Path to .env file")
-	id := fs.String("id", "", "Confluence page ID (required)")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	id := fs.String("id", "", "Page ID, URL, tiny link or SPACE:Title (required)")
 	output := fs.String("output", "text", "Output format: text|json")
 	fs.Parse(args)
 
@@ -505,11 +868,12 @@ func runGetComments(args []string) {
 		os.Exit(1)
 	}
 
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(*profile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
+	*id = resolvePage(client, *profile, *id)
 
 	maxResults := 50
 	comments, response, err := client.Content.Comment.Gets(
@@ -568,24 +932,40 @@ func runGetComments(args []string) {
 		out.Message = fmt.Sprintf("Found %d comments (page 1)", len(comments.Results))
 	}
 
-	outputResult(out, *output)
+	outputResult(out, resolveOutput(fs, *output, *profile))
 }
 
 func runListSpaces(args []string) {
 	fs := flag.NewFlagSet("list-spaces", flag.ExitOnError)
 	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	startAt := fs.Int("start-at", 0, "Starting index for pagination")
+	maxResults := fs.Int("max-results", 100, "Maximum number of spaces to return")
+	spaceType := fs.String("type", "", "Filter by space type: global|personal")
+	status := fs.String("status", "", "Filter by space status: current|archived")
+	labels := fs.String("labels", "", "Filter by space labels (comma-separated)")
+	favourite := fs.Bool("favourite", false, "Only list spaces favourited by the current user")
 	output := fs.String("output", "text", "Output format: text|json")
 	fs.Parse(args)
 
 	loadEnv(*env)
 
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(*profile)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
-	spaces, response, err := client.Space.Gets(context.Background(), nil, 0, 100)
+	options := &models.GetSpacesOptionScheme{
+		SpaceType: *spaceType,
+		Status:    *status,
+		Favorite:  *favourite,
+	}
+	if *labels != "" {
+		options.Labels = strings.Split(*labels, ",")
+	}
+
+	spaces, response, err := client.Space.Gets(context.Background(), options, *startAt, *maxResults)
 	if err != nil {
 		if response != nil {
 			fmt.Fprintf(os.Stderr, "failed to list spaces: %s (endpoint: %s)\n", response.Bytes.String(), response.Endpoint)
@@ -604,14 +984,22 @@ func runListSpaces(args []string) {
 		Link   string `json:"link" yaml:"link"`
 	}
 	type ListSpacesOutput struct {
-		Spaces     []SpaceInfo `json:"spaces" yaml:"spaces"`
-		SpaceCount int         `json:"space_count" yaml:"space_count"`
-		Message    string      `json:"message" yaml:"message"`
+		Spaces      []SpaceInfo `json:"spaces" yaml:"spaces"`
+		SpaceCount  int         `json:"space_count" yaml:"space_count"`
+		StartAt     int         `json:"start_at" yaml:"start_at"`
+		HasMore     bool        `json:"has_more" yaml:"has_more"`
+		NextStartAt int         `json:"next_start_at,omitempty" yaml:"next_start_at,omitempty"`
+		Message     string      `json:"message" yaml:"message"`
 	}
 
 	out := ListSpacesOutput{
 		Spaces:     make([]SpaceInfo, 0, len(spaces.Results)),
 		SpaceCount: len(spaces.Results),
+		StartAt:    *startAt,
+	}
+	if len(spaces.Results) >= *maxResults {
+		out.HasMore = true
+		out.NextStartAt = *startAt + len(spaces.Results)
 	}
 
 	if len(spaces.Results) == 0 {
@@ -634,5 +1022,728 @@ func runListSpaces(args []string) {
 		out.Message = fmt.Sprintf("Found %d spaces", len(spaces.Results))
 	}
 
-	outputResult(out, *output)
+	outputResult(out, resolveOutput(fs, *output, *profile))
+}
+
+func runGetSpacePermissions(args []string) {
+	fs := flag.NewFlagSet("get-space-permissions", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	spaceKey := fs.String("space", "", "Space key (required)")
+	compare := fs.String("compare", "", "Key of a second space to diff permissions against")
+	output := fs.String("output", "text", "Output format: text|json|csv")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	if *spaceKey == "" {
+		fmt.Fprintln(os.Stderr, "Error: --space is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	matrix, response, err := services.GetSpacePermissionMatrix(context.Background(), client, *spaceKey)
+	if err != nil {
+		if response != nil {
+			fmt.Fprintf(os.Stderr, "failed to get space permissions: %s (endpoint: %s)\n", response.Bytes.String(), response.Endpoint)
+		} else {
+			fmt.Fprintf(os.Stderr, "failed to get space permissions: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	if *compare == "" {
+		outputResult(matrix, resolveOutput(fs, *output, *profile))
+		return
+	}
+
+	other, response, err := services.GetSpacePermissionMatrix(context.Background(), client, *compare)
+	if err != nil {
+		if response != nil {
+			fmt.Fprintf(os.Stderr, "failed to get space permissions: %s (endpoint: %s)\n", response.Bytes.String(), response.Endpoint)
+		} else {
+			fmt.Fprintf(os.Stderr, "failed to get space permissions: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	outputResult(services.DiffPermissionMatrices(matrix, other), resolveOutput(fs, *output, *profile))
+}
+
+func runPagePropertiesReport(args []string) {
+	fs := flag.NewFlagSet("page-properties", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	label := fs.String("label", "", "Label of the pages to report on (required)")
+	spaces := fs.String("space", "", "Comma-separated space keys (default: all spaces)")
+	macroID := fs.String("macro-id", "", "Only read Page Properties macros with this ID")
+	columns := fs.String("columns", "", "Comma-separated keys to report, in order (default: every key found)")
+	where := fs.String("where", "", "Comma-separated filters, e.g. Status=Proposed or Status!=Done")
+	limit := fs.Int("limit", 0, "Maximum number of pages to read (default: 500)")
+	output := fs.String("output", "text", "Output format: text|json|csv")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	if *label == "" {
+		fmt.Fprintln(os.Stderr, "Error: --label is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	report, response, err := services.BuildPropertiesReport(context.Background(), client, cfg, services.PropertiesQuery{
+		Label:   *label,
+		Spaces:  splitList(*spaces),
+		MacroID: *macroID,
+		Columns: splitList(*columns),
+		Filters: splitList(*where),
+		Limit:   *limit,
+	})
+	if err != nil {
+		if response != nil {
+			fmt.Fprintf(os.Stderr, "failed to build report: %s (endpoint: %s)\n", response.Bytes.String(), response.Endpoint)
+		} else {
+			fmt.Fprintf(os.Stderr, "failed to build report: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	outputResult(report, resolveOutput(fs, *output, *profile))
+}
+
+func runCheckLinks(args []string) {
+	fs := flag.NewFlagSet("check-links", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	spaceKey := fs.String("space", "", "Space key to check (default: the profile's default space)")
+	external := fs.Bool("external", false, "Also request external URLs and report dead ones")
+	timeout := fs.Duration("timeout", 10*time.Second, "Timeout of each external URL request")
+	output := fs.String("output", "text", "Output format: text|json|csv")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *spaceKey == "" {
+		*spaceKey = cfg.DefaultSpace
+	}
+	if *spaceKey == "" {
+		fmt.Fprintln(os.Stderr, "Error: --space is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	report, err := services.CheckLinks(context.Background(), client, cfg, services.LinkCheckOptions{
+		SpaceKey: *spaceKey,
+		External: *external,
+		Timeout:  *timeout,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to check links: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputResult(report, resolveOutput(fs, *output, *profile))
+}
+
+func runContentHealth(args []string) {
+	fs := flag.NewFlagSet("content-health", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	spaceKey := fs.String("space", "", "Space key to scan (default: the profile's default space)")
+	staleDays := fs.Int("stale-days", 365, "Pages not modified for this many days are stale")
+	checks := fs.String("checks", "", "Comma-separated checks: stale, orphan, deactivated_editor, empty, duplicate_title (default: all)")
+	output := fs.String("output", "text", "Output format: text|json|csv")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *spaceKey == "" {
+		*spaceKey = cfg.DefaultSpace
+	}
+	if *spaceKey == "" {
+		fmt.Fprintln(os.Stderr, "Error: --space is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	report, err := services.ContentHealth(context.Background(), client, cfg, services.HealthOptions{
+		SpaceKey:  *spaceKey,
+		StaleDays: *staleDays,
+		Checks:    splitList(*checks),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to check content health: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputResult(report, resolveOutput(fs, *output, *profile))
+}
+
+func runPull(args []string) {
+	fs := flag.NewFlagSet("pull", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	pageID := fs.String("page", "", "Root page ID, URL, tiny link or SPACE:Title (default: the root page of a previously pulled directory)")
+	dir := fs.String("dir", ".", "Directory to write Markdown files to")
+	dryRun := fs.Bool("dry-run", false, "Show what would change without writing files")
+	force := fs.Bool("force", false, "Overwrite local changes to pages that also changed in Confluence")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	*pageID = resolvePage(client, *profile, *pageID)
+
+	plan, err := services.Pull(context.Background(), client, cfg, services.PullOptions{
+		RootPageID: *pageID,
+		Dir:        *dir,
+		DryRun:     *dryRun,
+		Force:      *force,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pull failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputResult(plan, resolveOutput(fs, *output, *profile))
+	if plan.HasConflicts() {
+		os.Exit(2)
+	}
+}
+
+func runPush(args []string) {
+	fs := flag.NewFlagSet("push", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	dir := fs.String("dir", ".", "Directory of Markdown files to push")
+	spaceKey := fs.String("space", "", "Space key (default: the space of a previously pulled directory, or the profile's default space)")
+	parentID := fs.String("parent", "", "Parent page ID, URL, tiny link or SPACE:Title for top-level files (default: the parent recorded in the state file)")
+	dryRun := fs.Bool("dry-run", false, "Show what would change without updating Confluence")
+	force := fs.Bool("force", false, "Overwrite pages that changed in Confluence since the last pull")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	*parentID = resolvePage(client, *profile, *parentID)
+
+	plan, err := services.Push(context.Background(), client, cfg, services.PushOptions{
+		Dir:      *dir,
+		SpaceKey: *spaceKey,
+		ParentID: *parentID,
+		DryRun:   *dryRun,
+		Force:    *force,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "push failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputResult(plan, resolveOutput(fs, *output, *profile))
+	if plan.HasConflicts() {
+		os.Exit(2)
+	}
+}
+
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	spaceKey := fs.String("space", "", "Space key (required)")
+	format := fs.String("format", services.ExportMarkdown, "Page format: markdown|html")
+	archive := fs.String("archive", "", "Archive to write, .tar.gz or .zip (default \"<space>-export.tar.gz\")")
+	concurrency := fs.Int("concurrency", 4, "Number of pages fetched in parallel")
+	noAttachments := fs.Bool("no-attachments", false, "Skip downloading attachments")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	if *spaceKey == "" {
+		fmt.Fprintln(os.Stderr, "Error: --space is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if *archive == "" {
+		*archive = *spaceKey + "-export.tar.gz"
+	}
+
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	summary, err := services.ExportSpace(context.Background(), client, cfg, services.ExportOptions{
+		SpaceKey:    *spaceKey,
+		Format:      *format,
+		Output:      *archive,
+		Concurrency: *concurrency,
+		Attachments: !*noAttachments,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "export failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputResult(summary, resolveOutput(fs, *output, *profile))
+}
+
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	dir := fs.String("dir", "", "Directory of Markdown or HTML files (required)")
+	spaceKey := fs.String("space", "", "Space key (default: the profile's default space)")
+	parentID := fs.String("parent", "", "Parent page ID, URL, tiny link or SPACE:Title for top-level files")
+	checkpoint := fs.String("checkpoint", "", "Checkpoint file used to resume an interrupted import (default \"<dir>/.confluence-import.json\")")
+	dryRun := fs.Bool("dry-run", false, "Show the pages that would be created without creating them")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	if *dir == "" {
+		fmt.Fprintln(os.Stderr, "Error: --dir is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if *spaceKey == "" {
+		*spaceKey = cfg.DefaultSpace
+	}
+	if *spaceKey == "" {
+		fmt.Fprintln(os.Stderr, "Error: --space is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	*parentID = resolvePage(client, *profile, *parentID)
+
+	report, err := services.ImportDirectory(context.Background(), client, services.ImportOptions{
+		Dir:        *dir,
+		SpaceKey:   *spaceKey,
+		ParentID:   *parentID,
+		Checkpoint: *checkpoint,
+		DryRun:     *dryRun,
+	})
+	if report != nil {
+		outputResult(report, resolveOutput(fs, *output, *profile))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "import failed: %v\n", err)
+		if report != nil && !*dryRun {
+			fmt.Fprintf(os.Stderr, "run the same command again to resume from %s\n", report.Checkpoint)
+		}
+		os.Exit(1)
+	}
+}
+
+func runIndex(args []string) {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	spaces := fs.String("space", "", "Comma-separated space keys (default: every indexed space)")
+	full := fs.Bool("full", false, "Re-crawl every page instead of only pages modified since the last crawl")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	ix, err := services.OpenSearchIndex(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	keys := splitList(*spaces)
+	if len(keys) == 0 {
+		for key := range ix.IndexedSpaces() {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: --space is required when no space is indexed yet")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var refreshed []*services.IndexRefresh
+	for _, key := range keys {
+		refresh, err := ix.RefreshSpace(context.Background(), client, cfg, key, *full)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "index failed: %v\n", err)
+			os.Exit(1)
+		}
+		refreshed = append(refreshed, refresh)
+	}
+	if err := ix.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to save search index: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputResult(refreshed, resolveOutput(fs, *output, *profile))
+}
+
+func runLocalSearch(args []string) {
+	fs := flag.NewFlagSet("local-search", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	query := fs.String("query", "", "Search query: terms, \"phrases\", title:/heading:/label:/body: fields, +required, -excluded (required)")
+	spaces := fs.String("space", "", "Comma-separated space keys to search (default: every indexed space)")
+	refresh := fs.Bool("refresh", false, "Fetch pages modified since the last crawl before searching")
+	limit := fs.Int("limit", 10, "Maximum number of results")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	if *query == "" {
+		fmt.Fprintln(os.Stderr, "Error: --query is required")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg, err := services.ConfigFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	client, err := services.ConfluenceClientFor(*profile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	keys := splitList(*spaces)
+
+	result, err := services.LocalSearch(context.Background(), client, cfg, services.LocalSearchOptions{
+		Query:   *query,
+		Spaces:  keys,
+		Refresh: *refresh,
+		Limit:   *limit,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "local search failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	outputResult(result, resolveOutput(fs, *output, *profile))
+}
+
+func runLogin(args []string) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	site := fs.String("site", "", "Site to use when the account can access several (default: ATLASSIAN_HOST)")
+	redirect := fs.String("redirect-uri", "http://localhost:8765/callback", "OAuth callback URL registered for the app (ATLASSIAN_OAUTH_REDIRECT_URI)")
+	noBrowser := fs.Bool("no-browser", false, "Print the authorization URL instead of opening a browser")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	if *site == "" && *profile != "" {
+		if file, err := services.LoadConfigFile(); err == nil && file.Profiles[*profile] != nil {
+			*site = file.Profiles[*profile].Host
+		}
+	}
+	if *site == "" {
+		*site = os.Getenv("ATLASSIAN_HOST")
+	}
+	if envRedirect := os.Getenv("ATLASSIAN_OAUTH_REDIRECT_URI"); envRedirect != "" && !isFlagSet(fs, "redirect-uri") {
+		*redirect = envRedirect
+	}
+
+	callbackURL, err := url.Parse(*redirect)
+	if err != nil || callbackURL.Host == "" {
+		fmt.Fprintf(os.Stderr, "invalid --redirect-uri: %s\n", *redirect)
+		os.Exit(1)
+	}
+
+	type loginResult struct {
+		token *services.OAuthToken
+		err   error
+	}
+	done := make(chan loginResult, 1)
+	handler := services.NewOAuthHandler(*redirect, func(token *services.OAuthToken, err error) {
+		done <- loginResult{token: token, err: err}
+	})
+
+	loginURL, err := handler.LoginURL(*profile, *site)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	listener, err := net.Listen("tcp", callbackURL.Host)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to listen on %s: %v\n", callbackURL.Host, err)
+		os.Exit(1)
+	}
+
+	callbackPath := callbackURL.Path
+	if callbackPath == "" {
+		callbackPath = "/"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(callbackPath, handler.ServeCallback)
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(listener)
+	defer srv.Shutdown(context.Background())
+
+	fmt.Fprintf(os.Stderr, "Open the following URL to authorize confluence-cli:\n\n  %s\n\n", loginURL)
+	if !*noBrowser {
+		if err := openBrowser(loginURL); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not open browser: %v\n", err)
+		}
+	}
+
+	var result loginResult
+	select {
+	case result = <-done:
+	case <-time.After(5 * time.Minute):
+		fmt.Fprintln(os.Stderr, "login timed out")
+		os.Exit(1)
+	}
+	if result.err != nil {
+		fmt.Fprintf(os.Stderr, "login failed: %v\n", result.err)
+		os.Exit(1)
+	}
+
+	type LoginOutput struct {
+		Success bool   `json:"success" yaml:"success"`
+		Site    string `json:"site" yaml:"site"`
+		CloudID string `json:"cloud_id" yaml:"cloud_id"`
+		Expiry  string `json:"expiry" yaml:"expiry"`
+		Message string `json:"message" yaml:"message"`
+	}
+
+	outputResult(LoginOutput{
+		Success: true,
+		Site:    result.token.SiteURL,
+		CloudID: result.token.CloudID,
+		Expiry:  result.token.Expiry.Format(time.RFC3339),
+		Message: fmt.Sprintf("Logged in to %s. The token is stored encrypted and refreshed automatically.", result.token.SiteURL),
+	}, resolveOutput(fs, *output, *profile))
+}
+
+func runLogout(args []string) {
+	fs := flag.NewFlagSet("logout", flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	profile := fs.String("profile", "", "Configuration profile to use")
+	fs.Parse(args)
+
+	loadEnv(*env)
+
+	if err := services.DeleteOAuthToken(*profile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println("Logged out")
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func runConfig(args []string) {
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: confluence-cli config <list|use|show> [profile] [flags]")
+		os.Exit(1)
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ExitOnError)
+	env := fs.String("env", "", "Path to .env file")
+	output := fs.String("output", "text", "Output format: text|json")
+	fs.Parse(args[1:])
+
+	loadEnv(*env)
+
+	file, err := services.LoadConfigFile()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "list":
+		type ProfileSummary struct {
+			Name         string `json:"name" yaml:"name"`
+			Host         string `json:"host" yaml:"host"`
+			AuthType     string `json:"auth_type,omitempty" yaml:"auth_type,omitempty"`
+			DefaultSpace string `json:"default_space,omitempty" yaml:"default_space,omitempty"`
+			Current      bool   `json:"current" yaml:"current"`
+		}
+		type ConfigListOutput struct {
+			Profiles []ProfileSummary `json:"profiles" yaml:"profiles"`
+			Message  string           `json:"message" yaml:"message"`
+		}
+
+		out := ConfigListOutput{Profiles: make([]ProfileSummary, 0, len(file.Profiles))}
+		active := services.ActiveProfileName()
+		for _, name := range file.ProfileNames() {
+			p := file.Profiles[name]
+			out.Profiles = append(out.Profiles, ProfileSummary{
+				Name:         name,
+				Host:         p.Host,
+				AuthType:     p.AuthType,
+				DefaultSpace: p.DefaultSpace,
+				Current:      name == active,
+			})
+		}
+		if len(out.Profiles) == 0 {
+			path, _ := services.ConfigFilePath()
+			out.Message = fmt.Sprintf("No profiles configured in %s", path)
+		} else {
+			out.Message = fmt.Sprintf("Found %d profiles", len(out.Profiles))
+		}
+		outputResult(out, *output)
+
+	case "use":
+		if fs.NArg() < 1 {
+			fmt.Fprintln(os.Stderr, "Usage: confluence-cli config use <profile>")
+			os.Exit(1)
+		}
+		name := fs.Arg(0)
+		if _, ok := file.Profiles[name]; !ok {
+			fmt.Fprintf(os.Stderr, "profile %q not found\n", name)
+			os.Exit(1)
+		}
+		file.CurrentProfile = name
+		if err := services.SaveConfigFile(file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Switched to profile %q\n", name)
+
+	case "show":
+		name := fs.Arg(0)
+		if name == "" {
+			name = services.ActiveProfileName()
+		}
+		p, ok := file.Profiles[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "profile %q not found\n", name)
+			os.Exit(1)
+		}
+
+		type ConfigShowOutput struct {
+			Name    string           `json:"name" yaml:"name"`
+			Current bool             `json:"current" yaml:"current"`
+			Profile services.Profile `json:"profile" yaml:"profile"`
+		}
+
+		shown := *p
+		if shown.Token != "" {
+			shown.Token = "********"
+		}
+		outputResult(ConfigShowOutput{
+			Name:    name,
+			Current: name == file.CurrentProfile,
+			Profile: shown,
+		}, *output)
+
+	default:
+		fmt.Fprintf(os.Stderr, "unknown config command: %s\n", args[0])
+		os.Exit(1)
+	}
 }
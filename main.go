@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -12,6 +13,9 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/prompts"
+	"github.com/nguyenvanduocit/confluence-mcp/resources"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
 	"github.com/nguyenvanduocit/confluence-mcp/tools"
 )
 
@@ -21,6 +25,7 @@ type CleanupFunc func()
 func main() {
 	envFile := flag.String("env", "", "Path to environment file (optional when environment variables are set directly)")
 	streamableHttpPort := flag.String("http_port", "", "Port for streamable HTTP server. If not provided, will use stdio")
+	oauthLogin := flag.Bool("oauth_login", false, "Serve /oauth/login and /oauth/callback on the HTTP server; requires CONFLUENCE_MCP_OAUTH_LOGIN_SECRET")
 	flag.Parse()
 
 	if *envFile != "" {
@@ -30,7 +35,17 @@ func main() {
 	}
 
 	// Check required envs for Docker/production
-	requiredEnvs := []string{"ATLASSIAN_HOST", "ATLASSIAN_EMAIL", "ATLASSIAN_TOKEN"}
+	requiredEnvs := []string{"ATLASSIAN_HOST", "ATLASSIAN_TOKEN"}
+	switch {
+	case services.ActiveProfileName() != "":
+		// Credentials come from the profile in the config file
+		requiredEnvs = nil
+	case services.AuthTypeFromEnv() == services.AuthBasic:
+		requiredEnvs = append(requiredEnvs, "ATLASSIAN_EMAIL")
+	case services.AuthTypeFromEnv() == services.AuthOAuth:
+		// Credentials come from the token stored by 'confluence-cli login'
+		requiredEnvs = nil
+	}
 	missingEnvs := false
 	for _, env := range requiredEnvs {
 		if os.Getenv(env) == "" {
@@ -44,21 +59,80 @@ func main() {
 	}
 	
 
+	// Subscriptions are created after the server they notify through
+	var subscriptions *resources.Subscriptions
+	hooks := &server.Hooks{}
+	hooks.AddOnUnregisterSession(func(ctx context.Context, session server.ClientSession) {
+		subscriptions.RemoveSession(session.SessionID())
+	})
+
 	mcpServer := server.NewMCPServer(
 		"Confluence Tool",
 		"1.0.0",
 		server.WithRecovery(),
 		server.WithToolCapabilities(true),
+		server.WithResourceCapabilities(true, false),
+		server.WithPromptCapabilities(false),
+		server.WithHooks(hooks),
 		server.WithLogging(),
 	)
 
 	// Register Confluence tools
 	tools.RegisterSearchPageTool(mcpServer)
+	tools.RegisterSearchContentTool(mcpServer)
+	tools.RegisterLintCQLTool(mcpServer)
+	tools.RegisterLocalSearchTool(mcpServer)
+	tools.RegisterRetrievePassagesTool(mcpServer)
 	tools.RegisterGetPageTool(mcpServer)
+	tools.RegisterGetPageSectionTool(mcpServer)
 	tools.RegisterCreatePageTool(mcpServer)
+	tools.RegisterListTemplatesTool(mcpServer)
+	tools.RegisterGetTemplateTool(mcpServer)
+	tools.RegisterCreatePageFromTemplateTool(mcpServer)
+	tools.RegisterGetPageTablesTool(mcpServer)
+	tools.RegisterUpdatePageTableTool(mcpServer)
+	tools.RegisterPagePropertiesReportTool(mcpServer)
+	tools.RegisterListTasksTool(mcpServer)
+	tools.RegisterSetTaskStatusTool(mcpServer)
+	tools.RegisterGetOutgoingLinksTool(mcpServer)
+	tools.RegisterGetBacklinksTool(mcpServer)
+	tools.RegisterContentHealthTool(mcpServer)
+	tools.RegisterGetContentPropertiesTool(mcpServer)
+	tools.RegisterSetContentPropertyTool(mcpServer)
+	tools.RegisterDeleteContentPropertyTool(mcpServer)
+	tools.RegisterFindPagesByPropertyTool(mcpServer)
+	tools.RegisterCreateDraftTool(mcpServer)
+	tools.RegisterUpdateDraftTool(mcpServer)
+	tools.RegisterPublishDraftTool(mcpServer)
+	tools.RegisterListDraftsTool(mcpServer)
+	tools.RegisterListBlogPostsTool(mcpServer)
+	tools.RegisterGetBlogPostTool(mcpServer)
+	tools.RegisterCreateBlogPostTool(mcpServer)
+	tools.RegisterUpdateBlogPostTool(mcpServer)
 	tools.RegisterUpdatePageTool(mcpServer)
+	tools.RegisterGetPageRestrictionsTool(mcpServer)
+	tools.RegisterSetPageRestrictionsTool(mcpServer)
 	tools.RegisterGetCommentsPageTool(mcpServer)
 	tools.RegisterListSpacesTool(mcpServer)
+	tools.RegisterGetSpaceTool(mcpServer)
+	tools.RegisterGetSpaceHomepageTool(mcpServer)
+	tools.RegisterCreateSpaceTool(mcpServer)
+	tools.RegisterGetSpacePermissionsTool(mcpServer)
+
+	// Register Confluence resources
+	resources.RegisterPageResource(mcpServer)
+	resources.RegisterSpaceResource(mcpServer)
+
+	// Register Confluence prompts
+	prompts.RegisterSummarizePagePrompt(mcpServer)
+	prompts.RegisterSummarizeSpacePrompt(mcpServer)
+	prompts.RegisterDraftPageFromTemplatePrompt(mcpServer)
+	prompts.RegisterReviewCommentsPrompt(mcpServer)
+	prompts.RegisterMeetingNotesPrompt(mcpServer)
+
+	subscriptions = resources.NewSubscriptions(mcpServer)
+	go subscriptions.Run(context.Background())
+	extensions := resources.NewExtensions(subscriptions)
 
 	 // Setup signal handling
 	sigChan := make(chan os.Signal, 1)
@@ -68,7 +142,37 @@ func main() {
 	go func() {
 		if *streamableHttpPort != "" {
 			log.Println("Add endpoint path http://localhost:" + *streamableHttpPort + "/mcp")
-			streamableHttpServer := server.NewStreamableHTTPServer(mcpServer, server.WithEndpointPath("/mcp"),)
+			mux := http.NewServeMux()
+			streamableHttpServer := server.NewStreamableHTTPServer(mcpServer,
+				server.WithEndpointPath("/mcp"),
+				server.WithStreamableHTTPServer(&http.Server{Handler: mux}),
+			)
+			mux.Handle("/mcp", extensions.WrapHTTP(streamableHttpServer))
+
+			// OAuth login for the HTTP transport: opt-in, since the token obtained replaces the
+			// credentials every tool call uses, and guarded by a shared secret
+			if *oauthLogin {
+				secret := os.Getenv("CONFLUENCE_MCP_OAUTH_LOGIN_SECRET")
+				if secret == "" {
+					log.Fatal("--oauth_login requires CONFLUENCE_MCP_OAUTH_LOGIN_SECRET to be set")
+				}
+				redirectURI := os.Getenv("ATLASSIAN_OAUTH_REDIRECT_URI")
+				if redirectURI == "" {
+					redirectURI = "http://localhost:" + *streamableHttpPort + "/oauth/callback"
+				}
+				oauthHandler := services.NewOAuthHandler(redirectURI, func(token *services.OAuthToken, err error) {
+					if err != nil {
+						log.Printf("OAuth login failed: %v", err)
+						return
+					}
+					log.Printf("OAuth login succeeded for %s", token.SiteURL)
+					services.ResetClient()
+				})
+				oauthHandler.RequireSecret(secret)
+				mux.HandleFunc("/oauth/login", oauthHandler.ServeLogin)
+				mux.HandleFunc("/oauth/callback", oauthHandler.ServeCallback)
+			}
+
 			cleanupFunc = func() {
 				log.Println("Stopping Streamable HTTP server")
 				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -82,7 +186,7 @@ func main() {
 				log.Fatalf("Server error: %v", err)
 			}
 		} else {
-			if err := server.ServeStdio(mcpServer); err != nil {
+			if err := extensions.ServeStdio(context.Background(), mcpServer); err != nil {
 				cleanupFunc = func() {
 					log.Println("Stopping stdio server")
 				}
@@ -0,0 +1,73 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// draftPageFromTemplateHandler embeds a template page and asks for a new page following its structure
+func draftPageFromTemplateHandler(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	arguments := request.Params.Arguments
+	templateID, err := required(arguments, "template_page_id")
+	if err != nil {
+		return nil, err
+	}
+	topic, err := required(arguments, "topic")
+	if err != nil {
+		return nil, err
+	}
+	client, cfg, err := clientFor(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	template, page, err := pageMessage(ctx, client, cfg, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	spaceKey := arguments["space_key"]
+	if spaceKey == "" {
+		spaceKey = cfg.DefaultSpace
+	}
+	if spaceKey == "" {
+		spaceKey = template.SpaceKey
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Draft a new Confluence page about \"%s\" using the page \"%s\" above as the template.\n\n", topic, template.Title)
+	b.WriteString("- Keep the template's headings, order and tables; replace placeholder and example text with content for the new topic.\n")
+	b.WriteString("- Mark anything you cannot fill in from the notes below as TODO instead of guessing.\n")
+	if notes := arguments["notes"]; notes != "" {
+		fmt.Fprintf(&b, "- Base the content on these notes:\n\n%s\n\n", notes)
+	}
+	fmt.Fprintf(&b, "- Show me the draft first. Once I approve it, create it with the create_page tool in space %s", spaceKey)
+	if parentID := arguments["parent_id"]; parentID != "" {
+		fmt.Fprintf(&b, " under parent page %s", parentID)
+	}
+	b.WriteString(", converting the content to Confluence storage format (XHTML).")
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Draft %s from %s", topic, template.Title),
+		[]mcp.PromptMessage{page, userText(b.String())},
+	), nil
+}
+
+// RegisterDraftPageFromTemplatePrompt registers the draft_page_from_template prompt with the MCP server
+func RegisterDraftPageFromTemplatePrompt(s *server.MCPServer) {
+	prompt := mcp.NewPrompt("draft_page_from_template",
+		mcp.WithPromptDescription("Draft a new page, e.g. a release note, following the structure of an existing template page"),
+		mcp.WithArgument("template_page_id", mcp.RequiredArgument(), mcp.ArgumentDescription("Page to use as the template: "+services.PageReferenceHelp)),
+		mcp.WithArgument("topic", mcp.RequiredArgument(), mcp.ArgumentDescription("Subject of the new page, e.g. 'Release 2.4'")),
+		mcp.WithArgument("notes", mcp.ArgumentDescription("Raw notes or facts to base the content on")),
+		mcp.WithArgument("space_key", mcp.ArgumentDescription("Space to create the page in (defaults to the profile's default space, then the template's space)")),
+		mcp.WithArgument("parent_id", mcp.ArgumentDescription("ID of the parent page for the new page")),
+		withProfile(),
+	)
+	s.AddPrompt(prompt, draftPageFromTemplateHandler)
+}
@@ -0,0 +1,65 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nguyenvanduocit/confluence-mcp/resources"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// withProfile returns the optional profile argument shared by all prompts
+func withProfile() mcp.PromptOption {
+	return mcp.WithArgument("profile", mcp.ArgumentDescription("Configuration profile to use (default: active profile)"))
+}
+
+// clientFor returns the client and settings of the profile named by the prompt arguments
+func clientFor(arguments map[string]string) (*confluence.Client, *services.AtlassianConfig, error) {
+	client, err := services.ConfluenceClientFor(arguments["profile"])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize Confluence client: %w", err)
+	}
+	cfg, err := services.ConfigFor(arguments["profile"])
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, cfg, nil
+}
+
+// required returns a prompt argument or an error naming it when it is missing
+func required(arguments map[string]string, name string) (string, error) {
+	if value := arguments[name]; value != "" {
+		return value, nil
+	}
+	return "", fmt.Errorf("argument %q is required", name)
+}
+
+// pageMessage fetches a page, given by any reference ResolvePageID accepts, and embeds it as a
+// Markdown resource in a user message
+func pageMessage(ctx context.Context, client *confluence.Client, cfg *services.AtlassianConfig, reference string) (*services.PageDocument, mcp.PromptMessage, error) {
+	pageID, err := services.ResolvePageID(ctx, client, cfg, reference)
+	if err != nil {
+		return nil, mcp.PromptMessage{}, err
+	}
+	document, response, err := services.GetPageDocument(ctx, client, cfg, pageID)
+	if err != nil {
+		if response != nil {
+			return nil, mcp.PromptMessage{}, fmt.Errorf("failed to get page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+		}
+		return nil, mcp.PromptMessage{}, fmt.Errorf("failed to get page: %w", err)
+	}
+
+	message := mcp.NewPromptMessage(mcp.RoleUser, mcp.NewEmbeddedResource(mcp.TextResourceContents{
+		URI:      resources.PageURI(cfg.SiteName(), document.ID),
+		MIMEType: "text/markdown",
+		Text:     document.String(),
+	}))
+	return document, message, nil
+}
+
+// userText is a plain text user message
+func userText(text string) mcp.PromptMessage {
+	return mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent(text))
+}
@@ -0,0 +1,84 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// meetingNotesHandler turns raw notes into a structured meeting notes page
+func meetingNotesHandler(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	arguments := request.Params.Arguments
+	title, err := required(arguments, "title")
+	if err != nil {
+		return nil, err
+	}
+	notes, err := required(arguments, "notes")
+	if err != nil {
+		return nil, err
+	}
+
+	date := arguments["date"]
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+
+	var messages []mcp.PromptMessage
+	if previousID := arguments["previous_page_id"]; previousID != "" {
+		client, cfg, err := clientFor(arguments)
+		if err != nil {
+			return nil, err
+		}
+		_, previous, err := pageMessage(ctx, client, cfg, previousID)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, previous, userText("These are the notes of the previous meeting; carry over its action items that are not marked as done."))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write meeting notes titled \"%s - %s\" from the raw notes below.\n\n", date, title)
+	if attendees := arguments["attendees"]; attendees != "" {
+		fmt.Fprintf(&b, "Attendees: %s\n\n", attendees)
+	}
+	fmt.Fprintf(&b, "Raw notes:\n\n%s\n\n", notes)
+	b.WriteString(`Structure the page as:
+- Date and attendees
+- Goals
+- Discussion items as a table (topic, presenter, notes)
+- Decisions
+- Action items as a Confluence task list (<ac:task-list>), each with an owner and due date when known
+
+Keep the wording factual and do not add discussion that is not in the notes.`)
+	if spaceKey := arguments["space_key"]; spaceKey != "" {
+		fmt.Fprintf(&b, "\n\nAfter I confirm, create the page with the create_page tool in space %s", spaceKey)
+		if parentID := arguments["parent_id"]; parentID != "" {
+			fmt.Fprintf(&b, " under parent page %s", parentID)
+		}
+		b.WriteString(", in Confluence storage format (XHTML).")
+	}
+	messages = append(messages, userText(b.String()))
+
+	return mcp.NewGetPromptResult(fmt.Sprintf("Meeting notes: %s", title), messages), nil
+}
+
+// RegisterMeetingNotesPrompt registers the meeting_notes prompt with the MCP server
+func RegisterMeetingNotesPrompt(s *server.MCPServer) {
+	prompt := mcp.NewPrompt("meeting_notes",
+		mcp.WithPromptDescription("Turn raw meeting notes into a structured Confluence meeting notes page with decisions and action items"),
+		mcp.WithArgument("title", mcp.RequiredArgument(), mcp.ArgumentDescription("Meeting title")),
+		mcp.WithArgument("notes", mcp.RequiredArgument(), mcp.ArgumentDescription("Raw notes taken during the meeting")),
+		mcp.WithArgument("date", mcp.ArgumentDescription("Meeting date (default: today)")),
+		mcp.WithArgument("attendees", mcp.ArgumentDescription("Comma-separated attendees")),
+		mcp.WithArgument("previous_page_id", mcp.ArgumentDescription("Previous meeting's notes page, to carry over open action items: "+services.PageReferenceHelp)),
+		mcp.WithArgument("space_key", mcp.ArgumentDescription("Space to create the notes page in")),
+		mcp.WithArgument("parent_id", mcp.ArgumentDescription("ID of the parent page for the notes")),
+		withProfile(),
+	)
+	s.AddPrompt(prompt, meetingNotesHandler)
+}
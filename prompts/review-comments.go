@@ -0,0 +1,80 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// reviewCommentsHandler embeds a page with its comments and asks for a triage of the feedback
+func reviewCommentsHandler(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	arguments := request.Params.Arguments
+	pageID, err := required(arguments, "page_id")
+	if err != nil {
+		return nil, err
+	}
+	client, cfg, err := clientFor(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	document, page, err := pageMessage(ctx, client, cfg, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, response, err := client.Content.Comment.Gets(ctx, document.ID, []string{"body.storage", "version"}, nil, 0, 100)
+	if err != nil {
+		if response != nil {
+			return nil, fmt.Errorf("failed to get comments: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+		}
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Comments on \"%s\" (%d):\n\n", document.Title, len(comments.Results))
+	for i, comment := range comments.Results {
+		author, when := "unknown", ""
+		if comment.Version != nil {
+			when = comment.Version.When
+			if comment.Version.By != nil && comment.Version.By.DisplayName != "" {
+				author = comment.Version.By.DisplayName
+			}
+		}
+		body, err := services.StorageToMarkdown(services.ContentBody(comment), nil)
+		if err != nil {
+			body = services.ContentBody(comment)
+		}
+		fmt.Fprintf(&b, "### Comment %d by %s %s\n\n%s\n", i+1, author, when, body)
+	}
+
+	instructions := `Review the comments above against the page content.
+
+1. Group the comments by theme and say which part of the page each one refers to.
+2. List the questions that have not been answered in a later comment or on the page.
+3. Propose concrete edits to the page that would address the feedback, quoting the text to change.
+4. Flag disagreements between commenters that need a decision, and who is involved.`
+	if focus := arguments["focus"]; focus != "" {
+		instructions += "\n\nFocus on: " + focus
+	}
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Review comments on %s", document.Title),
+		[]mcp.PromptMessage{page, userText(b.String()), userText(instructions)},
+	), nil
+}
+
+// RegisterReviewCommentsPrompt registers the review_comments prompt with the MCP server
+func RegisterReviewCommentsPrompt(s *server.MCPServer) {
+	prompt := mcp.NewPrompt("review_comments",
+		mcp.WithPromptDescription("Triage the comments on a Confluence page: themes, open questions and proposed edits"),
+		mcp.WithArgument("page_id", mcp.RequiredArgument(), mcp.ArgumentDescription("Page whose comments to review: "+services.PageReferenceHelp)),
+		mcp.WithArgument("focus", mcp.ArgumentDescription("Aspect to focus the review on, e.g. 'security concerns'")),
+		withProfile(),
+	)
+	s.AddPrompt(prompt, reviewCommentsHandler)
+}
@@ -0,0 +1,62 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// summarizePageHandler embeds a page and asks for a summary aimed at the given audience
+func summarizePageHandler(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	arguments := request.Params.Arguments
+	pageID, err := required(arguments, "page_id")
+	if err != nil {
+		return nil, err
+	}
+	client, cfg, err := clientFor(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	document, page, err := pageMessage(ctx, client, cfg, pageID)
+	if err != nil {
+		return nil, err
+	}
+
+	audience := arguments["audience"]
+	if audience == "" {
+		audience = "a colleague who has not read the page"
+	}
+	length := arguments["length"]
+	if length == "" {
+		length = "one short paragraph followed by at most five bullet points"
+	}
+
+	instructions := fmt.Sprintf(`Summarize the Confluence page "%s" above for %s.
+
+- Length: %s.
+- Lead with the purpose of the page and its key decisions or conclusions.
+- List open questions, owners and dates if the page mentions them.
+- Do not invent information that is not on the page; say so if the page is empty or outdated.
+- End with a link to the page: %s`, document.Title, audience, length, document.URL)
+
+	return mcp.NewGetPromptResult(
+		fmt.Sprintf("Summarize %s", document.Title),
+		[]mcp.PromptMessage{page, userText(instructions)},
+	), nil
+}
+
+// RegisterSummarizePagePrompt registers the summarize_page prompt with the MCP server
+func RegisterSummarizePagePrompt(s *server.MCPServer) {
+	prompt := mcp.NewPrompt("summarize_page",
+		mcp.WithPromptDescription("Summarize a Confluence page for a given audience"),
+		mcp.WithArgument("page_id", mcp.RequiredArgument(), mcp.ArgumentDescription("Page to summarize: "+services.PageReferenceHelp)),
+		mcp.WithArgument("audience", mcp.ArgumentDescription("Who the summary is for, e.g. executives or new team members")),
+		mcp.WithArgument("length", mcp.ArgumentDescription("Desired length, e.g. 'three bullet points'")),
+		withProfile(),
+	)
+	s.AddPrompt(prompt, summarizePageHandler)
+}
@@ -0,0 +1,87 @@
+package prompts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// summarizeSpaceHandler embeds a space's homepage and page titles and asks for an overview of the space
+func summarizeSpaceHandler(ctx context.Context, request mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+	arguments := request.Params.Arguments
+	spaceKey, err := required(arguments, "space_key")
+	if err != nil {
+		return nil, err
+	}
+	client, cfg, err := clientFor(arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	space, response, err := client.Space.Get(ctx, spaceKey, []string{"homepage"})
+	if err != nil {
+		if response != nil {
+			return nil, fmt.Errorf("failed to get space: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+		}
+		return nil, fmt.Errorf("failed to get space: %w", err)
+	}
+
+	var messages []mcp.PromptMessage
+	if space.HomePage != nil && space.HomePage.ID != "" {
+		_, homepage, err := pageMessage(ctx, client, cfg, space.HomePage.ID)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, homepage)
+	}
+
+	options := &models.GetContentOptionsScheme{ContextType: "page", SpaceKey: spaceKey, Expand: []string{"version"}}
+	pages, response, err := client.Content.Gets(ctx, options, 0, 200)
+	if err != nil {
+		if response != nil {
+			return nil, fmt.Errorf("failed to list pages: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+		}
+		return nil, fmt.Errorf("failed to list pages: %w", err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pages in space %s (%s), first %d:\n\n", space.Name, space.Key, len(pages.Results))
+	for _, page := range pages.Results {
+		fmt.Fprintf(&b, "- %s (id %s", page.Title, page.ID)
+		if page.Version != nil && page.Version.When != "" {
+			fmt.Fprintf(&b, ", updated %s", page.Version.When)
+		}
+		b.WriteString(")\n")
+	}
+	messages = append(messages, userText(b.String()))
+
+	instructions := fmt.Sprintf(`Give an overview of the Confluence space %s from its homepage and page list above.
+
+- What the space is for and who it seems to serve.
+- The main areas of content, grouping related pages.
+- Pages that look outdated, duplicated or orphaned.
+- Which pages a newcomer should read first.
+
+Use the get_page tool if you need the content of a specific page.`, space.Key)
+	if focus := arguments["focus"]; focus != "" {
+		instructions += "\n\nFocus on: " + focus
+	}
+	messages = append(messages, userText(instructions))
+
+	return mcp.NewGetPromptResult(fmt.Sprintf("Summarize space %s", space.Key), messages), nil
+}
+
+// RegisterSummarizeSpacePrompt registers the summarize_space prompt with the MCP server
+func RegisterSummarizeSpacePrompt(s *server.MCPServer) {
+	prompt := mcp.NewPrompt("summarize_space",
+		mcp.WithPromptDescription("Give an overview of a Confluence space from its homepage and page list"),
+		mcp.WithArgument("space_key", mcp.RequiredArgument(), mcp.ArgumentDescription("Key of the space to summarize")),
+		mcp.WithArgument("focus", mcp.ArgumentDescription("Aspect to focus on, e.g. 'onboarding material'")),
+		withProfile(),
+	)
+	s.AddPrompt(prompt, summarizeSpaceHandler)
+}
@@ -0,0 +1,147 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// maxCompletionValues is the number of values the MCP specification allows in a completion result
+const maxCompletionValues = 100
+
+// completionRef is the resource reference of a completion/complete request
+type completionRef struct {
+	Type string `json:"type"`
+	URI  string `json:"uri"`
+}
+
+// Complete suggests values for a resource template variable: profile names for {site},
+// IDs of pages whose title matches the typed text for {id}, and space keys for {key}.
+// arguments holds the variables the client already completed, such as {site}.
+func Complete(ctx context.Context, ref completionRef, argument, value string, arguments map[string]string) (*mcp.CompleteResult, error) {
+	result := &mcp.CompleteResult{}
+	result.Completion.Values = []string{}
+	if ref.Type != "ref/resource" {
+		return result, nil
+	}
+
+	var values []string
+	var err error
+	switch {
+	case (ref.URI == PageURITemplate || ref.URI == SpaceURITemplate) && argument == "site":
+		values, err = completeSites(value)
+	case ref.URI == PageURITemplate && argument == "id":
+		values, err = completePageIDs(ctx, arguments["site"], value)
+	case (ref.URI == SpaceURITemplate || ref.URI == ActiveSpaceURITemplate) && argument == "key":
+		values, err = completeSpaceKeys(ctx, arguments["site"], value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Completion.Total = len(values)
+	if len(values) > maxCompletionValues {
+		values = values[:maxCompletionValues]
+		result.Completion.HasMore = true
+	}
+	result.Completion.Values = values
+	return result, nil
+}
+
+func completeSites(prefix string) ([]string, error) {
+	sites := make(map[string]bool)
+	if cfg, err := services.Config(); err == nil {
+		sites[cfg.SiteName()] = true
+	}
+	if file, err := services.LoadConfigFile(); err == nil {
+		for _, name := range file.ProfileNames() {
+			sites[name] = true
+		}
+	}
+
+	var values []string
+	for site := range sites {
+		if strings.HasPrefix(strings.ToLower(site), strings.ToLower(prefix)) {
+			values = append(values, site)
+		}
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+// completePageIDs searches pages by title on a site, the active one when site is empty, and
+// returns their IDs
+func completePageIDs(ctx context.Context, site, title string) ([]string, error) {
+	profile, err := services.ProfileForSite(site)
+	if err != nil {
+		return nil, err
+	}
+	client, err := services.ConfluenceClientFor(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Confluence client: %w", err)
+	}
+	cfg, err := services.ConfigFor(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	query := services.CQLQuery{Types: []string{"page"}, OrderBy: "lastmodified desc"}
+	if title = strings.TrimSpace(title); title != "" {
+		query.Title = title + "*"
+	}
+	cql, err := services.BuildCQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	results, response, err := services.SearchContent(ctx, client, cfg, cql, &models.SearchContentOptions{Limit: 20})
+	if err != nil {
+		if response != nil {
+			return nil, fmt.Errorf("failed to search pages: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+		}
+		return nil, fmt.Errorf("failed to search pages: %w", err)
+	}
+
+	var values []string
+	for _, result := range results.Results {
+		if result.Content != nil && result.Content.ID != "" {
+			values = append(values, result.Content.ID)
+		}
+	}
+	return values, nil
+}
+
+// completeSpaceKeys lists the keys of the spaces of a site, the active one when site is empty,
+// that start with prefix
+func completeSpaceKeys(ctx context.Context, site, prefix string) ([]string, error) {
+	profile, err := services.ProfileForSite(site)
+	if err != nil {
+		return nil, err
+	}
+	client, err := services.ConfluenceClientFor(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Confluence client: %w", err)
+	}
+
+	spaces, response, err := client.Space.Gets(ctx, &models.GetSpacesOptionScheme{}, 0, 250)
+	if err != nil {
+		if response != nil {
+			return nil, fmt.Errorf("failed to list spaces: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+		}
+		return nil, fmt.Errorf("failed to list spaces: %w", err)
+	}
+
+	var values []string
+	for _, space := range spaces.Results {
+		if strings.HasPrefix(strings.ToLower(space.Key), strings.ToLower(prefix)) {
+			values = append(values, space.Key)
+		}
+	}
+	sort.Strings(values)
+	return values, nil
+}
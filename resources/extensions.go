@@ -0,0 +1,273 @@
+package resources
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// Methods the MCP server library does not dispatch itself
+const (
+	methodSubscribe   = "resources/subscribe"
+	methodUnsubscribe = "resources/unsubscribe"
+	methodComplete    = "completion/complete"
+)
+
+// sessionIDHeader carries the session of a streamable HTTP request
+const sessionIDHeader = "Mcp-Session-Id"
+
+// stdioSessionID is the session ID the stdio transport registers its single client under
+const stdioSessionID = "stdio"
+
+// Extensions answers resources/subscribe, resources/unsubscribe and completion/complete
+// requests in front of the MCP server, which replies "method not found" to them, and adds the
+// completions capability to the initialize result. JSON-RPC batches are not supported, as by
+// the MCP server library and the 2025-06-18 revision of the specification; they get an error.
+type Extensions struct {
+	subscriptions *Subscriptions
+}
+
+// NewExtensions creates the request handler for subscriptions and completions
+func NewExtensions(subscriptions *Subscriptions) *Extensions {
+	return &Extensions{subscriptions: subscriptions}
+}
+
+type extensionRequest struct {
+	ID     *mcp.RequestId  `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// handles reports whether a raw JSON-RPC message is a request answered by the extensions
+func handles(message []byte) (*extensionRequest, bool) {
+	var request extensionRequest
+	if err := json.Unmarshal(message, &request); err != nil || request.ID == nil {
+		return nil, false
+	}
+	switch request.Method {
+	case methodSubscribe, methodUnsubscribe, methodComplete:
+		return &request, true
+	}
+	return nil, false
+}
+
+// isBatch reports whether a raw JSON-RPC message is a batch of messages
+func isBatch(message []byte) bool {
+	trimmed := bytes.TrimSpace(message)
+	return len(trimmed) > 0 && trimmed[0] == '['
+}
+
+// batchError is the reply to a batch
+func batchError() mcp.JSONRPCMessage {
+	return mcp.NewJSONRPCError(mcp.RequestId{}, mcp.INVALID_REQUEST, "JSON-RPC batches are not supported; send one request per message", nil)
+}
+
+// isInitialize reports whether a raw JSON-RPC message is an initialize request
+func isInitialize(message []byte) bool {
+	var request extensionRequest
+	return json.Unmarshal(message, &request) == nil && request.ID != nil && request.Method == string(mcp.MethodInitialize)
+}
+
+// advertiseCompletions adds the completions capability to an initialize result, which the
+// MCP server library has no option for, and returns other messages as they are
+func advertiseCompletions(message []byte) []byte {
+	var response struct {
+		JSONRPC string                     `json:"jsonrpc"`
+		ID      json.RawMessage            `json:"id"`
+		Result  map[string]json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(message, &response); err != nil || response.Result["serverInfo"] == nil {
+		return message
+	}
+	var capabilities map[string]json.RawMessage
+	if err := json.Unmarshal(response.Result["capabilities"], &capabilities); err != nil || capabilities == nil {
+		capabilities = map[string]json.RawMessage{}
+	}
+	capabilities["completions"] = json.RawMessage("{}")
+	encoded, err := json.Marshal(capabilities)
+	if err != nil {
+		return message
+	}
+	response.Result["capabilities"] = encoded
+	rewritten, err := json.Marshal(response)
+	if err != nil {
+		return message
+	}
+	if bytes.HasSuffix(message, []byte("\n")) {
+		rewritten = append(rewritten, '\n')
+	}
+	return rewritten
+}
+
+// handle answers an extension request on behalf of a session
+func (e *Extensions) handle(ctx context.Context, sessionID string, request *extensionRequest) mcp.JSONRPCMessage {
+	invalid := func(err error) mcp.JSONRPCMessage {
+		return mcp.NewJSONRPCError(*request.ID, mcp.INVALID_PARAMS, err.Error(), nil)
+	}
+
+	switch request.Method {
+	case methodSubscribe:
+		var params mcp.SubscribeParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return invalid(err)
+		}
+		if err := e.subscriptions.Subscribe(ctx, sessionID, params.URI); err != nil {
+			return invalid(err)
+		}
+	case methodUnsubscribe:
+		var params mcp.UnsubscribeParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return invalid(err)
+		}
+		e.subscriptions.Unsubscribe(sessionID, params.URI)
+	case methodComplete:
+		var params struct {
+			Ref      completionRef `json:"ref"`
+			Argument struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			} `json:"argument"`
+			Context struct {
+				Arguments map[string]string `json:"arguments"`
+			} `json:"context"`
+		}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return invalid(err)
+		}
+		result, err := Complete(ctx, params.Ref, params.Argument.Name, params.Argument.Value, params.Context.Arguments)
+		if err != nil {
+			return mcp.NewJSONRPCError(*request.ID, mcp.INTERNAL_ERROR, err.Error(), nil)
+		}
+		return mcp.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: *request.ID, Result: result}
+	}
+	return mcp.JSONRPCResponse{JSONRPC: mcp.JSONRPC_VERSION, ID: *request.ID, Result: mcp.EmptyResult{}}
+}
+
+// WrapHTTP answers extension requests posted to the streamable HTTP endpoint and passes
+// everything else to next
+func (e *Extensions) WrapHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		if isBatch(body) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(batchError())
+			return
+		}
+		if isInitialize(body) {
+			recorder := &responseRecorder{header: http.Header{}, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+			result := advertiseCompletions(recorder.body.Bytes())
+			for key, values := range recorder.header {
+				w.Header()[key] = values
+			}
+			w.Header().Del("Content-Length")
+			w.WriteHeader(recorder.status)
+			w.Write(result)
+			return
+		}
+
+		request, ok := handles(body)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		response := e.handle(r.Context(), r.Header.Get(sessionIDHeader), request)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	})
+}
+
+// ServeStdio serves the MCP server over stdin/stdout, answering extension requests itself
+func (e *Extensions) ServeStdio(ctx context.Context, s *server.MCPServer) error {
+	stdout := &lockedWriter{w: os.Stdout}
+	reader, writer := io.Pipe()
+
+	go func() {
+		defer writer.Close()
+		input := bufio.NewReader(os.Stdin)
+		for {
+			line, err := input.ReadBytes('\n')
+			if len(line) > 0 {
+				if isBatch(line) {
+					response, _ := json.Marshal(batchError())
+					stdout.Write(append(response, '\n'))
+				} else if request, ok := handles(line); ok {
+					response, _ := json.Marshal(e.handle(ctx, stdioSessionID, request))
+					stdout.Write(append(response, '\n'))
+				} else if _, err := writer.Write(line); err != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return server.NewStdioServer(s).Listen(ctx, reader, &initializeWriter{w: stdout})
+}
+
+// initializeWriter adds the completions capability to the initialize result the MCP server
+// writes; the server writes each message with a single call
+type initializeWriter struct {
+	w io.Writer
+}
+
+func (i *initializeWriter) Write(p []byte) (int, error) {
+	if _, err := i.w.Write(advertiseCompletions(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// responseRecorder keeps the response to an initialize request so it can be rewritten
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	return r.body.Write(p)
+}
+
+// lockedWriter serializes writes so responses from both handlers never interleave
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
@@ -0,0 +1,89 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// PageURITemplate addresses a page of a site, where the site is a profile name or host
+const PageURITemplate = "confluence://{site}/page/{id}"
+
+var pageURIPattern = regexp.MustCompile(`^confluence://([^/]+)/page/([^/?#]+)$`)
+
+// PageURI returns the resource URI of a page
+func PageURI(site, pageID string) string {
+	return fmt.Sprintf("confluence://%s/page/%s", site, pageID)
+}
+
+// parsePageURI returns the site and page ID of a page resource URI
+func parsePageURI(uri string) (string, string, bool) {
+	match := pageURIPattern.FindStringSubmatch(uri)
+	if match == nil {
+		return "", "", false
+	}
+	return match[1], match[2], true
+}
+
+// argument returns a URI template variable matched by the server
+func argument(request mcp.ReadResourceRequest, name string) string {
+	switch value := request.Params.Arguments[name].(type) {
+	case string:
+		return value
+	case []string:
+		if len(value) > 0 {
+			return value[0]
+		}
+	}
+	return ""
+}
+
+// pageResourceHandler renders a page as Markdown with a YAML front matter of its metadata
+func pageResourceHandler(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	site, pageID := argument(request, "site"), argument(request, "id")
+	if pageID == "" {
+		return nil, fmt.Errorf("invalid page resource URI: %s", request.Params.URI)
+	}
+
+	profile, err := services.ProfileForSite(site)
+	if err != nil {
+		return nil, err
+	}
+	client, err := services.ConfluenceClientFor(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Confluence client: %w", err)
+	}
+	cfg, err := services.ConfigFor(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	document, response, err := services.GetPageDocument(ctx, client, cfg, pageID)
+	if err != nil {
+		if response != nil {
+			return nil, fmt.Errorf("failed to get page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+		}
+		return nil, fmt.Errorf("failed to get page: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "text/markdown",
+			Text:     document.String(),
+		},
+	}, nil
+}
+
+// RegisterPageResource registers the page resource template with the MCP server
+func RegisterPageResource(s *server.MCPServer) {
+	template := mcp.NewResourceTemplate(PageURITemplate, "Confluence page",
+		mcp.WithTemplateDescription("Content of a Confluence page as Markdown. {site} is a profile name or the host of the site, {id} the page ID."),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	)
+	s.AddResourceTemplate(template, pageResourceHandler)
+}
@@ -0,0 +1,107 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// SpaceURITemplate addresses the page listing of a space of a site, where the site is a profile
+// name or host as in PageURITemplate
+const SpaceURITemplate = "confluence://{site}/space/{key}"
+
+// ActiveSpaceURITemplate addresses the page listing of a space on the active site
+const ActiveSpaceURITemplate = "confluence://space/{key}"
+
+// maxSpaceListing caps the number of pages listed for a space
+const maxSpaceListing = 1000
+
+// spaceResourceHandler lists the pages of a space as Markdown links to their page resources
+func spaceResourceHandler(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	site, spaceKey := argument(request, "site"), argument(request, "key")
+	if spaceKey == "" {
+		return nil, fmt.Errorf("invalid space resource URI: %s", request.Params.URI)
+	}
+
+	profile, err := services.ProfileForSite(site)
+	if err != nil {
+		return nil, err
+	}
+	client, err := services.ConfluenceClientFor(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Confluence client: %w", err)
+	}
+	cfg, err := services.ConfigFor(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	space, response, err := client.Space.Get(ctx, spaceKey, []string{"homepage"})
+	if err != nil {
+		if response != nil {
+			return nil, fmt.Errorf("failed to get space: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+		}
+		return nil, fmt.Errorf("failed to get space: %w", err)
+	}
+
+	var pages []*models.ContentScheme
+	options := &models.GetContentOptionsScheme{ContextType: "page", SpaceKey: spaceKey, OrderBy: "title"}
+	for start := 0; start < maxSpaceListing; start += 100 {
+		page, response, err := client.Content.Gets(ctx, options, start, 100)
+		if err != nil {
+			if response != nil {
+				return nil, fmt.Errorf("failed to list pages: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+			}
+			return nil, fmt.Errorf("failed to list pages: %w", err)
+		}
+		pages = append(pages, page.Results...)
+		if len(page.Results) < 100 {
+			break
+		}
+	}
+
+	if site == "" {
+		site = cfg.SiteName()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s (%s)\n\n", space.Name, space.Key)
+	if space.HomePage != nil && space.HomePage.ID != "" {
+		fmt.Fprintf(&b, "Homepage: [%s](%s)\n\n", space.HomePage.Title, PageURI(site, space.HomePage.ID))
+	}
+	fmt.Fprintf(&b, "%d pages", len(pages))
+	if len(pages) >= maxSpaceListing {
+		fmt.Fprintf(&b, " (listing truncated at %d)", maxSpaceListing)
+	}
+	b.WriteString(":\n\n")
+	for _, page := range pages {
+		fmt.Fprintf(&b, "- [%s](%s)\n", page.Title, PageURI(site, page.ID))
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      request.Params.URI,
+			MIMEType: "text/markdown",
+			Text:     b.String(),
+		},
+	}, nil
+}
+
+// RegisterSpaceResource registers the space listing resource templates with the MCP server
+func RegisterSpaceResource(s *server.MCPServer) {
+	template := mcp.NewResourceTemplate(SpaceURITemplate, "Confluence space",
+		mcp.WithTemplateDescription("Pages of a Confluence space as a Markdown list of page resource URIs. {site} is a profile name or the host of the site, {key} the space key."),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	)
+	s.AddResourceTemplate(template, spaceResourceHandler)
+
+	active := mcp.NewResourceTemplate(ActiveSpaceURITemplate, "Confluence space on the active site",
+		mcp.WithTemplateDescription("Pages of a Confluence space on the active site, as a Markdown list of page resource URIs"),
+		mcp.WithTemplateMIMEType("text/markdown"),
+	)
+	s.AddResourceTemplate(active, spaceResourceHandler)
+}
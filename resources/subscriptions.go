@@ -0,0 +1,166 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// defaultPollInterval is how often subscribed pages are checked for new versions
+const defaultPollInterval = time.Minute
+
+// Subscriptions tracks which sessions subscribed to which page resources and notifies
+// them with notifications/resources/updated when the page version changes
+type Subscriptions struct {
+	server   *server.MCPServer
+	interval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[string]map[string]bool // uri -> session IDs
+	versions    map[string]int             // uri -> last seen version
+}
+
+// NewSubscriptions creates a subscription tracker polling at CONFLUENCE_MCP_POLL_INTERVAL (default 1m)
+func NewSubscriptions(s *server.MCPServer) *Subscriptions {
+	interval := defaultPollInterval
+	if value := os.Getenv("CONFLUENCE_MCP_POLL_INTERVAL"); value != "" {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed > 0 {
+			interval = parsed
+		} else {
+			log.Printf("Warning: invalid CONFLUENCE_MCP_POLL_INTERVAL %q, using %s", value, defaultPollInterval)
+		}
+	}
+
+	return &Subscriptions{
+		server:      s,
+		interval:    interval,
+		subscribers: make(map[string]map[string]bool),
+		versions:    make(map[string]int),
+	}
+}
+
+// pageVersion fetches the current version number of a page resource
+func pageVersion(ctx context.Context, uri string) (int, error) {
+	site, pageID, ok := parsePageURI(uri)
+	if !ok {
+		return 0, fmt.Errorf("only page resources (%s) support subscriptions", PageURITemplate)
+	}
+	profile, err := services.ProfileForSite(site)
+	if err != nil {
+		return 0, err
+	}
+	client, err := services.ConfluenceClientFor(profile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to initialize Confluence client: %w", err)
+	}
+
+	page, response, err := client.Content.Get(ctx, pageID, []string{"version"}, 0)
+	if err != nil {
+		if response != nil {
+			return 0, fmt.Errorf("failed to get page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
+		}
+		return 0, fmt.Errorf("failed to get page: %w", err)
+	}
+	if page.Version == nil {
+		return 0, nil
+	}
+	return page.Version.Number, nil
+}
+
+// Subscribe registers a session for updates of a page resource
+func (s *Subscriptions) Subscribe(ctx context.Context, sessionID, uri string) error {
+	version, err := pageVersion(ctx, uri)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers[uri] == nil {
+		s.subscribers[uri] = make(map[string]bool)
+		s.versions[uri] = version
+	}
+	s.subscribers[uri][sessionID] = true
+	return nil
+}
+
+// Unsubscribe removes a session's subscription to a resource
+func (s *Subscriptions) Unsubscribe(sessionID, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(sessionID, uri)
+}
+
+// RemoveSession drops every subscription of a session, e.g. when it disconnects
+func (s *Subscriptions) RemoveSession(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for uri := range s.subscribers {
+		s.remove(sessionID, uri)
+	}
+}
+
+func (s *Subscriptions) remove(sessionID, uri string) {
+	delete(s.subscribers[uri], sessionID)
+	if len(s.subscribers[uri]) == 0 {
+		delete(s.subscribers, uri)
+		delete(s.versions, uri)
+	}
+}
+
+// Run polls the subscribed pages until the context is cancelled
+func (s *Subscriptions) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.poll(ctx)
+		}
+	}
+}
+
+// poll checks every subscribed page once and notifies the subscribers of changed pages
+func (s *Subscriptions) poll(ctx context.Context) {
+	s.mu.Lock()
+	uris := make([]string, 0, len(s.subscribers))
+	for uri := range s.subscribers {
+		uris = append(uris, uri)
+	}
+	s.mu.Unlock()
+
+	for _, uri := range uris {
+		version, err := pageVersion(ctx, uri)
+		if err != nil {
+			log.Printf("Failed to check %s for updates: %v", uri, err)
+			continue
+		}
+
+		s.mu.Lock()
+		previous, subscribed := s.versions[uri]
+		var sessions []string
+		if subscribed && version != previous {
+			s.versions[uri] = version
+			for sessionID := range s.subscribers[uri] {
+				sessions = append(sessions, sessionID)
+			}
+		}
+		s.mu.Unlock()
+
+		for _, sessionID := range sessions {
+			err := s.server.SendNotificationToSpecificClient(sessionID, string(mcp.MethodNotificationResourceUpdated), map[string]any{"uri": uri})
+			if err != nil {
+				log.Printf("Failed to notify session %s of %s update: %v", sessionID, uri, err)
+			}
+		}
+	}
+}
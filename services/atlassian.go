@@ -3,58 +3,281 @@ package services
 import (
 	"fmt"
 	"log"
-	"os"
+	"net/http"
+	"net/url"
+	"strings"
 	"sync"
 
 	"github.com/ctreminiom/go-atlassian/confluence"
 	"github.com/pkg/errors"
 )
 
+// Supported deployment types
+const (
+	DeploymentCloud      = "cloud"
+	DeploymentDataCenter = "datacenter"
+)
+
+// Supported authentication methods
+const (
+	AuthBasic = "basic"
+	AuthPAT   = "pat"
+	AuthOAuth = "oauth"
+)
+
 var (
-	clientOnce sync.Once
-	client     *confluence.Client
-	clientErr  error
+	clientMu sync.Mutex
+	clients  = make(map[string]*confluence.Client)
+	configs  = make(map[string]*AtlassianConfig)
 )
 
-func loadAtlassianCredentials() (host, mail, token string, err error) {
-	host = os.Getenv("ATLASSIAN_HOST")
-	mail = os.Getenv("ATLASSIAN_EMAIL")
-	token = os.Getenv("ATLASSIAN_TOKEN")
+// AtlassianConfig holds the connection settings for a Confluence site
+type AtlassianConfig struct {
+	Profile      string
+	Host         string
+	ContextPath  string
+	Deployment   string
+	AuthType     string
+	Email        string
+	Token        string
+	OAuth        *OAuthToken
+	DefaultSpace string
+	Output       string
+}
+
+// IsDataCenter reports whether the site is a Data Center / Server deployment
+func (c *AtlassianConfig) IsDataCenter() bool {
+	return c.Deployment == DeploymentDataCenter
+}
 
-	if host == "" || mail == "" || token == "" {
-		return "", "", "", fmt.Errorf("ATLASSIAN_HOST, ATLASSIAN_EMAIL, ATLASSIAN_TOKEN are required environment variables")
+// BaseURL returns the root URL of the Confluence web UI, including the context path
+func (c *AtlassianConfig) BaseURL() string {
+	if c.IsDataCenter() {
+		return c.Host + c.ContextPath
 	}
+	return c.Host + "/wiki"
+}
 
-	return host, mail, token, nil
+// WebURL builds an absolute web UI link from a path relative to the Confluence root
+func (c *AtlassianConfig) WebURL(path string) string {
+	if path == "" {
+		return ""
+	}
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return c.BaseURL() + path
 }
 
-func ConfluenceClient() (*confluence.Client, error) {
-	clientOnce.Do(func() {
-		host, mail, token, err := loadAtlassianCredentials()
+// SiteName identifies the site in resource URIs: the profile name, or the host name for the environment profile
+func (c *AtlassianConfig) SiteName() string {
+	if c.Profile != "" {
+		return c.Profile
+	}
+	return c.hostName()
+}
+
+// hostName returns the host without its scheme
+func (c *AtlassianConfig) hostName() string {
+	return strings.TrimPrefix(strings.TrimPrefix(c.Host, "https://"), "http://")
+}
+
+// DeploymentFromEnv returns the configured deployment type, defaulting to cloud
+func DeploymentFromEnv() string {
+	return envProfile().deployment()
+}
+
+// AuthTypeFromEnv returns the authentication method configured by the environment
+func AuthTypeFromEnv() string {
+	return envProfile().authType("")
+}
+
+func loadAtlassianCredentials(name string) (*AtlassianConfig, error) {
+	profile, err := lookupProfile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &AtlassianConfig{
+		Profile:      name,
+		Host:         profile.Host,
+		ContextPath:  profile.ContextPath,
+		Deployment:   profile.deployment(),
+		AuthType:     profile.authType(name),
+		Email:        profile.Email,
+		Token:        profile.token(),
+		DefaultSpace: profile.DefaultSpace,
+		Output:       profile.Output,
+	}
+
+	if cfg.AuthType == AuthOAuth {
+		token, err := LoadOAuthToken(name)
 		if err != nil {
-			clientErr = err
-			log.Printf("Failed to load Atlassian credentials: %v", err)
-			return
+			return nil, err
+		}
+		cfg.OAuth = token
+		cfg.Host = token.SiteURL
+		cfg.Deployment = DeploymentCloud
+		if err := cfg.normalize(); err != nil {
+			return nil, err
 		}
+		return cfg, nil
+	}
 
-		instance, err := confluence.New(nil, host)
-		if err != nil {
-			clientErr = errors.WithMessage(err, "failed to create confluence client")
-			log.Printf("Failed to create Confluence client: %v", clientErr)
-			return
+	if cfg.Host == "" || cfg.Token == "" {
+		if name != "" {
+			return nil, fmt.Errorf("profile %q requires a host and a token", name)
 		}
+		return nil, fmt.Errorf("ATLASSIAN_HOST and ATLASSIAN_TOKEN are required environment variables")
+	}
+	if cfg.AuthType == AuthBasic && cfg.Email == "" {
+		return nil, fmt.Errorf("an email (ATLASSIAN_EMAIL) is required when using basic authentication")
+	}
 
-		instance.Auth.SetBasicAuth(mail, token)
-		client = instance
-	})
+	if err := cfg.normalize(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// normalize splits a context path embedded in the host (e.g. https://example.com/confluence)
+// and ensures the host carries a scheme
+func (c *AtlassianConfig) normalize() error {
+	host := c.Host
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+
+	u, err := url.Parse(host)
+	if err != nil {
+		return errors.WithMessage(err, "invalid Confluence host")
+	}
 
-	if clientErr != nil {
-		return nil, clientErr
+	path := strings.TrimRight(u.Path, "/")
+	if c.IsDataCenter() && c.ContextPath == "" {
+		c.ContextPath = path
 	}
-	if client == nil {
-		return nil, fmt.Errorf("confluence client is not initialized")
+	// "" and "/" both mean Confluence is served from the root
+	if contextPath := strings.Trim(c.ContextPath, "/"); contextPath != "" {
+		c.ContextPath = "/" + contextPath
+	} else {
+		c.ContextPath = ""
 	}
 
-	return client, nil
+	c.Host = u.Scheme + "://" + u.Host
+	return nil
 }
 
+// Config returns the connection settings of the active profile
+func Config() (*AtlassianConfig, error) {
+	return ConfigFor("")
+}
+
+// ConfigFor returns the connection settings of the named profile.
+// An empty name selects the active profile (see ActiveProfileName).
+func ConfigFor(profile string) (*AtlassianConfig, error) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	return loadConfig(profile)
+}
+
+func loadConfig(profile string) (*AtlassianConfig, error) {
+	if profile == "" {
+		profile = ActiveProfileName()
+	}
+	if cfg, ok := configs[profile]; ok {
+		return cfg, nil
+	}
+
+	cfg, err := loadAtlassianCredentials(profile)
+	if err != nil {
+		return nil, err
+	}
+	configs[profile] = cfg
+	return cfg, nil
+}
+
+// ResetClient drops the cached clients and configurations, e.g. after a new OAuth login
+func ResetClient() {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	clients = make(map[string]*confluence.Client)
+	configs = make(map[string]*AtlassianConfig)
+}
+
+// dataCenterHttpClient rewrites the Cloud-style "/wiki/rest/..." endpoints built by
+// go-atlassian into "<context path>/rest/..." as served by Data Center / Server.
+type dataCenterHttpClient struct {
+	base        *http.Client
+	contextPath string
+}
+
+func (c *dataCenterHttpClient) Do(req *http.Request) (*http.Response, error) {
+	if strings.HasPrefix(req.URL.Path, "/wiki/") {
+		req.URL.Path = c.contextPath + strings.TrimPrefix(req.URL.Path, "/wiki")
+		req.URL.RawPath = ""
+	}
+
+	// Cloud treats version=0 as the latest version, Data Center expects it to be omitted
+	query := req.URL.Query()
+	if query.Get("version") == "0" {
+		query.Del("version")
+		req.URL.RawQuery = query.Encode()
+	}
+
+	return c.base.Do(req)
+}
+
+// ConfluenceClient returns the client of the active profile
+func ConfluenceClient() (*confluence.Client, error) {
+	return ConfluenceClientFor("")
+}
+
+// ConfluenceClientFor returns the client of the named profile.
+// An empty name selects the active profile (see ActiveProfileName).
+func ConfluenceClientFor(profile string) (*confluence.Client, error) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	cfg, err := loadConfig(profile)
+	if err != nil {
+		log.Printf("Failed to load Atlassian credentials: %v", err)
+		return nil, err
+	}
+
+	if client, ok := clients[cfg.Profile]; ok {
+		return client, nil
+	}
+
+	var instance *confluence.Client
+	switch {
+	case cfg.AuthType == AuthOAuth:
+		// OAuth tokens are only accepted through the api.atlassian.com gateway
+		instance, err = confluence.New(&oauthHttpClient{base: DefaultHttpClient(), profile: cfg.Profile, token: cfg.OAuth}, oauthAPIBaseURL+cfg.OAuth.CloudID)
+	case cfg.IsDataCenter():
+		instance, err = confluence.New(&dataCenterHttpClient{base: DefaultHttpClient(), contextPath: cfg.ContextPath}, cfg.Host)
+	default:
+		instance, err = confluence.New(nil, cfg.Host)
+	}
+	if err != nil {
+		err = errors.WithMessage(err, "failed to create confluence client")
+		log.Printf("Failed to create Confluence client: %v", err)
+		return nil, err
+	}
+
+	switch cfg.AuthType {
+	case AuthPAT:
+		instance.Auth.SetBearerToken(cfg.Token)
+	case AuthBasic:
+		instance.Auth.SetBasicAuth(cfg.Email, cfg.Token)
+	}
+	clients[cfg.Profile] = instance
+
+	return instance, nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// PageAttachments lists all attachments of a page, following pagination
+func PageAttachments(ctx context.Context, client *confluence.Client, pageID string) ([]*models.ContentScheme, *models.ResponseScheme, error) {
+	options := &models.GetContentAttachmentsOptionsScheme{Expand: []string{"version"}}
+	var attachments []*models.ContentScheme
+	for start := 0; ; start += 100 {
+		page, response, err := client.Content.Attachment.Gets(ctx, pageID, start, 100, options)
+		if err != nil {
+			return nil, response, err
+		}
+		attachments = append(attachments, page.Results...)
+		if len(page.Results) < 100 {
+			return attachments, response, nil
+		}
+	}
+}
+
+// DownloadAttachment fetches the content of an attachment through its download link
+func DownloadAttachment(ctx context.Context, client *confluence.Client, attachment *models.ContentScheme) ([]byte, *models.ResponseScheme, error) {
+	if attachment.Links == nil || attachment.Links.Download == "" {
+		return nil, nil, fmt.Errorf("attachment %s has no download link", attachment.Title)
+	}
+
+	// Download links are relative to the Confluence root, which the client addresses as "wiki/"
+	endpoint := "wiki/" + strings.TrimPrefix(attachment.Links.Download, "/")
+	request, err := client.NewRequest(ctx, http.MethodGet, endpoint, "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	request.Header.Set("Accept", "*/*")
+
+	response, err := client.Call(request, nil)
+	if err != nil {
+		return nil, response, err
+	}
+	return response.Bytes.Bytes(), response, nil
+}
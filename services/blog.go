@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// ContentTypeBlogPost is the content type of blog posts
+const ContentTypeBlogPost = "blogpost"
+
+var blogPostExpand = []string{"space", "version", "history"}
+
+// BlogPost is a blog post with its metadata
+type BlogPost struct {
+	ID        string `json:"id" yaml:"id"`
+	Title     string `json:"title" yaml:"title"`
+	SpaceKey  string `json:"space,omitempty" yaml:"space,omitempty"`
+	Author    string `json:"author,omitempty" yaml:"author,omitempty"`
+	Published string `json:"published,omitempty" yaml:"published,omitempty"`
+	Version   int    `json:"version,omitempty" yaml:"version,omitempty"`
+	Updated   string `json:"updated,omitempty" yaml:"updated,omitempty"`
+	URL       string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// NewBlogPost converts content fetched with the space, version and history expansions
+func NewBlogPost(cfg *AtlassianConfig, content *models.ContentScheme) *BlogPost {
+	post := &BlogPost{ID: content.ID, Title: content.Title}
+	if content.Space != nil {
+		post.SpaceKey = content.Space.Key
+	}
+	if content.History != nil {
+		post.Published = content.History.CreatedDate
+		post.Author = UserName(content.History.CreatedBy)
+	}
+	if content.Version != nil {
+		post.Version = content.Version.Number
+		post.Updated = content.Version.When
+	}
+	if content.Links != nil {
+		post.URL = cfg.WebURL(content.Links.Webui)
+	}
+	return post
+}
+
+// UserName returns the display name of a user, falling back to the public name and the username
+func UserName(user *models.ContentUserScheme) string {
+	if user == nil {
+		return ""
+	}
+	for _, name := range []string{user.DisplayName, user.PublicName, user.Username, user.AccountID} {
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// BlogPostQuery selects the blog posts to list
+type BlogPostQuery struct {
+	Spaces []string
+	From   string // published on or after: date (2024-01-31) or relative period (7d, 2w, 3M, 1y)
+	To     string // published before
+	Limit  int
+}
+
+// ListBlogPosts lists blog posts, newest first
+func ListBlogPosts(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, query BlogPostQuery) ([]*BlogPost, *models.ResponseScheme, error) {
+	cql, err := BuildCQL(CQLQuery{
+		Types:         []string{ContentTypeBlogPost},
+		Spaces:        query.Spaces,
+		CreatedAfter:  query.From,
+		CreatedBefore: query.To,
+		OrderBy:       "created desc",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if query.Limit <= 0 {
+		query.Limit = 25
+	}
+
+	posts := []*BlogPost{}
+	cursor := ""
+	for len(posts) < query.Limit {
+		contents, response, err := client.Content.Search(ctx, cql, "", blogPostExpand, cursor, min(query.Limit-len(posts), 100))
+		if err != nil {
+			return nil, response, err
+		}
+		for _, content := range contents.Results {
+			posts = append(posts, NewBlogPost(cfg, content))
+		}
+		cursor = nextCursor(contents.Links)
+		if cursor == "" || len(contents.Results) == 0 {
+			break
+		}
+	}
+	return posts, nil, nil
+}
+
+// nextCursor returns the cursor of the next result page from its link, or "" on the last page
+func nextCursor(links *models.LinkScheme) string {
+	if links == nil || links.Next == "" {
+		return ""
+	}
+	next, err := url.Parse(links.Next)
+	if err != nil {
+		return ""
+	}
+	return next.Query().Get("cursor")
+}
+
+// GetBlogPost fetches a blog post with its storage body. Content of another type is an error.
+func GetBlogPost(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, id string) (*BlogPost, string, *models.ResponseScheme, error) {
+	content, response, err := client.Content.Get(ctx, id, append([]string{"body.storage"}, blogPostExpand...), 0)
+	if err != nil {
+		return nil, "", response, err
+	}
+	if content.Type != ContentTypeBlogPost {
+		return nil, "", nil, fmt.Errorf("content %s is a %s, not a blog post", id, content.Type)
+	}
+	return NewBlogPost(cfg, content), ContentBody(content), response, nil
+}
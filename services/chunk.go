@@ -0,0 +1,142 @@
+package services
+
+import (
+	"strings"
+)
+
+// DefaultChunkTokens is the default upper bound of the size of a chunk
+const DefaultChunkTokens = 300
+
+// Chunk is a passage of a page: part of one section, with the headings leading to it
+type Chunk struct {
+	PageID    string   `json:"page_id" yaml:"page_id"`
+	PageTitle string   `json:"page_title" yaml:"page_title"`
+	SpaceKey  string   `json:"space,omitempty" yaml:"space,omitempty"`
+	URL       string   `json:"link,omitempty" yaml:"link,omitempty"`
+	Section   []string `json:"section" yaml:"section"` // breadcrumb: page title, then headings
+	Text      string   `json:"text" yaml:"text"`
+	Tokens    int      `json:"tokens" yaml:"tokens"`
+}
+
+// Breadcrumb joins the section path of a chunk, e.g. "Runbook > Deploy > Rollback"
+func (c *Chunk) Breadcrumb() string {
+	return strings.Join(c.Section, " > ")
+}
+
+// ApproxTokens estimates the number of model tokens of a text, at about four tokens per three words
+func ApproxTokens(text string) int {
+	return (len(strings.Fields(text))*4 + 2) / 3
+}
+
+// ChunkDocument splits a page into chunks of at most maxTokens tokens. Sections are delimited by
+// headings; a section that is too large is split between paragraphs, and a paragraph that is
+// still too large between words. Fenced code blocks are kept in one piece when they fit.
+func ChunkDocument(document *PageDocument, maxTokens int) []Chunk {
+	if maxTokens <= 0 {
+		maxTokens = DefaultChunkTokens
+	}
+
+	type heading struct {
+		level int
+		text  string
+	}
+	var chunks []Chunk
+	var stack []heading
+	var section []string
+	flush := func() {
+		path := []string{document.Title}
+		for _, h := range stack {
+			path = append(path, h.text)
+		}
+		for _, text := range splitSection(section, maxTokens) {
+			chunks = append(chunks, Chunk{
+				PageID:    document.ID,
+				PageTitle: document.Title,
+				SpaceKey:  document.SpaceKey,
+				URL:       document.URL,
+				Section:   path,
+				Text:      text,
+				Tokens:    ApproxTokens(text),
+			})
+		}
+		section = nil
+	}
+
+	inFence := false
+	for _, line := range strings.Split(document.Markdown, "\n") {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+		if match := headingPattern.FindStringSubmatch(line); match != nil && !inFence {
+			flush()
+			level := len(match[1])
+			for len(stack) > 0 && stack[len(stack)-1].level >= level {
+				stack = stack[:len(stack)-1]
+			}
+			stack = append(stack, heading{level: level, text: match[2]})
+			continue
+		}
+		section = append(section, line)
+	}
+	flush()
+	return chunks
+}
+
+// splitSection packs the paragraphs of a section into pieces of at most maxTokens tokens
+func splitSection(lines []string, maxTokens int) []string {
+	var blocks []string
+	var current []string
+	inFence := false
+	for _, line := range lines {
+		if fencePattern.MatchString(line) {
+			inFence = !inFence
+		}
+		if strings.TrimSpace(line) == "" && !inFence {
+			if len(current) > 0 {
+				blocks = append(blocks, strings.Join(current, "\n"))
+				current = nil
+			}
+			continue
+		}
+		current = append(current, line)
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, strings.Join(current, "\n"))
+	}
+
+	var pieces []string
+	var piece []string
+	size := 0
+	emit := func() {
+		if len(piece) > 0 {
+			pieces = append(pieces, strings.Join(piece, "\n\n"))
+			piece, size = nil, 0
+		}
+	}
+	for _, block := range blocks {
+		tokens := ApproxTokens(block)
+		if tokens > maxTokens {
+			emit()
+			pieces = append(pieces, splitWords(block, maxTokens)...)
+			continue
+		}
+		if size+tokens > maxTokens {
+			emit()
+		}
+		piece = append(piece, block)
+		size += tokens
+	}
+	emit()
+	return pieces
+}
+
+// splitWords cuts a long block into pieces of at most maxTokens tokens
+func splitWords(block string, maxTokens int) []string {
+	words := strings.Fields(block)
+	perPiece := max(maxTokens*3/4, 1)
+	var pieces []string
+	for start := 0; start < len(words); start += perPiece {
+		pieces = append(pieces, strings.Join(words[start:min(start+perPiece, len(words))], " "))
+	}
+	return pieces
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"gopkg.in/yaml.v3"
+)
+
+// ContentBody returns the storage body of content, falling back to the view body
+func ContentBody(content *models.ContentScheme) string {
+	if content == nil || content.Body == nil {
+		return ""
+	}
+	if content.Body.Storage != nil && content.Body.Storage.Value != "" {
+		return content.Body.Storage.Value
+	}
+	if content.Body.View != nil {
+		return content.Body.View.Value
+	}
+	return ""
+}
+
+// PageDocument is a page rendered as Markdown together with its metadata
+type PageDocument struct {
+	ID       string `json:"id" yaml:"id"`
+	Title    string `json:"title" yaml:"title"`
+	SpaceKey string `json:"space,omitempty" yaml:"space,omitempty"`
+	Version  int    `json:"version,omitempty" yaml:"version,omitempty"`
+	Updated  string `json:"updated,omitempty" yaml:"updated,omitempty"`
+	URL      string `json:"url,omitempty" yaml:"url,omitempty"`
+	Markdown string `json:"-" yaml:"-"`
+}
+
+// GetPageDocument fetches a page and converts its body to Markdown
+func GetPageDocument(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, pageID string) (*PageDocument, *models.ResponseScheme, error) {
+	page, response, err := client.Content.Get(ctx, pageID, []string{"body.storage", "version", "space"}, 0)
+	if err != nil {
+		return nil, response, err
+	}
+
+	markdown, err := StorageToMarkdown(ContentBody(page), nil)
+	if err != nil {
+		return nil, response, err
+	}
+
+	document := &PageDocument{ID: page.ID, Title: page.Title, Markdown: markdown}
+	if page.Space != nil {
+		document.SpaceKey = page.Space.Key
+	}
+	if page.Version != nil {
+		document.Version = page.Version.Number
+		document.Updated = page.Version.When
+	}
+	if page.Links != nil {
+		document.URL = cfg.WebURL(page.Links.Webui)
+	}
+	return document, response, nil
+}
+
+// String renders the document with a YAML front matter of its metadata and the title as heading
+func (d *PageDocument) String() string {
+	frontMatter, _ := yaml.Marshal(d)
+	return "---\n" + string(frontMatter) + "---\n\n# " + d.Title + "\n\n" + d.Markdown
+}
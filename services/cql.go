@@ -0,0 +1,637 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// CQLQuery describes a content search by fields; BuildCQL turns it into escaped CQL
+type CQLQuery struct {
+	Text           string   // words anywhere in the content
+	Title          string   // words in the title
+	ExactTitle     bool     // match the title exactly instead of by words
+	Spaces         []string // space keys
+	Labels         []string // any of these labels
+	ExcludeLabels  []string // none of these labels
+	Types          []string // page, blogpost, comment, attachment...
+	Contributor    string   // account ID (username on Data Center), or "me"
+	Creator        string   // account ID (username on Data Center), or "me"
+	Ancestor       string   // page ID the content is below
+	Parent         string   // page ID the content is a direct child of
+	CreatedAfter   string   // date (2024-01-31) or relative period (7d, 2w, 3M, 1y)
+	CreatedBefore  string
+	ModifiedAfter  string
+	ModifiedBefore string
+	OrderBy        string // e.g. "lastmodified desc"
+}
+
+var (
+	cqlDatePattern     = regexp.MustCompile(`^\d{4}[-/]\d{2}[-/]\d{2}( \d{2}:\d{2})?$`)
+	cqlPeriodPattern   = regexp.MustCompile(`^-?(\d+)([hdwMy])$`)
+	cqlUnquotedPattern = regexp.MustCompile(`^[\p{L}\p{N}_.@-]+$`)
+)
+
+// BuildCQL builds a CQL query from its fields, quoting and escaping every value
+func BuildCQL(q CQLQuery) (string, error) {
+	var clauses []string
+	add := func(format string, args ...interface{}) {
+		clauses = append(clauses, fmt.Sprintf(format, args...))
+	}
+
+	if text := strings.TrimSpace(q.Text); text != "" {
+		add("text ~ %s", quoteCQL(text))
+	}
+	if title := strings.TrimSpace(q.Title); title != "" {
+		if q.ExactTitle {
+			add("title = %s", quoteCQL(title))
+		} else {
+			add("title ~ %s", quoteCQL(title))
+		}
+	}
+	if len(q.Types) > 0 {
+		types := make([]string, len(q.Types))
+		for i, t := range q.Types {
+			normalized, ok := cqlTypeAliases[strings.ToLower(strings.TrimSpace(t))]
+			if !ok {
+				return "", fmt.Errorf("unknown content type %q (use %s)", t, strings.Join(cqlContentTypes, ", "))
+			}
+			types[i] = normalized
+		}
+		add("type %s", cqlIn(types))
+	}
+	if len(q.Spaces) > 0 {
+		add("space %s", cqlIn(q.Spaces))
+	}
+	if len(q.Labels) > 0 {
+		add("label %s", cqlIn(q.Labels))
+	}
+	for _, label := range q.ExcludeLabels {
+		add("label != %s", quoteCQL(label))
+	}
+	if q.Contributor != "" {
+		add("contributor = %s", cqlUser(q.Contributor))
+	}
+	if q.Creator != "" {
+		add("creator = %s", cqlUser(q.Creator))
+	}
+	if q.Ancestor != "" {
+		add("ancestor = %s", quoteCQL(q.Ancestor))
+	}
+	if q.Parent != "" {
+		add("parent = %s", quoteCQL(q.Parent))
+	}
+
+	for _, r := range []struct{ field, op, value, name string }{
+		{"created", ">=", q.CreatedAfter, "created_after"},
+		{"created", "<", q.CreatedBefore, "created_before"},
+		{"lastmodified", ">=", q.ModifiedAfter, "modified_after"},
+		{"lastmodified", "<", q.ModifiedBefore, "modified_before"},
+	} {
+		if r.value == "" {
+			continue
+		}
+		value, err := cqlDate(r.value)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", r.name, err)
+		}
+		add("%s %s %s", r.field, r.op, value)
+	}
+
+	if len(clauses) == 0 {
+		return "", fmt.Errorf("at least one search field is required")
+	}
+	cql := strings.Join(clauses, " and ")
+
+	if orderBy := strings.TrimSpace(q.OrderBy); orderBy != "" {
+		parts := strings.Fields(orderBy)
+		if len(parts) > 2 || !cqlOrderFields[strings.ToLower(parts[0])] {
+			return "", fmt.Errorf("invalid order %q (use a field such as created, lastmodified or title, optionally followed by asc or desc)", orderBy)
+		}
+		if len(parts) == 2 && !strings.EqualFold(parts[1], "asc") && !strings.EqualFold(parts[1], "desc") {
+			return "", fmt.Errorf("invalid order direction %q (use asc or desc)", parts[1])
+		}
+		cql += " order by " + strings.ToLower(strings.Join(parts, " "))
+	}
+	return cql, nil
+}
+
+// escapeCQL escapes a value for use inside a double-quoted CQL string
+func escapeCQL(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
+
+func quoteCQL(value string) string {
+	return `"` + escapeCQL(value) + `"`
+}
+
+// cqlIn renders "= value" for one value and "in (...)" for several
+func cqlIn(values []string) string {
+	if len(values) == 1 {
+		return "= " + quoteCQL(values[0])
+	}
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = quoteCQL(value)
+	}
+	return "in (" + strings.Join(quoted, ", ") + ")"
+}
+
+func cqlUser(user string) string {
+	switch strings.ToLower(user) {
+	case "me", "currentuser", "currentuser()":
+		return "currentUser()"
+	}
+	return quoteCQL(user)
+}
+
+// cqlDate renders a date or a relative period (7d is seven days ago) as a CQL date value
+func cqlDate(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if cqlDatePattern.MatchString(value) {
+		return quoteCQL(value), nil
+	}
+	if match := cqlPeriodPattern.FindStringSubmatch(value); match != nil {
+		return fmt.Sprintf(`now("-%s%s")`, match[1], match[2]), nil
+	}
+	return "", fmt.Errorf("invalid date %q (use YYYY-MM-DD or a period such as 7d, 2w, 3M or 1y)", value)
+}
+
+// CQLIssue is a problem found in a CQL query
+type CQLIssue struct {
+	Severity string `json:"severity" yaml:"severity"` // error or warning
+	Column   int    `json:"column" yaml:"column"`     // 1-based position in the query
+	Message  string `json:"message" yaml:"message"`
+	Syntax   bool   `json:"syntax,omitempty" yaml:"syntax,omitempty"` // the query cannot be parsed; other errors are likely but not certain to be rejected
+}
+
+// CQLLint is the result of checking a CQL query
+type CQLLint struct {
+	CQL    string     `json:"cql" yaml:"cql"`
+	Valid  bool       `json:"valid" yaml:"valid"`
+	Issues []CQLIssue `json:"issues,omitempty" yaml:"issues,omitempty"`
+}
+
+// Errors returns the messages of the errors of the lint, with their position
+func (l *CQLLint) Errors() []string {
+	return l.messages("error")
+}
+
+// Warnings returns the messages of the warnings of the lint, with their position
+func (l *CQLLint) Warnings() []string {
+	return l.messages("warning")
+}
+
+// SyntaxErrors returns the messages of the errors that make the query unparseable
+func (l *CQLLint) SyntaxErrors() []string {
+	var messages []string
+	for _, issue := range l.Issues {
+		if issue.Syntax {
+			messages = append(messages, fmt.Sprintf("column %d: %s", issue.Column, issue.Message))
+		}
+	}
+	return messages
+}
+
+// Findings returns the messages of the errors and warnings that do not make the query unparseable
+func (l *CQLLint) Findings() []string {
+	var messages []string
+	for _, issue := range l.Issues {
+		if !issue.Syntax {
+			messages = append(messages, fmt.Sprintf("column %d: %s: %s", issue.Column, issue.Severity, issue.Message))
+		}
+	}
+	return messages
+}
+
+func (l *CQLLint) messages(severity string) []string {
+	var messages []string
+	for _, issue := range l.Issues {
+		if issue.Severity == severity {
+			messages = append(messages, fmt.Sprintf("column %d: %s", issue.Column, issue.Message))
+		}
+	}
+	return messages
+}
+
+type cqlFieldKind int
+
+const (
+	cqlEquality  cqlFieldKind = iota // =, !=, in, not in
+	cqlText                          // ~, !~
+	cqlTitle                         // both of the above
+	cqlDateField                     // =, !=, <, <=, >, >=
+)
+
+// cqlFields are the fields Confluence accepts, by the operators they support
+var cqlFields = map[string]cqlFieldKind{
+	"ancestor": cqlEquality, "container": cqlEquality, "content": cqlEquality, "contributor": cqlEquality,
+	"creator": cqlEquality, "favourite": cqlEquality, "favorite": cqlEquality, "id": cqlEquality,
+	"label": cqlEquality, "macro": cqlEquality, "mention": cqlEquality, "parent": cqlEquality,
+	"space": cqlEquality, "space.key": cqlEquality, "space.type": cqlEquality, "space.category": cqlEquality,
+	"type": cqlEquality, "watcher": cqlEquality, "user": cqlEquality, "user.accountid": cqlEquality,
+	"user.userkey": cqlEquality, "space.title": cqlTitle, "title": cqlTitle, "user.fullname": cqlTitle,
+	"text": cqlText, "sitesearch": cqlText, "created": cqlDateField, "lastmodified": cqlDateField,
+}
+
+// cqlFieldAliases are field names commonly written for the real ones
+var cqlFieldAliases = map[string]string{
+	"spacekey": "space", "space_key": "space", "key": "space", "labels": "label", "tag": "label", "tags": "label",
+	"author": "creator", "owner": "creator", "createdby": "creator", "editor": "contributor",
+	"modified": "lastmodified", "updated": "lastmodified", "lastupdated": "lastmodified", "last_modified": "lastmodified",
+	"lastmodifieddate": "lastmodified", "createddate": "created", "creationdate": "created", "date": "lastmodified",
+	"parentid": "parent", "parent_id": "parent", "ancestorid": "ancestor", "name": "title", "body": "text",
+	"contenttype": "type", "content_type": "type", "pageid": "id", "page_id": "id",
+}
+
+var cqlContentTypes = []string{"page", "blogpost", "comment", "attachment", "space", "user", "whiteboard", "database", "folder", "embed"}
+
+// cqlTypeAliases maps type names to the CQL content types
+var cqlTypeAliases = map[string]string{
+	"blog": "blogpost", "blog post": "blogpost", "blog_post": "blogpost", "blogposts": "blogpost", "post": "blogpost",
+	"pages": "page", "comments": "comment", "attachments": "attachment", "file": "attachment",
+}
+
+func init() {
+	for _, t := range cqlContentTypes {
+		cqlTypeAliases[t] = t
+	}
+}
+
+var cqlOrderFields = map[string]bool{
+	"created": true, "lastmodified": true, "title": true, "id": true, "type": true, "space": true, "space.key": true, "space.title": true,
+}
+
+var cqlFunctions = map[string]bool{
+	"currentuser": true, "currentcontent": true, "currentspace": true, "now": true, "recentlyviewedcontent": true,
+	"recentlyviewedspaces": true, "favouritespaces": true, "favoritespaces": true,
+	"startofday": true, "startofweek": true, "startofmonth": true, "startofyear": true,
+	"endofday": true, "endofweek": true, "endofmonth": true, "endofyear": true,
+}
+
+type cqlTokenKind int
+
+const (
+	cqlWord cqlTokenKind = iota
+	cqlString
+	cqlOperator
+	cqlOpen
+	cqlClose
+	cqlComma
+	cqlEnd
+)
+
+type cqlToken struct {
+	kind  cqlTokenKind
+	text  string
+	value string // unquoted value of strings
+	pos   int
+}
+
+// lexCQL splits a query into tokens, reporting unterminated strings
+func lexCQL(cql string) ([]cqlToken, *CQLIssue) {
+	var tokens []cqlToken
+	runes := []rune(cql)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, cqlToken{kind: cqlOpen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, cqlToken{kind: cqlClose, text: ")", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, cqlToken{kind: cqlComma, text: ",", pos: i})
+			i++
+		case r == '"' || r == '\'':
+			start := i
+			var value strings.Builder
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				value.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, &CQLIssue{Severity: "error", Column: start + 1, Message: fmt.Sprintf("unterminated string: add the closing %c", r), Syntax: true}
+			}
+			i++
+			tokens = append(tokens, cqlToken{kind: cqlString, text: string(runes[start:i]), value: value.String(), pos: start})
+		case strings.ContainsRune("=!~<>", r):
+			start := i
+			i++
+			if i < len(runes) && (runes[i] == '=' || (r == '!' && runes[i] == '~')) {
+				i++
+			}
+			tokens = append(tokens, cqlToken{kind: cqlOperator, text: string(runes[start:i]), pos: start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`()",'=!~<>`, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, cqlToken{kind: cqlWord, text: string(runes[start:i]), value: string(runes[start:i]), pos: start})
+		}
+	}
+	return append(tokens, cqlToken{kind: cqlEnd, pos: len(runes)}), nil
+}
+
+// cqlParser checks a query against the CQL grammar:
+//
+//	query  := or [ORDER BY field [ASC|DESC] {, field [ASC|DESC]}]
+//	or     := and {OR and}
+//	and    := not {AND not}
+//	not    := NOT not | ( or ) | field operator value
+type cqlParser struct {
+	tokens []cqlToken
+	next   int
+	issues []CQLIssue
+}
+
+// cqlSyntaxError stops the parse at the first syntax error
+type cqlSyntaxError struct{}
+
+func (p *cqlParser) peek() cqlToken {
+	return p.tokens[p.next]
+}
+
+func (p *cqlParser) take() cqlToken {
+	t := p.tokens[p.next]
+	if t.kind != cqlEnd {
+		p.next++
+	}
+	return t
+}
+
+func (p *cqlParser) keyword(t cqlToken, words ...string) bool {
+	if t.kind != cqlWord {
+		return false
+	}
+	for _, word := range words {
+		if strings.EqualFold(t.text, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *cqlParser) report(severity string, t cqlToken, format string, args ...interface{}) {
+	p.issues = append(p.issues, CQLIssue{Severity: severity, Column: t.pos + 1, Message: fmt.Sprintf(format, args...)})
+}
+
+// syntax reports an error that makes the query unparseable
+func (p *cqlParser) syntax(t cqlToken, format string, args ...interface{}) {
+	p.issues = append(p.issues, CQLIssue{Severity: "error", Column: t.pos + 1, Message: fmt.Sprintf(format, args...), Syntax: true})
+}
+
+func (p *cqlParser) fail(t cqlToken, format string, args ...interface{}) {
+	p.syntax(t, format, args...)
+	panic(cqlSyntaxError{})
+}
+
+func describe(t cqlToken) string {
+	if t.kind == cqlEnd {
+		return "end of query"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func (p *cqlParser) query() {
+	if p.peek().kind == cqlEnd {
+		p.fail(p.peek(), "the query is empty")
+	}
+	p.or()
+	if t := p.peek(); p.keyword(t, "order") {
+		p.take()
+		if !p.keyword(p.take(), "by") {
+			p.fail(t, "ORDER must be followed by BY")
+		}
+		for {
+			field := p.take()
+			if field.kind != cqlWord {
+				p.fail(field, "expected a field to order by, found %s", describe(field))
+			}
+			if !cqlOrderFields[strings.ToLower(field.text)] {
+				p.report("warning", field, "%q may not be sortable; use created, lastmodified or title", field.text)
+			}
+			if p.keyword(p.peek(), "asc", "desc") {
+				p.take()
+			}
+			if p.peek().kind != cqlComma {
+				break
+			}
+			p.take()
+		}
+	}
+	switch t := p.peek(); {
+	case t.kind == cqlClose:
+		p.fail(t, "unbalanced parenthesis: no ( matches this )")
+	case t.kind != cqlEnd:
+		if p.tokens[p.next-1].kind == cqlWord || p.tokens[p.next-1].kind == cqlString {
+			p.fail(t, "unexpected %s after a value; quote values containing spaces, or join clauses with AND/OR", describe(t))
+		}
+		p.fail(t, "unexpected %s", describe(t))
+	}
+}
+
+func (p *cqlParser) or() {
+	p.and()
+	for p.keyword(p.peek(), "or") {
+		p.take()
+		p.and()
+	}
+}
+
+func (p *cqlParser) and() {
+	p.not()
+	for p.keyword(p.peek(), "and") {
+		p.take()
+		p.not()
+	}
+}
+
+func (p *cqlParser) not() {
+	t := p.peek()
+	switch {
+	case p.keyword(t, "not"):
+		p.take()
+		p.not()
+	case t.kind == cqlOpen:
+		p.take()
+		p.or()
+		if closing := p.take(); closing.kind != cqlClose {
+			p.fail(t, "unbalanced parenthesis: this ( is never closed")
+		}
+	default:
+		p.clause()
+	}
+}
+
+func (p *cqlParser) clause() {
+	field := p.take()
+	if field.kind != cqlWord || p.keyword(field, "and", "or", "order") {
+		p.fail(field, "expected a field, found %s", describe(field))
+	}
+	name := strings.ToLower(field.text)
+	kind, known := cqlFields[name]
+	if !known {
+		if alias, ok := cqlFieldAliases[name]; ok {
+			p.report("error", field, "unknown field %q; did you mean %q?", field.text, alias)
+			kind, known = cqlFields[alias], true
+		} else if !strings.HasPrefix(name, "content.property") && !strings.Contains(name, "[") {
+			p.report("warning", field, "unknown field %q", field.text)
+		}
+	}
+
+	op := p.take()
+	operator := op.text
+	switch {
+	case op.kind == cqlOperator:
+	case p.keyword(op, "in"):
+		operator = "in"
+	case p.keyword(op, "not") && p.keyword(p.peek(), "in"):
+		p.take()
+		operator = "not in"
+	default:
+		p.fail(op, "expected an operator after %q, found %s", field.text, describe(op))
+	}
+	if known {
+		p.checkOperator(field, kind, op, operator)
+	}
+
+	if operator == "in" || operator == "not in" {
+		if open := p.take(); open.kind != cqlOpen {
+			p.fail(open, "%s must be followed by a list of values in parentheses", strings.ToUpper(operator))
+		}
+		for {
+			p.value(name, kind)
+			t := p.take()
+			if t.kind == cqlClose {
+				break
+			}
+			if t.kind != cqlComma {
+				p.fail(t, "expected , or ) in the list of values, found %s", describe(t))
+			}
+		}
+		return
+	}
+	p.value(name, kind)
+}
+
+func (p *cqlParser) checkOperator(field cqlToken, kind cqlFieldKind, op cqlToken, operator string) {
+	var allowed []string
+	switch kind {
+	case cqlEquality:
+		allowed = []string{"=", "!=", "in", "not in"}
+	case cqlText:
+		allowed = []string{"~", "!~"}
+	case cqlTitle:
+		allowed = []string{"=", "!=", "~", "!~", "in", "not in"}
+	case cqlDateField:
+		allowed = []string{"=", "!=", "<", "<=", ">", ">="}
+	}
+	for _, a := range allowed {
+		if a == operator {
+			return
+		}
+	}
+	message := fmt.Sprintf("field %q does not support %s; use %s", field.text, operator, strings.Join(allowed, ", "))
+	if kind == cqlEquality && (operator == "~" || operator == "!~") {
+		message += " (only text, title, space.title and user.fullname support ~)"
+	}
+	p.report("error", op, "%s", message)
+}
+
+func (p *cqlParser) value(field string, kind cqlFieldKind) {
+	t := p.take()
+	switch t.kind {
+	case cqlString:
+		if strings.TrimSpace(t.value) == "" {
+			p.report("warning", t, "empty value")
+		}
+	case cqlWord:
+		if p.keyword(t, "and", "or", "not", "order") {
+			p.fail(t, "missing value before %s", strings.ToUpper(t.text))
+		}
+		if p.peek().kind == cqlOpen {
+			p.function(t)
+			return
+		}
+		if !cqlUnquotedPattern.MatchString(t.text) {
+			p.syntax(t, "value %s contains reserved characters; quote it", t.text)
+		}
+	default:
+		p.fail(t, "expected a value, found %s", describe(t))
+	}
+
+	switch {
+	case kind == cqlDateField && !cqlDatePattern.MatchString(t.value):
+		p.report("error", t, "invalid date %q; use \"yyyy-MM-dd\", \"yyyy-MM-dd HH:mm\" or a function such as now(\"-7d\")", t.value)
+	case field == "type":
+		if normalized, ok := cqlTypeAliases[strings.ToLower(t.value)]; !ok {
+			p.report("warning", t, "unknown content type %q; types are %s", t.value, strings.Join(cqlContentTypes, ", "))
+		} else if strings.EqualFold(normalized, t.value) && normalized != t.value {
+			p.report("warning", t, "content type %q is usually written %q", t.value, normalized)
+		} else if normalized != t.value {
+			p.report("error", t, "unknown content type %q; did you mean %q?", t.value, normalized)
+		}
+	case kind == cqlText && strings.TrimSpace(t.value) != "" && strings.Trim(t.value, "*?") == "":
+		p.report("error", t, "a text search needs at least one word")
+	}
+}
+
+func (p *cqlParser) function(name cqlToken) {
+	if !cqlFunctions[strings.ToLower(name.text)] {
+		p.report("warning", name, "unknown function %s()", name.text)
+	}
+	p.take()
+	if p.peek().kind == cqlClose {
+		p.take()
+		return
+	}
+	for {
+		arg := p.take()
+		if arg.kind != cqlString && arg.kind != cqlWord {
+			p.fail(arg, "expected an argument of %s(), found %s", name.text, describe(arg))
+		}
+		t := p.take()
+		if t.kind == cqlClose {
+			return
+		}
+		if t.kind != cqlComma {
+			p.fail(t, "expected , or ) in the arguments of %s(), found %s", name.text, describe(t))
+		}
+	}
+}
+
+// LintCQL checks a CQL query for syntax errors, unknown or misspelled fields, operators a field does
+// not support, unquoted values and malformed dates, without sending it to Confluence
+func LintCQL(cql string) *CQLLint {
+	lint := &CQLLint{CQL: cql}
+	tokens, issue := lexCQL(cql)
+	if issue != nil {
+		lint.Issues = []CQLIssue{*issue}
+		return lint
+	}
+
+	p := &cqlParser{tokens: tokens}
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if _, ok := r.(cqlSyntaxError); !ok {
+					panic(r)
+				}
+			}
+		}()
+		p.query()
+	}()
+
+	lint.Issues = p.issues
+	sort.SliceStable(lint.Issues, func(i, j int) bool { return lint.Issues[i].Column < lint.Issues[j].Column })
+	lint.Valid = len(lint.Errors()) == 0
+	return lint
+}
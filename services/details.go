@@ -0,0 +1,350 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// PageProperties are the key/value pairs of the Page Properties (details) macros of a page
+type PageProperties struct {
+	Keys   []string          // in the order of the page
+	Values map[string]string // by key
+}
+
+// ParsePageProperties reads the tables of the details macros of a storage format body. When
+// macroID is set, only macros with that ID are read. Tables can list one key per row, with the
+// key in the first cell, or have a header row of keys above a row of values.
+func ParsePageProperties(body, macroID string) (*PageProperties, error) {
+	root, err := parseStorage(body)
+	if err != nil {
+		return nil, err
+	}
+	properties := &PageProperties{Values: map[string]string{}}
+	var walk func(n *storageNode)
+	walk = func(n *storageNode) {
+		for _, c := range n.children {
+			if c.isText {
+				continue
+			}
+			if (c.name == "ac:structured-macro" || c.name == "ac:macro") && c.attr("ac:name") == "details" {
+				if macroID == "" || strings.EqualFold(strings.TrimSpace(c.param("id")), macroID) {
+					if table := findElement(c, "table"); table != nil {
+						properties.add(table)
+					}
+				}
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(root)
+	return properties, nil
+}
+
+func (p *PageProperties) add(table *storageNode) {
+	var rows [][]*storageNode
+	var walk func(n *storageNode)
+	walk = func(n *storageNode) {
+		for _, c := range n.children {
+			switch {
+			case c.isText:
+			case c.name == "tr":
+				var cells []*storageNode
+				for _, cell := range c.children {
+					if !cell.isText && (cell.name == "th" || cell.name == "td") {
+						cells = append(cells, cell)
+					}
+				}
+				rows = append(rows, cells)
+			case c.name != "table":
+				walk(c)
+			}
+		}
+	}
+	walk(table)
+
+	// A header row of keys above a row of values
+	if len(rows) == 2 && len(rows[0]) >= 2 && allHeaders(rows[0]) && !allHeaders(rows[1]) {
+		for i, cell := range rows[0] {
+			if i < len(rows[1]) {
+				p.set(nodeText(cell), nodeText(rows[1][i]))
+			}
+		}
+		return
+	}
+	for _, row := range rows {
+		if len(row) >= 2 {
+			p.set(nodeText(row[0]), nodeText(row[1]))
+		}
+	}
+}
+
+func allHeaders(cells []*storageNode) bool {
+	for _, cell := range cells {
+		if cell.name != "th" {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *PageProperties) set(key, value string) {
+	if key == "" {
+		return
+	}
+	if _, ok := p.Values[key]; !ok {
+		p.Keys = append(p.Keys, key)
+	}
+	p.Values[key] = value
+}
+
+// Get returns the value of a key compared without case
+func (p *PageProperties) Get(key string) (string, bool) {
+	if value, ok := p.Values[key]; ok {
+		return value, true
+	}
+	for _, k := range p.Keys {
+		if strings.EqualFold(k, key) {
+			return p.Values[k], true
+		}
+	}
+	return "", false
+}
+
+// nodeText returns the readable text of storage markup: status macros become their title, dates
+// their value, users their name and page links their title; paragraphs and list items go on
+// separate lines
+func nodeText(n *storageNode) string {
+	var b strings.Builder
+	var walk func(n *storageNode)
+	walk = func(n *storageNode) {
+		if n.isText {
+			b.WriteString(whitespaceRun.ReplaceAllString(n.text, " "))
+			return
+		}
+		switch n.name {
+		case "ac:parameter", "ac:placeholder":
+			return
+		case "ac:structured-macro", "ac:macro":
+			switch n.attr("ac:name") {
+			case "status":
+				b.WriteString(n.param("title"))
+			case "jira":
+				b.WriteString(n.param("key"))
+			default:
+				for _, c := range n.children {
+					walk(c)
+				}
+			}
+			return
+		case "time":
+			b.WriteString(n.attr("datetime"))
+			return
+		case "ri:user":
+			for _, name := range []string{"ri:username", "ri:account-id", "ri:userkey"} {
+				if value := n.attr(name); value != "" {
+					b.WriteString("@" + value)
+					return
+				}
+			}
+			return
+		case "ac:link":
+			if body := n.child("ac:link-body"); body != nil {
+				walk(body)
+				return
+			}
+			if body := n.child("ac:plain-text-link-body"); body != nil {
+				b.WriteString(body.textContent())
+				return
+			}
+			if page := n.child("ri:page"); page != nil {
+				b.WriteString(page.attr("ri:content-title"))
+				return
+			}
+		case "br":
+			b.WriteString("\n")
+			return
+		}
+		block := n.name == "p" || n.name == "li" || n.name == "div"
+		if block {
+			b.WriteString("\n")
+		}
+		for _, c := range n.children {
+			walk(c)
+		}
+		if block {
+			b.WriteString("\n")
+		}
+	}
+	walk(n)
+
+	var lines []string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PropertiesQuery selects the pages of a Page Properties report
+type PropertiesQuery struct {
+	Label   string
+	Spaces  []string
+	MacroID string   // only read details macros with this ID
+	Columns []string // keys to report, in order; default: every key found
+	Filters []string // "Key=Value" or "Key!=Value", compared without case
+	Limit   int      // maximum number of pages to read; 0 means 500
+}
+
+// PropertiesRow is a page of a Page Properties report
+type PropertiesRow struct {
+	ID     string            `json:"id" yaml:"id"`
+	Title  string            `json:"title" yaml:"title"`
+	URL    string            `json:"url,omitempty" yaml:"url,omitempty"`
+	Values map[string]string `json:"values" yaml:"values"`
+}
+
+// PropertiesReport is the table of a Page Properties report: a row per page, a column per key
+type PropertiesReport struct {
+	Label   string           `json:"label" yaml:"label"`
+	Columns []string         `json:"columns" yaml:"columns"`
+	Rows    []*PropertiesRow `json:"rows" yaml:"rows"`
+	Count   int              `json:"count" yaml:"count"`
+}
+
+type propertiesFilter struct {
+	key, value string
+	negate     bool
+}
+
+func parsePropertiesFilters(filters []string) ([]propertiesFilter, error) {
+	var parsed []propertiesFilter
+	for _, filter := range filters {
+		f := propertiesFilter{}
+		key, value, ok := strings.Cut(filter, "!=")
+		if ok {
+			f.negate = true
+		} else if key, value, ok = strings.Cut(filter, "="); !ok {
+			return nil, fmt.Errorf("invalid filter %q: use Key=Value or Key!=Value", filter)
+		}
+		f.key, f.value = strings.TrimSpace(key), strings.TrimSpace(value)
+		parsed = append(parsed, f)
+	}
+	return parsed, nil
+}
+
+// BuildPropertiesReport reads the details macros of the pages with a label, like the Page
+// Properties Report macro does
+func BuildPropertiesReport(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, query PropertiesQuery) (*PropertiesReport, *models.ResponseScheme, error) {
+	if query.Label == "" {
+		return nil, nil, fmt.Errorf("a label is required")
+	}
+	filters, err := parsePropertiesFilters(query.Filters)
+	if err != nil {
+		return nil, nil, err
+	}
+	cql, err := BuildCQL(CQLQuery{Labels: []string{query.Label}, Spaces: query.Spaces, Types: []string{"page"}, OrderBy: "title"})
+	if err != nil {
+		return nil, nil, err
+	}
+	if query.Limit <= 0 {
+		query.Limit = 500
+	}
+
+	report := &PropertiesReport{Label: query.Label, Columns: query.Columns, Rows: []*PropertiesRow{}}
+	seen := map[string]bool{}
+	read := 0
+	cursor := ""
+	for read < query.Limit {
+		contents, response, err := client.Content.Search(ctx, cql, "", []string{"body.storage"}, cursor, min(query.Limit-read, 50))
+		if err != nil {
+			return nil, response, err
+		}
+		for _, content := range contents.Results {
+			read++
+			properties, err := ParsePageProperties(ContentBody(content), query.MacroID)
+			if err != nil || len(properties.Keys) == 0 || !matchesPropertiesFilters(properties, filters) {
+				continue
+			}
+
+			row := &PropertiesRow{ID: content.ID, Title: content.Title, Values: map[string]string{}}
+			if content.Links != nil {
+				row.URL = cfg.WebURL(content.Links.Webui)
+			}
+			if len(query.Columns) > 0 {
+				for _, column := range query.Columns {
+					if value, ok := properties.Get(column); ok {
+						row.Values[column] = value
+					}
+				}
+			} else {
+				for _, key := range properties.Keys {
+					row.Values[key] = properties.Values[key]
+					if !seen[strings.ToLower(key)] {
+						seen[strings.ToLower(key)] = true
+						report.Columns = append(report.Columns, key)
+					}
+				}
+			}
+			report.Rows = append(report.Rows, row)
+		}
+		cursor = nextCursor(contents.Links)
+		if cursor == "" || len(contents.Results) == 0 {
+			break
+		}
+	}
+
+	// Keys spelled differently across pages fill the column of their first spelling
+	if len(query.Columns) == 0 {
+		for _, row := range report.Rows {
+			for key, value := range row.Values {
+				for _, column := range report.Columns {
+					if column != key && strings.EqualFold(column, key) {
+						row.Values[column] = value
+						delete(row.Values, key)
+					}
+				}
+			}
+		}
+	}
+	report.Count = len(report.Rows)
+	return report, nil, nil
+}
+
+func matchesPropertiesFilters(properties *PageProperties, filters []propertiesFilter) bool {
+	for _, f := range filters {
+		value, _ := properties.Get(f.key)
+		if strings.EqualFold(value, f.value) == f.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// CSV renders the report with the page title, ID and link followed by a column per key
+func (r *PropertiesReport) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(append([]string{"title", "id", "url"}, r.Columns...)); err != nil {
+		return "", err
+	}
+	for _, row := range r.Rows {
+		record := []string{row.Title, row.ID, row.URL}
+		for _, column := range r.Columns {
+			record = append(record, row.Values[column])
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePageProperties(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		macroID string
+		keys    []string
+		values  map[string]string
+	}{
+		{
+			name: "one key per row",
+			body: `<ac:structured-macro ac:name="details"><ac:rich-text-body><table><tbody>` +
+				`<tr><td>Owner</td><td>Alice</td></tr>` +
+				`<tr><td>Status</td><td>Draft</td></tr>` +
+				`</tbody></table></ac:rich-text-body></ac:structured-macro>`,
+			keys:   []string{"Owner", "Status"},
+			values: map[string]string{"Owner": "Alice", "Status": "Draft"},
+		},
+		{
+			name: "one key per row with header cells",
+			body: `<ac:structured-macro ac:name="details"><ac:rich-text-body><table><tbody>` +
+				`<tr><th>Owner</th><td>Alice</td></tr>` +
+				`<tr><th>Status</th><td>Draft</td></tr>` +
+				`</tbody></table></ac:rich-text-body></ac:structured-macro>`,
+			keys:   []string{"Owner", "Status"},
+			values: map[string]string{"Owner": "Alice", "Status": "Draft"},
+		},
+		{
+			name: "header row of two keys",
+			body: `<ac:structured-macro ac:name="details"><ac:rich-text-body><table><tbody>` +
+				`<tr><th>Owner</th><th>Status</th></tr>` +
+				`<tr><td>Alice</td><td>Draft</td></tr>` +
+				`</tbody></table></ac:rich-text-body></ac:structured-macro>`,
+			keys:   []string{"Owner", "Status"},
+			values: map[string]string{"Owner": "Alice", "Status": "Draft"},
+		},
+		{
+			name: "header row of three keys",
+			body: `<ac:structured-macro ac:name="details"><ac:rich-text-body><table><tbody>` +
+				`<tr><th>Owner</th><th>Status</th><th>Due</th></tr>` +
+				`<tr><td>Alice</td><td>Draft</td><td>2024-05-01</td></tr>` +
+				`</tbody></table></ac:rich-text-body></ac:structured-macro>`,
+			keys:   []string{"Owner", "Status", "Due"},
+			values: map[string]string{"Owner": "Alice", "Status": "Draft", "Due": "2024-05-01"},
+		},
+		{
+			name: "two rows of header cells are one key per row",
+			body: `<ac:structured-macro ac:name="details"><ac:rich-text-body><table><tbody>` +
+				`<tr><th>Owner</th><th>Alice</th></tr>` +
+				`<tr><th>Status</th><th>Draft</th></tr>` +
+				`</tbody></table></ac:rich-text-body></ac:structured-macro>`,
+			keys:   []string{"Owner", "Status"},
+			values: map[string]string{"Owner": "Alice", "Status": "Draft"},
+		},
+		{
+			name: "macro ID selects the macro",
+			body: `<ac:structured-macro ac:name="details"><ac:parameter ac:name="id">a</ac:parameter><ac:rich-text-body><table><tbody>` +
+				`<tr><th>Owner</th><td>Alice</td></tr>` +
+				`</tbody></table></ac:rich-text-body></ac:structured-macro>` +
+				`<ac:structured-macro ac:name="details"><ac:parameter ac:name="id">b</ac:parameter><ac:rich-text-body><table><tbody>` +
+				`<tr><th>Owner</th><td>Bob</td></tr>` +
+				`</tbody></table></ac:rich-text-body></ac:structured-macro>`,
+			macroID: "b",
+			keys:    []string{"Owner"},
+			values:  map[string]string{"Owner": "Bob"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			properties, err := ParsePageProperties(tt.body, tt.macroID)
+			if err != nil {
+				t.Fatalf("ParsePageProperties() error = %v", err)
+			}
+			if !reflect.DeepEqual(properties.Keys, tt.keys) {
+				t.Errorf("Keys = %q, want %q", properties.Keys, tt.keys)
+			}
+			if !reflect.DeepEqual(properties.Values, tt.values) {
+				t.Errorf("Values = %q, want %q", properties.Values, tt.values)
+			}
+		})
+	}
+}
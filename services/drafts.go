@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// StatusDraft is the status of content that was saved but not published
+const StatusDraft = "draft"
+
+var draftExpand = []string{"version", "space", "history", "ancestors"}
+
+// Draft is a page or blog post waiting to be published
+type Draft struct {
+	ID       string `json:"id" yaml:"id"`
+	Type     string `json:"type" yaml:"type"`
+	Title    string `json:"title" yaml:"title"`
+	SpaceKey string `json:"space,omitempty" yaml:"space,omitempty"`
+	ParentID string `json:"parent_id,omitempty" yaml:"parent_id,omitempty"`
+	Author   string `json:"author,omitempty" yaml:"author,omitempty"`
+	Created  string `json:"created,omitempty" yaml:"created,omitempty"`
+	Updated  string `json:"updated,omitempty" yaml:"updated,omitempty"`
+	URL      string `json:"url,omitempty" yaml:"url,omitempty"`
+	EditURL  string `json:"edit_url,omitempty" yaml:"edit_url,omitempty"`
+}
+
+// NewDraft converts draft content fetched with the space, version, history and ancestors expansions
+func NewDraft(cfg *AtlassianConfig, content *models.ContentScheme) *Draft {
+	draft := &Draft{ID: content.ID, Type: content.Type, Title: content.Title}
+	if content.Space != nil {
+		draft.SpaceKey = content.Space.Key
+	}
+	if len(content.Ancestors) > 0 {
+		draft.ParentID = content.Ancestors[len(content.Ancestors)-1].ID
+	}
+	if content.History != nil {
+		draft.Author = UserName(content.History.CreatedBy)
+		draft.Created = content.History.CreatedDate
+	}
+	if content.Version != nil {
+		draft.Updated = content.Version.When
+	}
+	if content.Links != nil {
+		draft.URL = cfg.WebURL(content.Links.Webui)
+		draft.EditURL = cfg.WebURL(content.Links.Editui)
+	}
+	return draft
+}
+
+// DraftOptions describes a draft to create
+type DraftOptions struct {
+	Type     string // page (default) or blogpost
+	SpaceKey string
+	Title    string
+	Body     string // storage format
+	ParentID string
+}
+
+// CreateDraft saves content as a draft, visible to its author until it is published
+func CreateDraft(ctx context.Context, client *confluence.Client, options DraftOptions) (*models.ContentScheme, *models.ResponseScheme, error) {
+	if options.Type == "" {
+		options.Type = "page"
+	}
+	payload := &models.ContentScheme{
+		Type:   options.Type,
+		Status: StatusDraft,
+		Title:  options.Title,
+		Space:  &models.SpaceScheme{Key: options.SpaceKey},
+		Body: &models.BodyScheme{
+			Storage: &models.BodyNodeScheme{Value: options.Body, Representation: "storage"},
+		},
+	}
+	if options.ParentID != "" {
+		payload.Ancestors = []*models.ContentScheme{{ID: options.ParentID}}
+	}
+	return client.Content.Create(ctx, payload)
+}
+
+// GetDraft fetches the draft of content with its storage body
+func GetDraft(ctx context.Context, client *confluence.Client, id string) (*models.ContentScheme, *models.ResponseScheme, error) {
+	params := url.Values{}
+	params.Set("status", StatusDraft)
+	params.Set("expand", strings.Join(append([]string{"body.storage"}, draftExpand...), ","))
+	request, err := client.NewRequest(ctx, http.MethodGet, "wiki/rest/api/content/"+url.PathEscape(id)+"?"+params.Encode(), "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	content := new(models.ContentScheme)
+	response, err := client.Call(request, content)
+	if err != nil {
+		return nil, response, err
+	}
+	return content, response, nil
+}
+
+// UpdateDraft replaces the title or body of a draft; empty values keep the current ones
+func UpdateDraft(ctx context.Context, client *confluence.Client, id, title, body string) (*models.ContentScheme, *models.ResponseScheme, error) {
+	return saveDraft(ctx, client, id, title, body, StatusDraft)
+}
+
+// PublishDraft publishes a draft as the first version of its page or blog post, optionally with
+// a new title or body
+func PublishDraft(ctx context.Context, client *confluence.Client, id, title, body string) (*models.ContentScheme, *models.ResponseScheme, error) {
+	return saveDraft(ctx, client, id, title, body, "current")
+}
+
+func saveDraft(ctx context.Context, client *confluence.Client, id, title, body, status string) (*models.ContentScheme, *models.ResponseScheme, error) {
+	draft, response, err := GetDraft(ctx, client, id)
+	if err != nil {
+		return nil, response, err
+	}
+	if draft.Status != "" && draft.Status != StatusDraft {
+		return nil, nil, fmt.Errorf("content %s is %s, not a draft", id, draft.Status)
+	}
+
+	payload := &models.ContentScheme{
+		ID:     draft.ID,
+		Type:   draft.Type,
+		Status: status,
+		Title:  draft.Title,
+		Space:  draft.Space,
+		// Drafts are not versioned: they are saved and published as version 1
+		Version: &models.ContentVersionScheme{Number: 1},
+		Body: &models.BodyScheme{
+			Storage: &models.BodyNodeScheme{Value: ContentBody(draft), Representation: "storage"},
+		},
+	}
+	if len(draft.Ancestors) > 0 {
+		payload.Ancestors = []*models.ContentScheme{{ID: draft.Ancestors[len(draft.Ancestors)-1].ID}}
+	}
+	if title != "" {
+		payload.Title = title
+	}
+	if body != "" {
+		payload.Body.Storage.Value = body
+	}
+	if payload.Title == "" {
+		return nil, nil, fmt.Errorf("draft %s has no title; give one to save or publish it", id)
+	}
+
+	request, err := client.NewRequest(ctx, http.MethodPut, "wiki/rest/api/content/"+url.PathEscape(id)+"?status="+StatusDraft, "", payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	content := new(models.ContentScheme)
+	response, err = client.Call(request, content)
+	if err != nil {
+		return nil, response, err
+	}
+	return content, response, nil
+}
+
+// ListDrafts lists the drafts of the current user, in a space or all spaces. Type is page,
+// blogpost or empty for both.
+func ListDrafts(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, spaceKey, contentType string) ([]*Draft, *models.ResponseScheme, error) {
+	me, response, err := CurrentUser(ctx, client)
+	if err != nil {
+		return nil, response, err
+	}
+
+	types := []string{"page", ContentTypeBlogPost}
+	if contentType != "" {
+		types = []string{contentType}
+	}
+
+	drafts := []*Draft{}
+	for _, t := range types {
+		const limit = 100
+		for start := 0; ; start += limit {
+			options := &models.GetContentOptionsScheme{
+				ContextType: t,
+				SpaceKey:    spaceKey,
+				Status:      []string{StatusDraft},
+				Expand:      draftExpand,
+			}
+			page, response, err := client.Content.Gets(ctx, options, start, limit)
+			if err != nil {
+				return nil, response, err
+			}
+			for _, content := range page.Results {
+				// Drafts of other users are only returned to administrators; leave them out
+				if content.History != nil && !SameUser(content.History.CreatedBy, me) {
+					continue
+				}
+				drafts = append(drafts, NewDraft(cfg, content))
+			}
+			if len(page.Results) < limit {
+				break
+			}
+		}
+	}
+	return drafts, nil, nil
+}
+
+// CurrentUser returns the user the client is authenticated as
+func CurrentUser(ctx context.Context, client *confluence.Client) (*models.ContentUserScheme, *models.ResponseScheme, error) {
+	request, err := client.NewRequest(ctx, http.MethodGet, "wiki/rest/api/user/current", "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	user := new(models.ContentUserScheme)
+	response, err := client.Call(request, user)
+	if err != nil {
+		return nil, response, err
+	}
+	return user, response, nil
+}
+
+// SameUser reports whether two users are the same, by account ID on Cloud and user key or
+// username on Data Center
+func SameUser(a, b *models.ContentUserScheme) bool {
+	if a == nil || b == nil {
+		return false
+	}
+	switch {
+	case a.AccountID != "" && b.AccountID != "":
+		return a.AccountID == b.AccountID
+	case a.UserKey != "" && b.UserKey != "":
+		return a.UserKey == b.UserKey
+	default:
+		return a.Username != "" && a.Username == b.Username
+	}
+}
@@ -0,0 +1,300 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Embedder turns texts into vectors whose cosine similarity reflects semantic similarity
+type Embedder interface {
+	// Name identifies the model, so vectors of different models are never compared
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// HTTPEmbedder calls an OpenAI-compatible embeddings endpoint (POST {"model", "input"} returning
+// {"data": [{"index", "embedding"}]}), as served by OpenAI, Azure OpenAI, Ollama or vLLM
+type HTTPEmbedder struct {
+	URL       string
+	Model     string
+	APIKey    string
+	BatchSize int
+}
+
+// Name returns the model of the embedder
+func (e *HTTPEmbedder) Name() string {
+	return e.Model
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed sends the texts to the endpoint in batches
+func (e *HTTPEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	batchSize := e.BatchSize
+	if batchSize <= 0 {
+		batchSize = 64
+	}
+	vectors := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		batch := texts[start:min(start+batchSize, len(texts))]
+		embedded, err := e.embedBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, embedded...)
+	}
+	return vectors, nil
+}
+
+func (e *HTTPEmbedder) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(embeddingRequest{Model: e.Model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.APIKey)
+	}
+
+	resp, err := DefaultHttpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embedding request failed: %s: %s", resp.Status, data)
+	}
+
+	var parsed embeddingResponse
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid embedding response: %w", err)
+	}
+	if len(parsed.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding response has %d vectors for %d inputs", len(parsed.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for i, item := range parsed.Data {
+		index := item.Index
+		if index < 0 || index >= len(texts) {
+			index = i
+		}
+		vectors[index] = item.Embedding
+	}
+	return vectors, nil
+}
+
+var (
+	embedderMu       sync.Mutex
+	embedder         Embedder
+	embedderResolved bool
+)
+
+// SetEmbedder replaces the embedder used for passage retrieval; nil selects the offline BM25 ranking
+func SetEmbedder(e Embedder) {
+	embedderMu.Lock()
+	defer embedderMu.Unlock()
+	embedder, embedderResolved = e, true
+}
+
+// CurrentEmbedder returns the embedder set with SetEmbedder, or the HTTP embedder configured by
+// CONFLUENCE_MCP_EMBEDDINGS_URL, CONFLUENCE_MCP_EMBEDDINGS_MODEL and CONFLUENCE_MCP_EMBEDDINGS_API_KEY.
+// It returns nil when none is configured.
+func CurrentEmbedder() Embedder {
+	embedderMu.Lock()
+	defer embedderMu.Unlock()
+	if !embedderResolved {
+		if url := os.Getenv("CONFLUENCE_MCP_EMBEDDINGS_URL"); url != "" {
+			embedder = &HTTPEmbedder{
+				URL:    url,
+				Model:  os.Getenv("CONFLUENCE_MCP_EMBEDDINGS_MODEL"),
+				APIKey: os.Getenv("CONFLUENCE_MCP_EMBEDDINGS_API_KEY"),
+			}
+		}
+		embedderResolved = true
+	}
+	return embedder
+}
+
+// embeddingCache keeps the vectors of chunk texts, so unchanged passages are embedded once per process
+var embeddingCache = struct {
+	sync.Mutex
+	vectors map[string][]float32
+}{vectors: make(map[string][]float32)}
+
+func embeddingKey(model, text string) string {
+	sum := sha256.Sum256([]byte(model + "\x00" + text))
+	return hex.EncodeToString(sum[:])
+}
+
+// embedCached embeds the texts that are not cached yet
+func embedCached(ctx context.Context, e Embedder, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	var missing []string
+	var missingIndex []int
+
+	embeddingCache.Lock()
+	for i, text := range texts {
+		if vector, ok := embeddingCache.vectors[embeddingKey(e.Name(), text)]; ok {
+			vectors[i] = vector
+		} else {
+			missing = append(missing, text)
+			missingIndex = append(missingIndex, i)
+		}
+	}
+	embeddingCache.Unlock()
+
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+	embedded, err := e.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	embeddingCache.Lock()
+	defer embeddingCache.Unlock()
+	for j, vector := range embedded {
+		vectors[missingIndex[j]] = vector
+		embeddingCache.vectors[embeddingKey(e.Name(), missing[j])] = vector
+	}
+	return vectors, nil
+}
+
+// ScoredChunk is a chunk with its relevance to a query
+type ScoredChunk struct {
+	Chunk
+	Score float64 `json:"score" yaml:"score"`
+}
+
+// chunkText is the text a chunk is ranked on: its breadcrumb followed by its content
+func chunkText(chunk Chunk) string {
+	return chunk.Breadcrumb() + "\n\n" + chunk.Text
+}
+
+// RankChunks returns the k chunks most relevant to a query: by cosine similarity of embeddings
+// when an embedder is given, otherwise by BM25
+func RankChunks(ctx context.Context, e Embedder, query string, chunks []Chunk, k int) ([]ScoredChunk, error) {
+	if len(chunks) == 0 {
+		return nil, nil
+	}
+	var scores []float64
+	if e != nil {
+		texts := make([]string, len(chunks)+1)
+		texts[0] = query
+		for i, chunk := range chunks {
+			texts[i+1] = chunkText(chunk)
+		}
+		vectors, err := embedCached(ctx, e, texts)
+		if err != nil {
+			return nil, err
+		}
+		scores = make([]float64, len(chunks))
+		for i := range chunks {
+			scores[i] = cosine(vectors[0], vectors[i+1])
+		}
+	} else {
+		scores = bm25Scores(query, chunks)
+	}
+
+	ranked := make([]ScoredChunk, len(chunks))
+	for i, chunk := range chunks {
+		ranked[i] = ScoredChunk{Chunk: chunk, Score: math.Round(scores[i]*1000) / 1000}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	if e == nil {
+		// Chunks without a single query term are not relevant at all
+		for len(ranked) > 0 && ranked[len(ranked)-1].Score <= 0 {
+			ranked = ranked[:len(ranked)-1]
+		}
+	}
+	if k > 0 && len(ranked) > k {
+		ranked = ranked[:k]
+	}
+	return ranked, nil
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// bm25Scores scores chunks against the query terms with BM25, computing term statistics over the chunks
+func bm25Scores(query string, chunks []Chunk) []float64 {
+	documents := make([]map[string]int, len(chunks))
+	lengths := make([]int, len(chunks))
+	df := make(map[string]int)
+	total := 0
+	for i, chunk := range chunks {
+		documents[i] = make(map[string]int)
+		for _, t := range tokenize(chunkText(chunk)) {
+			if documents[i][t.term] == 0 {
+				df[t.term]++
+			}
+			documents[i][t.term]++
+			lengths[i]++
+		}
+		total += lengths[i]
+	}
+	average := float64(total) / float64(len(chunks))
+
+	terms := make(map[string]bool)
+	for _, t := range tokenize(query) {
+		terms[t.term] = true
+	}
+
+	scores := make([]float64, len(chunks))
+	for term := range terms {
+		if df[term] == 0 {
+			continue
+		}
+		idf := math.Log(1 + (float64(len(chunks))-float64(df[term])+0.5)/(float64(df[term])+0.5))
+		for i, document := range documents {
+			tf := float64(document[term])
+			if tf == 0 {
+				continue
+			}
+			norm := 1 - bm25B + bm25B*float64(lengths[i])/average
+			scores[i] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*norm)
+		}
+	}
+	return scores
+}
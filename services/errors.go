@@ -0,0 +1,15 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// ResponseError describes a failed API call, preferring the response body returned by Confluence
+func ResponseError(message string, response *models.ResponseScheme, err error) error {
+	if response != nil && response.Bytes.Len() > 0 {
+		return fmt.Errorf("%s: %s (endpoint: %s)", message, response.Bytes.String(), response.Endpoint)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
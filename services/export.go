@@ -0,0 +1,525 @@
+package services
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// Export page formats
+const (
+	ExportMarkdown = "markdown"
+	ExportHTML     = "html"
+)
+
+// Export archive formats
+const (
+	ArchiveTarGz = "tar.gz"
+	ArchiveZip   = "zip"
+)
+
+// ExportOptions configures a space export
+type ExportOptions struct {
+	SpaceKey    string
+	Format      string // ExportMarkdown (default) or ExportHTML
+	Output      string // archive path; the archive format follows its extension
+	Concurrency int    // pages fetched in parallel, 4 by default
+	Attachments bool
+}
+
+// ExportManifest lists everything written to an export archive
+type ExportManifest struct {
+	Space      string         `json:"space"`
+	Site       string         `json:"site"`
+	Format     string         `json:"format"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Pages      []ExportedPage `json:"pages"`
+}
+
+// ExportedPage is one page of an export
+type ExportedPage struct {
+	ID          string               `json:"id"`
+	Title       string               `json:"title"`
+	ParentID    string               `json:"parent_id,omitempty"`
+	Path        string               `json:"path"`
+	Version     int                  `json:"version"`
+	SHA256      string               `json:"sha256"`
+	Attachments []ExportedAttachment `json:"attachments,omitempty"`
+}
+
+// ExportedAttachment is one attachment of an exported page
+type ExportedAttachment struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Path    string `json:"path,omitempty"`
+	Version int    `json:"version,omitempty"`
+	Size    int    `json:"size"`
+	SHA256  string `json:"sha256,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ExportSummary describes a finished export
+type ExportSummary struct {
+	Space              string `json:"space" yaml:"space"`
+	Archive            string `json:"archive" yaml:"archive"`
+	Format             string `json:"format" yaml:"format"`
+	Pages              int    `json:"pages" yaml:"pages"`
+	Attachments        int    `json:"attachments" yaml:"attachments"`
+	FailedAttachments  int    `json:"failed_attachments,omitempty" yaml:"failed_attachments,omitempty"`
+	ArchiveSizeInBytes int64  `json:"archive_size_bytes" yaml:"archive_size_bytes"`
+}
+
+// ArchiveFormat picks the archive format from the extension of a file name
+func ArchiveFormat(name string) (string, error) {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz, nil
+	}
+	return "", fmt.Errorf("unsupported archive %q: use a .tar.gz, .tgz or .zip file name", name)
+}
+
+// archiveWriter adds files to a tar.gz or zip archive
+type archiveWriter interface {
+	Add(name string, data []byte, modified time.Time) error
+	Close() error
+}
+
+type tarGzWriter struct {
+	gzip *gzip.Writer
+	tar  *tar.Writer
+}
+
+func (w *tarGzWriter) Add(name string, data []byte, modified time.Time) error {
+	header := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modified, Typeflag: tar.TypeReg}
+	if err := w.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := w.tar.Write(data)
+	return err
+}
+
+func (w *tarGzWriter) Close() error {
+	if err := w.tar.Close(); err != nil {
+		return err
+	}
+	return w.gzip.Close()
+}
+
+type zipWriter struct {
+	zip *zip.Writer
+}
+
+func (w *zipWriter) Add(name string, data []byte, modified time.Time) error {
+	file, err := w.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(data)
+	return err
+}
+
+func (w *zipWriter) Close() error {
+	return w.zip.Close()
+}
+
+func newArchiveWriter(format string, out io.Writer) archiveWriter {
+	if format == ArchiveZip {
+		return &zipWriter{zip: zip.NewWriter(out)}
+	}
+	compressed := gzip.NewWriter(out)
+	return &tarGzWriter{gzip: compressed, tar: tar.NewWriter(compressed)}
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// exportFile is a file produced by an export worker
+type exportFile struct {
+	name string
+	data []byte
+}
+
+// exportResult is the outcome of exporting one page
+type exportResult struct {
+	page  ExportedPage
+	files []exportFile
+	err   error
+}
+
+// exporter holds the state shared by the export workers
+type exporter struct {
+	client  *confluence.Client
+	cfg     *AtlassianConfig
+	options ExportOptions
+	paths   map[string]string // page ID -> path in the archive, relative to the export root
+	titles  map[string]string // lower-case title -> page ID
+	parents map[string]string
+}
+
+// ExportSpace writes every page of a space, with its attachments, an index and a manifest to an archive
+func ExportSpace(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, options ExportOptions) (*ExportSummary, error) {
+	if options.Format == "" {
+		options.Format = ExportMarkdown
+	}
+	if options.Format != ExportMarkdown && options.Format != ExportHTML {
+		return nil, fmt.Errorf("unsupported format %q: use markdown or html", options.Format)
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 4
+	}
+	archiveFormat, err := ArchiveFormat(options.Output)
+	if err != nil {
+		return nil, err
+	}
+
+	roots, response, err := GetSpaceTree(ctx, client, options.SpaceKey, []string{"version"})
+	if err != nil {
+		return nil, ResponseError("failed to list pages of space "+options.SpaceKey, response, err)
+	}
+
+	ext := ".md"
+	if options.Format == ExportHTML {
+		ext = ".html"
+	}
+	e := &exporter{
+		client:  client,
+		cfg:     cfg,
+		options: options,
+		paths:   assignPaths(roots, "pages", ext),
+		titles:  make(map[string]string),
+		parents: make(map[string]string),
+	}
+	var ids []string
+	for _, root := range roots {
+		root.Walk(func(node *PageNode, depth int) error {
+			ids = append(ids, node.Page.ID)
+			e.titles[strings.ToLower(node.Page.Title)] = node.Page.ID
+			for _, child := range node.Children {
+				e.parents[child.Page.ID] = node.Page.ID
+			}
+			return nil
+		})
+	}
+
+	file, err := os.Create(options.Output)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	// A failed export leaves no partial archive behind
+	fail := func(err error) (*ExportSummary, error) {
+		file.Close()
+		os.Remove(options.Output)
+		return nil, err
+	}
+	archive := newArchiveWriter(archiveFormat, file)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := make(chan string)
+	results := make(chan exportResult)
+	var workers sync.WaitGroup
+	for i := 0; i < options.Concurrency; i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for id := range jobs {
+				results <- e.exportPage(ctx, id)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, id := range ids {
+			select {
+			case jobs <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	root := options.SpaceKey
+	now := time.Now().UTC().Truncate(time.Second)
+	manifest := &ExportManifest{Space: options.SpaceKey, Site: cfg.SiteName(), Format: options.Format, ExportedAt: now}
+	summary := &ExportSummary{Space: options.SpaceKey, Archive: options.Output, Format: options.Format}
+
+	var exportErr error
+	for result := range results {
+		if exportErr != nil {
+			continue
+		}
+		if result.err != nil {
+			exportErr = result.err
+			cancel()
+			continue
+		}
+		for _, f := range result.files {
+			if err := archive.Add(path.Join(root, f.name), f.data, now); err != nil {
+				exportErr = err
+				cancel()
+				break
+			}
+		}
+		manifest.Pages = append(manifest.Pages, result.page)
+		for _, attachment := range result.page.Attachments {
+			if attachment.Error != "" {
+				summary.FailedAttachments++
+			} else {
+				summary.Attachments++
+			}
+		}
+	}
+	if exportErr != nil {
+		return fail(exportErr)
+	}
+
+	sort.Slice(manifest.Pages, func(i, j int) bool { return manifest.Pages[i].Path < manifest.Pages[j].Path })
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fail(err)
+	}
+	if err := archive.Add(path.Join(root, "manifest.json"), append(manifestData, '\n'), now); err != nil {
+		return fail(err)
+	}
+	indexName, index := e.index(roots)
+	if err := archive.Add(path.Join(root, indexName), index, now); err != nil {
+		return fail(err)
+	}
+	if err := archive.Close(); err != nil {
+		return fail(err)
+	}
+
+	summary.Pages = len(manifest.Pages)
+	if info, err := file.Stat(); err == nil {
+		summary.ArchiveSizeInBytes = info.Size()
+	}
+	return summary, nil
+}
+
+// exportPage fetches, converts and collects the attachments of one page
+func (e *exporter) exportPage(ctx context.Context, pageID string) exportResult {
+	expand := []string{"body.storage", "version", "space"}
+	if e.options.Format == ExportHTML {
+		expand = []string{"body.export_view", "version", "space"}
+	}
+	page, response, err := e.client.Content.Get(ctx, pageID, expand, 0)
+	if err != nil {
+		return exportResult{err: ResponseError("failed to get page "+pageID, response, err)}
+	}
+
+	pagePath := e.paths[pageID]
+	exported := ExportedPage{ID: page.ID, Title: page.Title, ParentID: e.parents[page.ID], Path: pagePath}
+	if page.Version != nil {
+		exported.Version = page.Version.Number
+	}
+
+	var files []exportFile
+	attachmentPaths := make(map[string]string) // file name -> path in the archive
+	if e.options.Attachments {
+		attachments, response, err := PageAttachments(ctx, e.client, pageID)
+		if err != nil {
+			return exportResult{err: ResponseError("failed to list attachments of page "+pageID, response, err)}
+		}
+		for _, attachment := range attachments {
+			entry := ExportedAttachment{ID: attachment.ID, Title: attachment.Title}
+			if attachment.Version != nil {
+				entry.Version = attachment.Version.Number
+			}
+			data, response, err := DownloadAttachment(ctx, e.client, attachment)
+			if err != nil {
+				if ctx.Err() != nil {
+					return exportResult{err: ctx.Err()}
+				}
+				entry.Error = ResponseError("download failed", response, err).Error()
+				exported.Attachments = append(exported.Attachments, entry)
+				continue
+			}
+			entry.Path = path.Join("attachments", pageID, FileSlug(attachment.Title))
+			entry.Size = len(data)
+			entry.SHA256 = checksum(data)
+			attachmentPaths[attachment.Title] = entry.Path
+			files = append(files, exportFile{name: entry.Path, data: data})
+			exported.Attachments = append(exported.Attachments, entry)
+		}
+	}
+
+	var content []byte
+	if e.options.Format == ExportHTML {
+		content = e.htmlPage(page, attachmentPaths)
+	} else {
+		content, err = e.markdownPage(page, attachmentPaths)
+		if err != nil {
+			return exportResult{err: err}
+		}
+	}
+	exported.SHA256 = checksum(content)
+	files = append(files, exportFile{name: pagePath, data: content})
+	return exportResult{page: exported, files: files}
+}
+
+func (e *exporter) markdownPage(page *models.ContentScheme, attachmentPaths map[string]string) ([]byte, error) {
+	pagePath := e.paths[page.ID]
+	markdown, err := StorageToMarkdown(ContentBody(page), &MarkdownOptions{
+		PageLink: func(spaceKey, title string) string {
+			if id, ok := e.titles[strings.ToLower(title)]; ok && (spaceKey == "" || spaceKey == e.options.SpaceKey) {
+				return relativeLink(pagePath, e.paths[id])
+			}
+			return title
+		},
+		AttachmentLink: func(filename string) string {
+			if target, ok := attachmentPaths[filename]; ok {
+				return relativeLink(pagePath, target)
+			}
+			return filename
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert page %s: %w", page.ID, err)
+	}
+
+	frontMatter := &PageFrontMatter{Title: page.Title, ID: page.ID, Parent: e.parents[page.ID], Space: e.options.SpaceKey}
+	if page.Version != nil {
+		frontMatter.Version = page.Version.Number
+	}
+	return FormatPageFile(frontMatter, markdown), nil
+}
+
+var (
+	linkAttribute     = regexp.MustCompile(`(href|src)="([^"]*)"`)
+	pageIDLink        = regexp.MustCompile(`(?:pageId=|/pages/)(\d+)`)
+	displayLink       = regexp.MustCompile(`/display/([^/?#]+)/([^?#]+)`)
+	attachmentURLPath = regexp.MustCompile(`/download/(?:attachments|thumbnails)/(\d+)/([^?#]+)`)
+	linkTextEscaper   = strings.NewReplacer(`[`, `\[`, `]`, `\]`)
+)
+
+func (e *exporter) htmlPage(page *models.ContentScheme, attachmentPaths map[string]string) []byte {
+	pagePath := e.paths[page.ID]
+	body := ""
+	if page.Body != nil && page.Body.ExportView != nil {
+		body = page.Body.ExportView.Value
+	}
+
+	// Point links to pages and attachments of the export at the exported files
+	body = linkAttribute.ReplaceAllStringFunc(body, func(attribute string) string {
+		parts := linkAttribute.FindStringSubmatch(attribute)
+		target := e.rewriteLink(html.UnescapeString(parts[2]), pagePath, page.ID, attachmentPaths)
+		if target == "" {
+			return attribute
+		}
+		return parts[1] + `="` + html.EscapeString(hrefPath(target)) + `"`
+	})
+
+	index := relativeLink(pagePath, "index.html")
+	var out strings.Builder
+	out.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&out, "<title>%s</title>\n</head>\n<body>\n", html.EscapeString(page.Title))
+	fmt.Fprintf(&out, "<p><a href=\"%s\">%s</a></p>\n", html.EscapeString(index), html.EscapeString(e.options.SpaceKey))
+	fmt.Fprintf(&out, "<h1>%s</h1>\n%s\n</body>\n</html>\n", html.EscapeString(page.Title), body)
+	return []byte(out.String())
+}
+
+// hrefPath percent-encodes a relative file path for use in an HTML attribute, keeping any fragment
+func hrefPath(target string) string {
+	fragment := ""
+	if i := strings.Index(target, "#"); i >= 0 {
+		target, fragment = target[:i], target[i:]
+	}
+	return (&url.URL{Path: target}).String() + fragment
+}
+
+// rewriteLink returns the exported file a Confluence URL points to, or "" to keep the URL
+func (e *exporter) rewriteLink(link, pagePath, pageID string, attachmentPaths map[string]string) string {
+	if match := attachmentURLPath.FindStringSubmatch(link); match != nil {
+		name, _ := url.PathUnescape(match[2])
+		if match[1] == pageID {
+			if target, ok := attachmentPaths[name]; ok {
+				return relativeLink(pagePath, target)
+			}
+		}
+		return ""
+	}
+
+	fragment := ""
+	if i := strings.Index(link, "#"); i >= 0 {
+		fragment = link[i:]
+	}
+	if match := pageIDLink.FindStringSubmatch(link); match != nil {
+		if target, ok := e.paths[match[1]]; ok {
+			return relativeLink(pagePath, target) + fragment
+		}
+		return ""
+	}
+	if match := displayLink.FindStringSubmatch(link); match != nil && match[1] == e.options.SpaceKey {
+		title, err := url.QueryUnescape(match[2])
+		if err != nil {
+			return ""
+		}
+		if id, ok := e.titles[strings.ToLower(title)]; ok {
+			return relativeLink(pagePath, e.paths[id]) + fragment
+		}
+	}
+	return ""
+}
+
+// index renders the table of contents of the export
+func (e *exporter) index(roots []*PageNode) (string, []byte) {
+	var out strings.Builder
+	if e.options.Format == ExportHTML {
+		out.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+		fmt.Fprintf(&out, "<title>%s</title>\n</head>\n<body>\n<h1>%s</h1>\n", html.EscapeString(e.options.SpaceKey), html.EscapeString(e.options.SpaceKey))
+		var list func(nodes []*PageNode)
+		list = func(nodes []*PageNode) {
+			out.WriteString("<ul>\n")
+			for _, node := range nodes {
+				fmt.Fprintf(&out, "<li><a href=\"%s\">%s</a>", html.EscapeString(hrefPath(e.paths[node.Page.ID])), html.EscapeString(node.Page.Title))
+				if len(node.Children) > 0 {
+					out.WriteString("\n")
+					list(node.Children)
+				}
+				out.WriteString("</li>\n")
+			}
+			out.WriteString("</ul>\n")
+		}
+		list(roots)
+		out.WriteString("</body>\n</html>\n")
+		return "index.html", []byte(out.String())
+	}
+
+	fmt.Fprintf(&out, "# %s\n\n", e.options.SpaceKey)
+	for _, root := range roots {
+		root.Walk(func(node *PageNode, depth int) error {
+			fmt.Fprintf(&out, "%s- [%s](%s)\n", strings.Repeat("  ", depth), linkTextEscaper.Replace(node.Page.Title), escapeLinkTarget(e.paths[node.Page.ID]))
+			return nil
+		})
+	}
+	return "index.md", []byte(out.String())
+}
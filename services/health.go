@@ -0,0 +1,217 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+)
+
+// Content health checks
+const (
+	HealthStale       = "stale"
+	HealthOrphan      = "orphan"
+	HealthDeactivated = "deactivated_editor"
+	HealthEmpty       = "empty"
+	HealthDuplicate   = "duplicate_title"
+)
+
+// HealthChecks lists every content health check, in report order
+var HealthChecks = []string{HealthStale, HealthOrphan, HealthDeactivated, HealthEmpty, HealthDuplicate}
+
+var healthCheckAliases = map[string]string{"deactivated": HealthDeactivated, "duplicate": HealthDuplicate, "duplicates": HealthDuplicate}
+
+// contentElementPattern matches markup that gives a page content without text
+var contentElementPattern = regexp.MustCompile(`<(?:ac:structured-macro|ac:macro|ac:image|img|ac:adf-extension|ac:task-list|table)\b`)
+
+// HealthOptions configures ContentHealth
+type HealthOptions struct {
+	SpaceKey  string
+	StaleDays int      // pages not modified for this many days are stale; 0 means 365
+	Checks    []string // checks to run; default: all of them
+	Now       time.Time
+}
+
+// HealthIssue is a page that failed a check
+type HealthIssue struct {
+	Check        string `json:"check" yaml:"check"`
+	PageID       string `json:"page_id" yaml:"page_id"`
+	Title        string `json:"title" yaml:"title"`
+	URL          string `json:"url,omitempty" yaml:"url,omitempty"`
+	LastModified string `json:"last_modified,omitempty" yaml:"last_modified,omitempty"`
+	LastEditor   string `json:"last_editor,omitempty" yaml:"last_editor,omitempty"`
+	Detail       string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// HealthReport is the result of ContentHealth
+type HealthReport struct {
+	SpaceKey  string         `json:"space" yaml:"space"`
+	Pages     int            `json:"pages" yaml:"pages"`
+	StaleDays int            `json:"stale_days" yaml:"stale_days"`
+	Summary   map[string]int `json:"summary" yaml:"summary"` // issues per check
+	Issues    []*HealthIssue `json:"issues" yaml:"issues"`
+}
+
+// ContentHealth scans the pages of a space for content that needs attention: pages not modified
+// for a while, pages with no children that no other page links to, pages last edited by a
+// deactivated user, pages without content and pages sharing a title
+func ContentHealth(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, options HealthOptions) (*HealthReport, error) {
+	if options.SpaceKey == "" {
+		return nil, fmt.Errorf("a space key is required")
+	}
+	if options.StaleDays <= 0 {
+		options.StaleDays = 365
+	}
+	if options.Now.IsZero() {
+		options.Now = time.Now()
+	}
+	checks := map[string]bool{}
+	for _, check := range options.Checks {
+		check = strings.ToLower(strings.TrimSpace(check))
+		if alias, ok := healthCheckAliases[check]; ok {
+			check = alias
+		}
+		if !slices.Contains(HealthChecks, check) {
+			return nil, fmt.Errorf("unknown check %q (use %s)", check, strings.Join(HealthChecks, ", "))
+		}
+		checks[check] = true
+	}
+	if len(checks) == 0 {
+		for _, check := range HealthChecks {
+			checks[check] = true
+		}
+	}
+
+	graph, err := BuildLinkGraph(ctx, client, cfg, []string{options.SpaceKey}, "version", "ancestors")
+	if err != nil {
+		return nil, err
+	}
+	homepageID := ""
+	if space, _, err := GetSpace(ctx, client, options.SpaceKey, []string{"homepage"}); err == nil && space.HomePage != nil {
+		homepageID = space.HomePage.ID
+	}
+
+	report := &HealthReport{SpaceKey: options.SpaceKey, Pages: len(graph.Pages), StaleDays: options.StaleDays, Summary: map[string]int{}, Issues: []*HealthIssue{}}
+	for _, check := range HealthChecks {
+		if checks[check] {
+			report.Summary[check] = 0
+		}
+	}
+
+	parents := map[string]bool{}
+	titles := map[string][]*GraphPage{}
+	for _, node := range graph.Pages {
+		if ancestors := node.page.Ancestors; len(ancestors) > 0 {
+			parents[ancestors[len(ancestors)-1].ID] = true
+		}
+		titles[titleGroup(node.Title)] = append(titles[titleGroup(node.Title)], node)
+	}
+	inbound := graph.InboundCounts()
+	users := NewUserDirectory(client)
+	staleBefore := options.Now.AddDate(0, 0, -options.StaleDays)
+
+	for _, node := range graph.Pages {
+		issue := func(check, detail string) {
+			report.Issues = append(report.Issues, newHealthIssue(node, check, detail))
+			report.Summary[check]++
+		}
+		version := node.page.Version
+
+		if checks[HealthStale] && version != nil {
+			if modified, err := time.Parse(time.RFC3339, version.When); err == nil && modified.Before(staleBefore) {
+				issue(HealthStale, fmt.Sprintf("not modified for %d days", int(options.Now.Sub(modified).Hours()/24)))
+			}
+		}
+		if checks[HealthOrphan] && node.ID != homepageID && !parents[node.ID] && inbound[node.ID] == 0 {
+			issue(HealthOrphan, "no child pages and no links from other pages")
+		}
+		if checks[HealthDeactivated] && version != nil && version.By != nil {
+			by := version.By
+			ref := UserRef{AccountID: by.AccountID, UserKey: by.UserKey, Username: by.Username}
+			if by.Type == "unknown" || users.Deactivated(ctx, ref) {
+				issue(HealthDeactivated, "last editor is no longer active")
+			}
+		}
+		if checks[HealthEmpty] && emptyBody(ContentBody(node.page)) {
+			issue(HealthEmpty, "no text, macros, images or tables")
+		}
+		if checks[HealthDuplicate] {
+			if same := titles[titleGroup(node.Title)]; len(same) > 1 {
+				var others []string
+				for _, other := range same {
+					if other != node {
+						others = append(others, other.ID)
+					}
+				}
+				issue(HealthDuplicate, "same title as "+strings.Join(others, ", "))
+			}
+		}
+	}
+
+	order := map[string]int{}
+	for i, check := range HealthChecks {
+		order[check] = i
+	}
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.Check != b.Check {
+			return order[a.Check] < order[b.Check]
+		}
+		if a.Check == HealthStale {
+			return a.LastModified < b.LastModified
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+	return report, nil
+}
+
+func newHealthIssue(node *GraphPage, check, detail string) *HealthIssue {
+	issue := &HealthIssue{Check: check, PageID: node.ID, Title: node.Title, URL: node.URL, Detail: detail}
+	if version := node.page.Version; version != nil {
+		issue.LastModified = version.When
+		issue.LastEditor = UserName(version.By)
+	}
+	return issue
+}
+
+// titleGroup is the form of a title duplicates share: lower case with single spaces
+func titleGroup(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
+
+// emptyBody reports whether a storage format body has no text and no element that shows content
+// on its own
+func emptyBody(body string) bool {
+	if contentElementPattern.MatchString(body) {
+		return false
+	}
+	root, err := parseStorage(body)
+	if err != nil {
+		return strings.TrimSpace(tagPattern.ReplaceAllString(body, "")) == ""
+	}
+	return strings.TrimSpace(root.textContent()) == ""
+}
+
+// CSV renders the issues with a row per page and check
+func (r *HealthReport) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write([]string{"check", "title", "page_id", "url", "last_modified", "last_editor", "detail"}); err != nil {
+		return "", err
+	}
+	for _, issue := range r.Issues {
+		if err := w.Write([]string{issue.Check, issue.Title, issue.PageID, issue.URL, issue.LastModified, issue.LastEditor, issue.Detail}); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
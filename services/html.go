@@ -0,0 +1,202 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// htmlSkipped are elements dropped with their content when converting HTML
+var htmlSkipped = map[string]bool{
+	"head": true, "title": true, "script": true, "style": true, "noscript": true,
+	"nav": true, "iframe": true, "form": true, "button": true, "input": true, "select": true, "textarea": true,
+}
+
+// htmlKept are elements copied to storage format as they are
+var htmlKept = map[string]bool{
+	"p": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "dl": true, "dt": true, "dd": true,
+	"table": true, "caption": true, "colgroup": true, "thead": true, "tbody": true, "tfoot": true, "tr": true, "th": true, "td": true,
+	"strong": true, "b": true, "em": true, "i": true, "u": true, "s": true, "del": true, "sub": true, "sup": true,
+	"blockquote": true, "code": true, "span": true, "div": true,
+}
+
+// htmlKeptAttributes are the attributes kept on copied elements
+var htmlKeptAttributes = []string{"colspan", "rowspan", "start"}
+
+// HTMLToStorage converts an HTML document or fragment to Confluence storage format. Only the
+// body is converted; scripts, styles and forms are dropped, <pre> becomes a code macro, and
+// relative links and images are resolved like in MarkdownToStorage.
+func HTMLToStorage(source string, options *StorageOptions) (string, error) {
+	root, err := parseStorage(source)
+	if err != nil {
+		return "", err
+	}
+	if body := findElement(root, "body"); body != nil {
+		root = body
+	}
+	if options == nil {
+		options = &StorageOptions{}
+	}
+	c := &htmlConverter{options: options}
+	var b strings.Builder
+	c.nodes(&b, root.children)
+	return strings.TrimSpace(b.String()), nil
+}
+
+// HTMLTitle returns the text of the <title> of an HTML document, or of its first <h1>
+func HTMLTitle(source string) string {
+	root, err := parseStorage(source)
+	if err != nil {
+		return ""
+	}
+	for _, name := range []string{"title", "h1"} {
+		if n := findElement(root, name); n != nil {
+			if title := strings.Join(strings.Fields(n.textContent()), " "); title != "" {
+				return title
+			}
+		}
+	}
+	return ""
+}
+
+// findElement returns the first element with the given name, depth-first
+func findElement(n *storageNode, name string) *storageNode {
+	for _, child := range n.children {
+		if child.isText {
+			continue
+		}
+		if child.name == name {
+			return child
+		}
+		if found := findElement(child, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+type htmlConverter struct {
+	options *StorageOptions
+}
+
+func (c *htmlConverter) nodes(b *strings.Builder, nodes []*storageNode) {
+	for _, n := range nodes {
+		c.node(b, n)
+	}
+}
+
+func (c *htmlConverter) node(b *strings.Builder, n *storageNode) {
+	if n.isText {
+		b.WriteString(html.EscapeString(n.text))
+		return
+	}
+	switch {
+	case htmlSkipped[n.name]:
+	case n.name == "br" || n.name == "hr" || n.name == "col":
+		b.WriteString("<" + n.name + "/>")
+	case n.name == "pre":
+		b.WriteString(codeMacro(codeLanguage(n), strings.TrimSuffix(n.textContent(), "\n")))
+	case n.name == "a":
+		c.link(b, n)
+	case n.name == "img":
+		c.image(b, n)
+	case htmlKept[n.name]:
+		b.WriteString("<" + n.name)
+		for _, name := range htmlKeptAttributes {
+			if value := n.attr(name); value != "" {
+				fmt.Fprintf(b, ` %s="%s"`, name, html.EscapeString(value))
+			}
+		}
+		b.WriteString(">")
+		c.nodes(b, n.children)
+		b.WriteString("</" + n.name + ">")
+	default:
+		c.nodes(b, n.children)
+	}
+}
+
+// codeLanguage reads a "language-x" or "lang-x" class from a <pre> or the <code> inside it
+func codeLanguage(pre *storageNode) string {
+	classes := pre.attr("class")
+	if code := pre.child("code"); code != nil {
+		classes += " " + code.attr("class")
+	}
+	for _, class := range strings.Fields(classes) {
+		for _, prefix := range []string{"language-", "lang-"} {
+			if strings.HasPrefix(class, prefix) {
+				return strings.TrimPrefix(class, prefix)
+			}
+		}
+	}
+	return ""
+}
+
+// localTarget returns the unescaped file part and the anchor of a relative href, or ok=false
+func localTarget(href string) (file, anchor string, ok bool) {
+	if href == "" || !isRelativeTarget(href) {
+		return "", "", false
+	}
+	file, anchor, _ = strings.Cut(href, "#")
+	file, _, _ = strings.Cut(file, "?")
+	if unescaped, err := url.PathUnescape(file); err == nil {
+		file = unescaped
+	}
+	return file, anchor, true
+}
+
+func (c *htmlConverter) link(b *strings.Builder, n *storageNode) {
+	href := n.attr("href")
+	label := strings.Join(strings.Fields(n.textContent()), " ")
+	file, anchor, ok := localTarget(href)
+	if !ok {
+		if href == "" {
+			c.nodes(b, n.children)
+			return
+		}
+		fmt.Fprintf(b, `<a href="%s">`, html.EscapeString(href))
+		c.nodes(b, n.children)
+		b.WriteString("</a>")
+		return
+	}
+
+	b.WriteString("<ac:link")
+	if anchor != "" {
+		fmt.Fprintf(b, ` ac:anchor="%s"`, html.EscapeString(anchor))
+	}
+	b.WriteString(">")
+	if file != "" {
+		if title, found := c.pageTitle(file); found {
+			fmt.Fprintf(b, `<ri:page ri:content-title="%s"/>`, html.EscapeString(title))
+		} else {
+			fmt.Fprintf(b, `<ri:attachment ri:filename="%s"/>`, html.EscapeString(c.options.attachmentName(file)))
+		}
+	}
+	b.WriteString("<ac:plain-text-link-body>" + cdata(label) + "</ac:plain-text-link-body></ac:link>")
+}
+
+func (c *htmlConverter) pageTitle(file string) (string, bool) {
+	if c.options.PageTitle == nil {
+		return "", false
+	}
+	return c.options.PageTitle(file)
+}
+
+func (c *htmlConverter) image(b *strings.Builder, n *storageNode) {
+	src := n.attr("src")
+	if src == "" {
+		return
+	}
+	b.WriteString("<ac:image")
+	if alt := n.attr("alt"); alt != "" {
+		fmt.Fprintf(b, ` ac:alt="%s"`, html.EscapeString(alt))
+	}
+	b.WriteString(">")
+	if file, _, ok := localTarget(src); ok && file != "" {
+		fmt.Fprintf(b, `<ri:attachment ri:filename="%s"/>`, html.EscapeString(c.options.attachmentName(file)))
+	} else {
+		fmt.Fprintf(b, `<ri:url ri:value="%s"/>`, html.EscapeString(src))
+	}
+	b.WriteString("</ac:image>")
+}
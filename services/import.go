@@ -0,0 +1,546 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// ImportCheckpointFile is the default checkpoint file name, kept at the root of the imported directory
+const ImportCheckpointFile = ".confluence-import.json"
+
+// Import actions reported for each page
+const (
+	ImportCreate = "create"
+	ImportResume = "resume"
+	ImportSkip   = "skip"
+)
+
+// ImportOptions configures an import of a directory tree into a space
+type ImportOptions struct {
+	Dir        string
+	SpaceKey   string
+	ParentID   string
+	Checkpoint string // checkpoint file, ImportCheckpointFile in Dir by default
+	DryRun     bool
+}
+
+// ImportCheckpoint records the pages and attachments already created, so a failed import can be resumed
+type ImportCheckpoint struct {
+	SpaceKey string                   `json:"space_key"`
+	ParentID string                   `json:"parent_id,omitempty"`
+	Pages    map[string]*ImportedPage `json:"pages"` // keyed by source path
+}
+
+// ImportedPage is the progress of one imported page
+type ImportedPage struct {
+	ID          string   `json:"id"`
+	Title       string   `json:"title"`
+	Attachments []string `json:"attachments,omitempty"`
+	Done        bool     `json:"done"`
+}
+
+// ImportAction is what an import did, or would do, for one page
+type ImportAction struct {
+	Action      string   `json:"action" yaml:"action"`
+	Source      string   `json:"source" yaml:"source"`
+	Title       string   `json:"title" yaml:"title"`
+	ID          string   `json:"id,omitempty" yaml:"id,omitempty"`
+	Parent      string   `json:"parent,omitempty" yaml:"parent,omitempty"`
+	Attachments int      `json:"attachments,omitempty" yaml:"attachments,omitempty"`
+	Missing     []string `json:"missing,omitempty" yaml:"missing,omitempty"`
+}
+
+// ImportReport lists the outcome of an import
+type ImportReport struct {
+	Dir        string         `json:"dir" yaml:"dir"`
+	Space      string         `json:"space" yaml:"space"`
+	DryRun     bool           `json:"dry_run" yaml:"dry_run"`
+	Checkpoint string         `json:"checkpoint" yaml:"checkpoint"`
+	Pages      []ImportAction `json:"pages" yaml:"pages"`
+	Summary    map[string]int `json:"summary" yaml:"summary"`
+}
+
+// importNode is a page to import: a Markdown or HTML file, or a folder without one
+type importNode struct {
+	key      string // source path relative to the import directory; folders end with "/"
+	file     string // relative file path, empty for a placeholder page
+	title    string
+	children []*importNode
+}
+
+var importExtensions = map[string]bool{".md": true, ".markdown": true, ".html": true, ".htm": true}
+
+// indexNames are file names used as the page of the folder they are in
+var indexNames = []string{"index.md", "readme.md", "index.html", "index.htm"}
+
+var firstHeading = regexp.MustCompile(`^#\s+(.+?)\s*#*\s*$`)
+
+// LoadImportCheckpoint reads a checkpoint file, returning an empty checkpoint if there is none
+func LoadImportCheckpoint(file string) (*ImportCheckpoint, error) {
+	checkpoint := &ImportCheckpoint{Pages: make(map[string]*ImportedPage)}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, checkpoint); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", file, err)
+	}
+	if checkpoint.Pages == nil {
+		checkpoint.Pages = make(map[string]*ImportedPage)
+	}
+	return checkpoint, nil
+}
+
+// Save writes the checkpoint through a temporary file, so an interrupted write never corrupts it
+func (c *ImportCheckpoint) Save(file string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// importer holds the state of a running import
+type importer struct {
+	ctx        context.Context
+	client     *confluence.Client
+	options    ImportOptions
+	checkpoint *ImportCheckpoint
+	titles     map[string]string // relative file path -> page title
+	report     *ImportReport
+}
+
+// ImportDirectory creates a page tree from a directory of Markdown and HTML files. A file
+// "<name>.md" (or an index.md, README.md or index.html inside "<name>/") is the parent of the
+// pages in "<name>/". Relative images and files are uploaded as attachments and relative links
+// to other imported files become page links. Progress is saved to a checkpoint file after every
+// step, so running the import again resumes where it stopped.
+func ImportDirectory(ctx context.Context, client *confluence.Client, options ImportOptions) (*ImportReport, error) {
+	if options.Checkpoint == "" {
+		options.Checkpoint = filepath.Join(options.Dir, ImportCheckpointFile)
+	}
+	checkpoint, err := LoadImportCheckpoint(options.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+	if len(checkpoint.Pages) > 0 && (checkpoint.SpaceKey != options.SpaceKey || checkpoint.ParentID != options.ParentID) {
+		return nil, fmt.Errorf("checkpoint %s belongs to an import into space %s (parent %q); use another checkpoint file or delete it",
+			options.Checkpoint, checkpoint.SpaceKey, checkpoint.ParentID)
+	}
+	checkpoint.SpaceKey, checkpoint.ParentID = options.SpaceKey, options.ParentID
+
+	roots, err := scanImportDir(options.Dir, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	i := &importer{
+		ctx:        ctx,
+		client:     client,
+		options:    options,
+		checkpoint: checkpoint,
+		titles:     make(map[string]string),
+		report: &ImportReport{
+			Dir:        options.Dir,
+			Space:      options.SpaceKey,
+			DryRun:     options.DryRun,
+			Checkpoint: options.Checkpoint,
+			Summary:    make(map[string]int),
+		},
+	}
+	if err := i.readTitles(roots); err != nil {
+		return nil, err
+	}
+	return i.report, i.importNodes(roots, options.ParentID)
+}
+
+// scanImportDir builds the page tree of one directory. skipIndex leaves out its index file,
+// which is then used as the page of the folder itself.
+func scanImportDir(root, dir string, skipIndex bool) ([]*importNode, error) {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string) // name without extension -> file name
+	folders := make(map[string]bool)
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		if entry.IsDir() {
+			folders[name] = true
+			continue
+		}
+		ext := filepath.Ext(name)
+		if !importExtensions[strings.ToLower(ext)] {
+			continue
+		}
+		if skipIndex && isIndexName(name) {
+			continue
+		}
+		files[strings.TrimSuffix(name, ext)] = name
+	}
+
+	names := make([]string, 0, len(files)+len(folders))
+	for name := range files {
+		names = append(names, name)
+	}
+	for name := range folders {
+		if _, ok := files[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var nodes []*importNode
+	for _, name := range names {
+		node := &importNode{title: name}
+		if file, ok := files[name]; ok {
+			node.file = path.Join(dir, file)
+		} else if index := findIndexFile(root, path.Join(dir, name)); index != "" {
+			node.file = index
+		}
+		node.key = node.file
+		if folders[name] {
+			if node.key == "" {
+				node.key = path.Join(dir, name) + "/"
+			}
+			_, hasFile := files[name]
+			children, err := scanImportDir(root, path.Join(dir, name), !hasFile)
+			if err != nil {
+				return nil, err
+			}
+			node.children = children
+		}
+		if node.file == "" && len(node.children) == 0 {
+			continue // e.g. a folder of images
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func isIndexName(name string) bool {
+	for _, index := range indexNames {
+		if strings.EqualFold(name, index) {
+			return true
+		}
+	}
+	return false
+}
+
+// findIndexFile returns the relative path of the index file of a folder, if it has one
+func findIndexFile(root, dir string) string {
+	entries, err := os.ReadDir(filepath.Join(root, filepath.FromSlash(dir)))
+	if err != nil {
+		return ""
+	}
+	for _, index := range indexNames {
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.EqualFold(entry.Name(), index) {
+				return path.Join(dir, entry.Name())
+			}
+		}
+	}
+	return ""
+}
+
+// readTitles reads the title of every file: its front matter title, first heading or HTML title
+func (i *importer) readTitles(nodes []*importNode) error {
+	for _, node := range nodes {
+		if node.file != "" {
+			data, err := os.ReadFile(filepath.Join(i.options.Dir, filepath.FromSlash(node.file)))
+			if err != nil {
+				return err
+			}
+			if title := sourceTitle(node.file, data); title != "" {
+				node.title = title
+			}
+			i.titles[node.file] = node.title
+		}
+		if err := i.readTitles(node.children); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isHTMLFile(file string) bool {
+	ext := strings.ToLower(path.Ext(file))
+	return ext == ".html" || ext == ".htm"
+}
+
+func sourceTitle(file string, data []byte) string {
+	if isHTMLFile(file) {
+		return HTMLTitle(string(data))
+	}
+	frontMatter, body, err := ParsePageFile(data)
+	if err == nil && frontMatter.Title != "" {
+		return frontMatter.Title
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if match := firstHeading.FindStringSubmatch(line); match != nil {
+			return match[1]
+		}
+		break
+	}
+	return ""
+}
+
+// markdownBody returns the body of a Markdown file without its front matter and, when it
+// repeats the page title, its first heading
+func markdownBody(data []byte, title string) string {
+	_, body, err := ParsePageFile(data)
+	if err != nil {
+		body = string(data)
+	}
+	trimmed := strings.TrimLeft(body, "\n")
+	first, rest, _ := strings.Cut(trimmed, "\n")
+	if match := firstHeading.FindStringSubmatch(first); match != nil && match[1] == title {
+		return rest
+	}
+	return body
+}
+
+func (i *importer) importNodes(nodes []*importNode, parentID string) error {
+	for _, node := range nodes {
+		pageID, err := i.importNode(node, parentID)
+		if err != nil {
+			return fmt.Errorf("%s: %w", node.key, err)
+		}
+		if err := i.importNodes(node.children, pageID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pageAttachment is a local file referenced by a page and uploaded as one of its attachments
+type pageAttachment struct {
+	name string
+	file string // path relative to the import directory
+}
+
+// resolveImportPath resolves a link target against the folder of the file it appears in and
+// reports whether the result stays inside the import directory
+func resolveImportPath(baseDir, target string) (string, bool) {
+	if path.IsAbs(target) || filepath.IsAbs(target) {
+		return path.Clean(target), false
+	}
+	resolved := path.Clean(path.Join(baseDir, target))
+	if resolved == ".." || strings.HasPrefix(resolved, "../") || path.IsAbs(resolved) {
+		return resolved, false
+	}
+	return resolved, true
+}
+
+// convert renders a source file as storage format and collects the local files it references
+func (i *importer) convert(node *importNode) (string, []pageAttachment, []string, error) {
+	if node.file == "" {
+		return "", nil, nil, nil
+	}
+	data, err := os.ReadFile(filepath.Join(i.options.Dir, filepath.FromSlash(node.file)))
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	var attachments []pageAttachment
+	var missing []string
+	names := make(map[string]string) // attachment name -> file
+	baseDir := path.Dir(node.file)
+	options := &StorageOptions{
+		PageTitle: func(target string) (string, bool) {
+			resolved, ok := resolveImportPath(baseDir, unescapeTarget(target))
+			if !ok {
+				return "", false
+			}
+			if title, ok := i.titles[resolved]; ok {
+				return title, true
+			}
+			if index := findIndexFile(i.options.Dir, resolved); index != "" {
+				title, ok := i.titles[index]
+				return title, ok
+			}
+			return "", false
+		},
+		AttachmentName: func(target string) string {
+			target = unescapeTarget(target)
+			resolved, inside := resolveImportPath(baseDir, target)
+			name := path.Base(resolved)
+			if file, ok := names[name]; ok && file == resolved {
+				return name
+			}
+			var info os.FileInfo
+			var err error
+			if inside {
+				info, err = os.Stat(filepath.Join(i.options.Dir, filepath.FromSlash(resolved)))
+			}
+			if !inside || err != nil || info.IsDir() {
+				if !slices.Contains(missing, target) {
+					missing = append(missing, target)
+				}
+				return name
+			}
+			// Two files with the same name in different folders get distinct attachment names
+			for n := 2; names[name] != "" && names[name] != resolved; n++ {
+				name = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(path.Base(resolved), path.Ext(resolved)), n, path.Ext(resolved))
+			}
+			if _, ok := names[name]; !ok {
+				names[name] = resolved
+				attachments = append(attachments, pageAttachment{name: name, file: resolved})
+			}
+			return name
+		},
+	}
+
+	var storage string
+	if isHTMLFile(node.file) {
+		storage, err = HTMLToStorage(string(data), options)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("failed to parse HTML: %w", err)
+		}
+	} else {
+		storage = MarkdownToStorage(markdownBody(data, node.title), options)
+	}
+	return storage, attachments, missing, nil
+}
+
+func unescapeTarget(target string) string {
+	if unescaped, err := url.PathUnescape(target); err == nil {
+		return unescaped
+	}
+	return target
+}
+
+// importNode creates one page and uploads its attachments, recording each step in the checkpoint
+func (i *importer) importNode(node *importNode, parentID string) (string, error) {
+	action := ImportAction{Source: node.key, Title: node.title, Parent: parentID}
+	progress := i.checkpoint.Pages[node.key]
+	if progress != nil && progress.Done {
+		action.Action, action.ID = ImportSkip, progress.ID
+		i.add(action)
+		return progress.ID, nil
+	}
+
+	storage, attachments, missing, err := i.convert(node)
+	if err != nil {
+		return "", err
+	}
+	action.Attachments, action.Missing = len(attachments), missing
+
+	if progress != nil {
+		action.Action, action.ID = ImportResume, progress.ID
+	} else {
+		action.Action = ImportCreate
+	}
+	if i.options.DryRun {
+		i.add(action)
+		return action.ID, nil
+	}
+
+	if progress == nil {
+		pageID, err := i.createPage(node.title, storage, parentID)
+		if err != nil {
+			return "", err
+		}
+		progress = &ImportedPage{ID: pageID, Title: node.title}
+		i.checkpoint.Pages[node.key] = progress
+		if err := i.checkpoint.Save(i.options.Checkpoint); err != nil {
+			return "", err
+		}
+		action.ID = pageID
+	}
+
+	uploaded := make(map[string]bool, len(progress.Attachments))
+	for _, name := range progress.Attachments {
+		uploaded[name] = true
+	}
+	for _, attachment := range attachments {
+		if uploaded[attachment.name] {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(i.options.Dir, filepath.FromSlash(attachment.file)))
+		if err != nil {
+			return "", err
+		}
+		_, response, err := i.client.Content.Attachment.CreateOrUpdate(i.ctx, progress.ID, "current", attachment.name, bytes.NewReader(data))
+		if err != nil {
+			return "", ResponseError("failed to upload "+attachment.file, response, err)
+		}
+		progress.Attachments = append(progress.Attachments, attachment.name)
+		if err := i.checkpoint.Save(i.options.Checkpoint); err != nil {
+			return "", err
+		}
+	}
+
+	progress.Done = true
+	if err := i.checkpoint.Save(i.options.Checkpoint); err != nil {
+		return "", err
+	}
+	i.add(action)
+	return progress.ID, nil
+}
+
+// createPage creates a page. When a page with the same title already exists under the same
+// parent, e.g. because the checkpoint could not be saved after it was created, it is reused.
+func (i *importer) createPage(title, storage, parentID string) (string, error) {
+	payload := &models.ContentScheme{
+		Type:  "page",
+		Title: title,
+		Space: &models.SpaceScheme{Key: i.options.SpaceKey},
+		Body:  &models.BodyScheme{Storage: &models.BodyNodeScheme{Value: storage, Representation: "storage"}},
+	}
+	if parentID != "" {
+		payload.Ancestors = []*models.ContentScheme{{ID: parentID}}
+	}
+	page, response, err := i.client.Content.Create(i.ctx, payload)
+	if err == nil {
+		return page.ID, nil
+	}
+	createErr := ResponseError("failed to create page "+title, response, err)
+
+	options := &models.GetContentOptionsScheme{ContextType: "page", SpaceKey: i.options.SpaceKey, Title: title, Expand: []string{"ancestors"}}
+	existing, _, lookupErr := i.client.Content.Gets(i.ctx, options, 0, 1)
+	if lookupErr != nil || len(existing.Results) == 0 {
+		return "", createErr
+	}
+	found := existing.Results[0]
+	foundParent := ""
+	if len(found.Ancestors) > 0 {
+		foundParent = found.Ancestors[len(found.Ancestors)-1].ID
+	}
+	if foundParent != parentID {
+		return "", fmt.Errorf("%w; a page with this title already exists elsewhere in the space (id %s)", createErr, found.ID)
+	}
+	return found.ID, nil
+}
+
+func (i *importer) add(action ImportAction) {
+	i.report.Pages = append(i.report.Pages, action)
+	i.report.Summary[action.Action]++
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"context"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// IndexRefresh reports what a crawl of one space changed in the local index
+type IndexRefresh struct {
+	Space   string `json:"space" yaml:"space"`
+	Mode    string `json:"mode" yaml:"mode"` // full or incremental
+	Indexed int    `json:"indexed" yaml:"indexed"`
+	Removed int    `json:"removed" yaml:"removed"`
+	Pages   int    `json:"pages" yaml:"pages"`
+}
+
+var (
+	indexMu sync.Mutex
+	indexes = make(map[string]*SearchIndex)
+)
+
+// IndexDir returns the directory of the local search indexes, CONFLUENCE_MCP_INDEX_DIR or
+// confluence-mcp/index in the user cache directory
+func IndexDir() (string, error) {
+	if dir := os.Getenv("CONFLUENCE_MCP_INDEX_DIR"); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "confluence-mcp", "index"), nil
+}
+
+func indexPath(site string) (string, error) {
+	dir, err := IndexDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileSlug(site)+".gob"), nil
+}
+
+// OpenSearchIndex returns the local search index of a site, loading it from disk on first use
+func OpenSearchIndex(cfg *AtlassianConfig) (*SearchIndex, error) {
+	indexMu.Lock()
+	defer indexMu.Unlock()
+
+	site := cfg.SiteName()
+	if ix, ok := indexes[site]; ok {
+		return ix, nil
+	}
+
+	file, err := indexPath(site)
+	if err != nil {
+		return nil, err
+	}
+	ix := NewSearchIndex(site)
+	f, err := os.Open(file)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		defer f.Close()
+		if err := gob.NewDecoder(f).Decode(ix); err != nil {
+			return nil, fmt.Errorf("failed to read search index %s: %w (delete it to rebuild)", file, err)
+		}
+	}
+	indexes[site] = ix
+	return ix, nil
+}
+
+// Save writes the index to disk
+func (ix *SearchIndex) Save() error {
+	file, err := indexPath(ix.Site)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(ix); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+var indexExpand = []string{"body.storage", "version", "space", "metadata.labels"}
+
+// RefreshSpace crawls a space into the index. The first crawl, or one with full set, reads every
+// page; later crawls only fetch pages modified since the previous one and drop deleted pages.
+func (ix *SearchIndex) RefreshSpace(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, spaceKey string, full bool) (*IndexRefresh, error) {
+	started := time.Now()
+	since, crawled := ix.IndexedSpaces()[spaceKey]
+	refresh := &IndexRefresh{Space: spaceKey, Mode: "incremental"}
+
+	cql := fmt.Sprintf(`space = "%s" and type = page`, spaceKey)
+	if full || !crawled {
+		refresh.Mode = "full"
+	} else {
+		// CQL dates are in the server's time zone and have minute precision: look back a day
+		cql += fmt.Sprintf(` and lastmodified >= "%s"`, since.Add(-24*time.Hour).Format("2006-01-02"))
+	}
+
+	pages, response, err := SearchAllContent(ctx, client, cql, indexExpand)
+	if err != nil {
+		return nil, ResponseError("failed to crawl space "+spaceKey, response, err)
+	}
+
+	current := make(map[string]bool)
+	for _, page := range pages {
+		current[page.ID] = true
+		ix.mu.RLock()
+		existing := ix.Pages[page.ID]
+		ix.mu.RUnlock()
+		if existing != nil && page.Version != nil && existing.Version == page.Version.Number && !full {
+			continue
+		}
+		ix.Put(indexedPage(cfg, page))
+		refresh.Indexed++
+	}
+
+	// An incremental crawl only sees modified pages: list the IDs of the space to find deletions
+	if refresh.Mode == "incremental" {
+		all, response, err := SpacePages(ctx, client, spaceKey, nil)
+		if err != nil {
+			return nil, ResponseError("failed to list pages of space "+spaceKey, response, err)
+		}
+		current = make(map[string]bool, len(all))
+		for _, page := range all {
+			current[page.ID] = true
+		}
+	}
+	for _, id := range ix.SpacePageIDs(spaceKey) {
+		if !current[id] {
+			ix.Remove(id)
+			refresh.Removed++
+		}
+	}
+
+	ix.mu.Lock()
+	ix.Spaces[spaceKey] = started
+	ix.mu.Unlock()
+	refresh.Pages = len(ix.SpacePageIDs(spaceKey))
+	return refresh, nil
+}
+
+// indexedPage extracts the searchable fields of a page
+func indexedPage(cfg *AtlassianConfig, page *models.ContentScheme) *IndexedPage {
+	body, headings := storageText(ContentBody(page))
+	indexed := &IndexedPage{ID: page.ID, Title: page.Title, Headings: headings, Body: body}
+	if page.Space != nil {
+		indexed.SpaceKey = page.Space.Key
+	}
+	if page.Version != nil {
+		indexed.Version = page.Version.Number
+		indexed.Modified, _ = time.Parse(time.RFC3339, page.Version.When)
+	}
+	if page.Links != nil {
+		indexed.URL = cfg.WebURL(page.Links.Webui)
+	}
+	if page.Metadata != nil && page.Metadata.Labels != nil {
+		for _, label := range page.Metadata.Labels.Results {
+			indexed.Labels = append(indexed.Labels, label.Name)
+		}
+	}
+	return indexed
+}
+
+// storageText returns the plain text of a storage format body and the text of its headings
+func storageText(storage string) (string, []string) {
+	root, err := parseStorage(storage)
+	if err != nil {
+		return "", nil
+	}
+	var text strings.Builder
+	var headings []string
+	var walk func(n *storageNode)
+	walk = func(n *storageNode) {
+		if n.isText {
+			text.WriteString(n.text)
+			return
+		}
+		switch n.name {
+		case "ac:parameter":
+			return
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			if heading := strings.Join(strings.Fields(n.textContent()), " "); heading != "" {
+				headings = append(headings, heading)
+			}
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+		if isBlock(n) || n.name == "br" || n.name == "td" || n.name == "th" {
+			text.WriteString("\n")
+		}
+	}
+	walk(root)
+	return strings.TrimSpace(text.String()), headings
+}
+
+// SearchAllContent runs a CQL content search and follows its "next" links until every result is read
+func SearchAllContent(ctx context.Context, client *confluence.Client, cql string, expand []string) ([]*models.ContentScheme, *models.ResponseScheme, error) {
+	query := url.Values{}
+	query.Set("cql", cql)
+	query.Set("limit", "50")
+	if len(expand) != 0 {
+		query.Set("expand", strings.Join(expand, ","))
+	}
+	endpoint := "wiki/rest/api/content/search?" + query.Encode()
+
+	var contents []*models.ContentScheme
+	var response *models.ResponseScheme
+	for endpoint != "" {
+		request, err := client.NewRequest(ctx, http.MethodGet, endpoint, "", nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		page := new(models.ContentPageScheme)
+		response, err = client.Call(request, page)
+		if err != nil {
+			return nil, response, err
+		}
+		contents = append(contents, page.Results...)
+
+		endpoint = ""
+		if page.Links != nil && page.Links.Next != "" && len(page.Results) > 0 {
+			// Next links are relative to the Confluence root, which the client addresses as "wiki/"
+			endpoint = "wiki/" + strings.TrimPrefix(page.Links.Next, "/")
+		}
+	}
+	return contents, response, nil
+}
+
+// LocalSearchOptions configures a search of the local index
+type LocalSearchOptions struct {
+	Query   string
+	Spaces  []string // spaces to search; spaces not indexed yet are crawled first
+	Refresh bool     // refresh the searched spaces, or every indexed space, before searching
+	Limit   int
+	Boosts  map[string]float64
+}
+
+// LocalSearchResult is the outcome of a local search
+type LocalSearchResult struct {
+	Query         string            `json:"query" yaml:"query"`
+	Results       []LocalSearchItem `json:"results" yaml:"results"`
+	ResultCount   int               `json:"result_count" yaml:"result_count"`
+	IndexedSpaces []string          `json:"indexed_spaces" yaml:"indexed_spaces"`
+	Refreshed     []*IndexRefresh   `json:"refreshed,omitempty" yaml:"refreshed,omitempty"`
+}
+
+// LocalSearchItem is one page found by a local search
+type LocalSearchItem struct {
+	ID           string   `json:"id" yaml:"id"`
+	Title        string   `json:"title" yaml:"title"`
+	Space        string   `json:"space" yaml:"space"`
+	Link         string   `json:"link" yaml:"link"`
+	Score        float64  `json:"score" yaml:"score"`
+	MatchedIn    []string `json:"matched_in" yaml:"matched_in"`
+	Snippet      string   `json:"snippet,omitempty" yaml:"snippet,omitempty"`
+	LastModified string   `json:"last_modified,omitempty" yaml:"last_modified,omitempty"`
+}
+
+// LocalSearch searches the local index of a site, crawling or refreshing spaces first as requested
+func LocalSearch(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, options LocalSearchOptions) (*LocalSearchResult, error) {
+	ix, err := OpenSearchIndex(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := ix.IndexedSpaces()
+	result := &LocalSearchResult{Query: options.Query, Results: []LocalSearchItem{}}
+	var crawl []string
+	for _, key := range options.Spaces {
+		if _, ok := indexed[key]; !ok || options.Refresh {
+			crawl = append(crawl, key)
+		}
+	}
+	if options.Refresh && len(options.Spaces) == 0 {
+		for key := range indexed {
+			crawl = append(crawl, key)
+		}
+	}
+	if len(crawl) == 0 && len(indexed) == 0 {
+		return nil, fmt.Errorf("no space is indexed yet: pass a space key to index it, or run confluence-cli index --space KEY")
+	}
+
+	for _, key := range crawl {
+		refresh, err := ix.RefreshSpace(ctx, client, cfg, key, false)
+		if err != nil {
+			return nil, err
+		}
+		result.Refreshed = append(result.Refreshed, refresh)
+	}
+	if len(crawl) > 0 {
+		if err := ix.Save(); err != nil {
+			return nil, fmt.Errorf("failed to save search index: %w", err)
+		}
+	}
+
+	for key := range ix.IndexedSpaces() {
+		result.IndexedSpaces = append(result.IndexedSpaces, key)
+	}
+	sort.Strings(result.IndexedSpaces)
+
+	hits := ix.Search(LocalSearchQuery{Text: options.Query, Spaces: options.Spaces, Limit: options.Limit, Boosts: options.Boosts})
+	for _, hit := range hits {
+		item := LocalSearchItem{
+			ID:        hit.Page.ID,
+			Title:     hit.Page.Title,
+			Space:     hit.Page.SpaceKey,
+			Link:      hit.Page.URL,
+			Score:     hit.Score,
+			MatchedIn: hit.Fields,
+			Snippet:   hit.Snippet,
+		}
+		if !hit.Page.Modified.IsZero() {
+			item.LastModified = hit.Page.Modified.Format(time.RFC3339)
+		}
+		result.Results = append(result.Results, item)
+	}
+	result.ResultCount = len(result.Results)
+	return result, nil
+}
@@ -0,0 +1,683 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// Link types
+const (
+	LinkPage       = "page"
+	LinkAttachment = "attachment"
+	LinkURL        = "url"
+)
+
+// Link statuses
+const (
+	LinkOK        = "ok"
+	LinkBroken    = "broken"    // the page does not exist
+	LinkMissing   = "missing"   // the attachment does not exist
+	LinkDead      = "dead"      // the URL does not answer or answers with an error
+	LinkUnchecked = "unchecked" // external URL, not checked
+)
+
+var downloadPathPattern = regexp.MustCompile(`/download/(?:attachments|thumbnails)/(\d+)/([^/?#]+)`)
+
+// Link is a link of a page to a page, an attachment or a URL
+type Link struct {
+	Type     string `json:"type" yaml:"type"`
+	Target   string `json:"target" yaml:"target"` // SPACE:Title, page:file name or URL
+	SpaceKey string `json:"space,omitempty" yaml:"space,omitempty"`
+	Title    string `json:"title,omitempty" yaml:"title,omitempty"`
+	PageID   string `json:"page_id,omitempty" yaml:"page_id,omitempty"` // the linked page, or the page the attachment is on
+	Filename string `json:"filename,omitempty" yaml:"filename,omitempty"`
+	URL      string `json:"url,omitempty" yaml:"url,omitempty"`
+	Status   string `json:"status,omitempty" yaml:"status,omitempty"`
+	Problem  string `json:"problem,omitempty" yaml:"problem,omitempty"`
+	Count    int    `json:"count,omitempty" yaml:"count,omitempty"` // times the page uses the link, when more than once
+}
+
+func (l *Link) key() string {
+	return l.Type + "\x00" + l.SpaceKey + "\x00" + l.Title + "\x00" + l.PageID + "\x00" + l.Filename + "\x00" + l.URL
+}
+
+// ParseLinks returns the distinct links of a storage format body: ac:link and ac:image
+// references to pages and attachments, page references in macro parameters, and a href URLs.
+// Links to Confluence pages and attachment downloads written as URLs are read as page and
+// attachment links. The space of page links in the same space and the page of attachments of
+// the page itself are left empty for the resolver to fill in.
+func ParseLinks(cfg *AtlassianConfig, body string) ([]*Link, error) {
+	root, err := parseStorage(body)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []*Link
+	seen := map[string]*Link{}
+	add := func(link *Link) {
+		if link == nil {
+			return
+		}
+		if previous, ok := seen[link.key()]; ok {
+			previous.Count++
+			return
+		}
+		link.Count = 1
+		seen[link.key()] = link
+		links = append(links, link)
+	}
+
+	var walk func(n *storageNode)
+	walk = func(n *storageNode) {
+		for _, c := range n.children {
+			switch {
+			case c.isText:
+			case c.name == "ri:page" || c.name == "ri:content-entity":
+				add(pageReference(c))
+			case c.name == "ri:attachment":
+				add(attachmentReference(c))
+			case c.name == "ri:url":
+				add(urlLink(cfg, c.attr("ri:value")))
+			case c.name == "a":
+				add(urlLink(cfg, c.attr("href")))
+				walk(c)
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(root)
+
+	for _, link := range links {
+		if link.Count == 1 {
+			link.Count = 0
+		}
+	}
+	return links, nil
+}
+
+func pageReference(n *storageNode) *Link {
+	link := &Link{Type: LinkPage, SpaceKey: n.attr("ri:space-key"), Title: n.attr("ri:content-title"), PageID: n.attr("ri:content-id")}
+	if link.Title == "" && link.PageID == "" {
+		return nil
+	}
+	return link
+}
+
+func attachmentReference(n *storageNode) *Link {
+	link := &Link{Type: LinkAttachment, Filename: n.attr("ri:filename")}
+	if link.Filename == "" {
+		return nil
+	}
+	if page := n.child("ri:page"); page != nil {
+		link.SpaceKey, link.Title = page.attr("ri:space-key"), page.attr("ri:content-title")
+	} else if entity := n.child("ri:content-entity"); entity != nil {
+		link.PageID = entity.attr("ri:content-id")
+	} else if n.child("ri:blog-post") != nil {
+		// Attachments of blog posts are not checked
+		return nil
+	}
+	return link
+}
+
+// urlLink reads a URL, recognising links to pages and attachments of the site
+func urlLink(cfg *AtlassianConfig, href string) *Link {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return nil
+	}
+	link := &Link{Type: LinkURL, Target: href, URL: href}
+	u, err := url.Parse(href)
+	if err != nil || !cfg.isSiteURL(u) {
+		return link
+	}
+	if match := downloadPathPattern.FindStringSubmatch(u.Path); match != nil {
+		filename, _ := url.PathUnescape(match[2])
+		return &Link{Type: LinkAttachment, PageID: match[1], Filename: filename, URL: href, Target: href}
+	}
+	if id := u.Query().Get("pageId"); pageIDPattern.MatchString(id) {
+		return &Link{Type: LinkPage, PageID: id, URL: href, Target: href}
+	}
+	if match := urlPageIDPattern.FindStringSubmatch(u.Path); match != nil {
+		return &Link{Type: LinkPage, PageID: match[1], URL: href, Target: href}
+	}
+	if match := tinyLinkPattern.FindStringSubmatch(u.Path); match != nil {
+		if id, err := DecodeTinyLink(match[1]); err == nil {
+			return &Link{Type: LinkPage, PageID: id, URL: href, Target: href}
+		}
+	}
+	if match := displayPathPattern.FindStringSubmatch(u.EscapedPath()); match != nil {
+		spaceKey, _ := url.PathUnescape(match[1])
+		if title, err := url.QueryUnescape(match[2]); err == nil {
+			return &Link{Type: LinkPage, SpaceKey: spaceKey, Title: title, URL: href, Target: href}
+		}
+	}
+	return link
+}
+
+// isSiteURL reports whether a URL points to this Confluence site: relative paths and absolute
+// URLs on its host
+func (c *AtlassianConfig) isSiteURL(u *url.URL) bool {
+	if u.Scheme == "" && u.Host == "" {
+		return strings.HasPrefix(u.Path, "/")
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && strings.EqualFold(u.Host, c.hostName())
+}
+
+// LinkResolver checks that the pages and attachments links point to exist, looking each one up
+// once
+type LinkResolver struct {
+	client *confluence.Client
+	cfg    *AtlassianConfig
+
+	titles      map[string]string // space and title to page ID; "" when there is no such page
+	pages       map[string]bool   // page ID to whether it exists
+	attachments map[string]map[string]bool
+	urls        map[string]string // URL to its problem; "" when it answers
+}
+
+// NewLinkResolver returns a resolver with no page known yet
+func NewLinkResolver(client *confluence.Client, cfg *AtlassianConfig) *LinkResolver {
+	return &LinkResolver{
+		client:      client,
+		cfg:         cfg,
+		titles:      map[string]string{},
+		pages:       map[string]bool{},
+		attachments: map[string]map[string]bool{},
+		urls:        map[string]string{},
+	}
+}
+
+func titleKey(spaceKey, title string) string {
+	return strings.ToUpper(spaceKey) + "\x00" + title
+}
+
+// addPage records a page that exists, so links to it need no lookup
+func (r *LinkResolver) addPage(spaceKey string, page *models.ContentScheme) {
+	r.titles[titleKey(spaceKey, page.Title)] = page.ID
+	r.pages[page.ID] = true
+}
+
+func (r *LinkResolver) pageByTitle(ctx context.Context, spaceKey, title string) (string, error) {
+	key := titleKey(spaceKey, title)
+	if id, ok := r.titles[key]; ok {
+		return id, nil
+	}
+	options := &models.GetContentOptionsScheme{ContextType: "page", SpaceKey: spaceKey, Title: title}
+	page, response, err := r.client.Content.Gets(ctx, options, 0, 1)
+	if err != nil {
+		if response != nil && response.Code == http.StatusNotFound {
+			r.titles[key] = ""
+			return "", nil
+		}
+		return "", ResponseError("failed to look up page "+spaceKey+":"+title, response, err)
+	}
+	id := ""
+	if len(page.Results) > 0 {
+		id = page.Results[0].ID
+		r.pages[id] = true
+	}
+	r.titles[key] = id
+	return id, nil
+}
+
+func (r *LinkResolver) pageExists(ctx context.Context, id string) (bool, error) {
+	if exists, ok := r.pages[id]; ok {
+		return exists, nil
+	}
+	_, response, err := r.client.Content.Get(ctx, id, nil, 0)
+	if err != nil {
+		if response != nil && response.Code == http.StatusNotFound {
+			r.pages[id] = false
+			return false, nil
+		}
+		return false, ResponseError("failed to look up page "+id, response, err)
+	}
+	r.pages[id] = true
+	return true, nil
+}
+
+func (r *LinkResolver) hasAttachment(ctx context.Context, pageID, filename string) (bool, error) {
+	files, ok := r.attachments[pageID]
+	if !ok {
+		attachments, response, err := PageAttachments(ctx, r.client, pageID)
+		if err != nil {
+			return false, ResponseError("failed to list attachments of page "+pageID, response, err)
+		}
+		files = map[string]bool{}
+		for _, attachment := range attachments {
+			files[attachment.Title] = true
+		}
+		r.attachments[pageID] = files
+	}
+	return files[filename], nil
+}
+
+// ResolvePage fills in the space and ID of a page link of a page in a space and sets its
+// status. Other links are left alone.
+func (r *LinkResolver) ResolvePage(ctx context.Context, spaceKey string, link *Link) error {
+	if link.Type != LinkPage {
+		return nil
+	}
+	var exists bool
+	var err error
+	if link.PageID != "" {
+		exists, err = r.pageExists(ctx, link.PageID)
+	} else {
+		if link.SpaceKey == "" {
+			link.SpaceKey = spaceKey
+		}
+		link.PageID, err = r.pageByTitle(ctx, link.SpaceKey, link.Title)
+		exists = link.PageID != ""
+	}
+	if err != nil {
+		return err
+	}
+	if link.Target == "" {
+		link.Target = linkTarget(link)
+	}
+	if exists {
+		link.Status = LinkOK
+	} else {
+		link.Status, link.Problem = LinkBroken, "page does not exist"
+	}
+	return nil
+}
+
+// resolveOwner fills in the page an attachment link of a page refers to. It returns false when
+// that page does not exist.
+func (r *LinkResolver) resolveOwner(ctx context.Context, pageID, spaceKey string, link *Link) (bool, error) {
+	if link.PageID != "" {
+		return true, nil
+	}
+	if link.Title == "" {
+		link.PageID = pageID
+		return true, nil
+	}
+	if link.SpaceKey == "" {
+		link.SpaceKey = spaceKey
+	}
+	id, err := r.pageByTitle(ctx, link.SpaceKey, link.Title)
+	link.PageID = id
+	return id != "", err
+}
+
+// ResolveAttachment fills in the page of an attachment link of a page and checks that the
+// attachment exists
+func (r *LinkResolver) ResolveAttachment(ctx context.Context, pageID, spaceKey string, link *Link) error {
+	if link.Type != LinkAttachment {
+		return nil
+	}
+	found, err := r.resolveOwner(ctx, pageID, spaceKey, link)
+	if err != nil {
+		return err
+	}
+	link.Target = linkTarget(link)
+	if !found {
+		link.Status, link.Problem = LinkMissing, "the page the attachment is on does not exist"
+		return nil
+	}
+	if found, err = r.hasAttachment(ctx, link.PageID, link.Filename); err != nil {
+		return err
+	}
+	if found {
+		link.Status = LinkOK
+	} else {
+		link.Status, link.Problem = LinkMissing, "attachment does not exist"
+	}
+	return nil
+}
+
+func linkTarget(link *Link) string {
+	switch link.Type {
+	case LinkPage:
+		if link.Title != "" {
+			return link.SpaceKey + ":" + link.Title
+		}
+		return link.PageID
+	case LinkAttachment:
+		owner := link.PageID
+		if link.Title != "" {
+			owner = link.SpaceKey + ":" + link.Title
+		}
+		return owner + "/" + link.Filename
+	}
+	return link.URL
+}
+
+// CheckURLs requests the external URLs of links, a few at a time, and marks those that fail or
+// answer with an error status as dead. Links to the site itself are not requested.
+func (r *LinkResolver) CheckURLs(ctx context.Context, links []*Link, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	var pending []string
+	for _, link := range links {
+		if link.Type != LinkURL {
+			continue
+		}
+		if _, ok := r.urls[link.URL]; !ok && isExternalURL(r.cfg, link.URL) {
+			r.urls[link.URL] = ""
+			pending = append(pending, link.URL)
+		}
+	}
+
+	client := &http.Client{Transport: DefaultHttpClient().Transport, Timeout: timeout}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, 8)
+	for _, target := range pending {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(target string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			problem := checkURL(ctx, client, target)
+			mu.Lock()
+			r.urls[target] = problem
+			mu.Unlock()
+		}(target)
+	}
+	wg.Wait()
+
+	for _, link := range links {
+		if link.Type != LinkURL {
+			continue
+		}
+		if !isExternalURL(r.cfg, link.URL) {
+			continue
+		}
+		if problem := r.urls[link.URL]; problem != "" {
+			link.Status, link.Problem = LinkDead, problem
+		} else {
+			link.Status = LinkOK
+		}
+	}
+}
+
+func isExternalURL(cfg *AtlassianConfig, target string) bool {
+	u, err := url.Parse(target)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && !cfg.isSiteURL(u)
+}
+
+// checkURL returns why a URL cannot be reached, or "" when it answers. Servers that refuse HEAD
+// requests are asked again with GET.
+func checkURL(ctx context.Context, client *http.Client, target string) string {
+	var problem string
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		request, err := http.NewRequestWithContext(ctx, method, target, nil)
+		if err != nil {
+			return err.Error()
+		}
+		request.Header.Set("User-Agent", "confluence-cli link checker")
+		response, err := client.Do(request)
+		if err != nil {
+			problem = err.Error()
+			continue
+		}
+		response.Body.Close()
+		if response.StatusCode < 400 {
+			return ""
+		}
+		problem = "HTTP " + response.Status
+	}
+	return problem
+}
+
+// PageLinks parses and checks the links of a page fetched with its body and space. External
+// URLs are only requested when checkExternal is set.
+func PageLinks(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, page *models.ContentScheme, checkExternal bool, timeout time.Duration) ([]*Link, error) {
+	links, err := ParseLinks(cfg, ContentBody(page))
+	if err != nil {
+		return nil, err
+	}
+	spaceKey := ""
+	if page.Space != nil {
+		spaceKey = page.Space.Key
+	}
+	resolver := NewLinkResolver(client, cfg)
+	for _, link := range links {
+		if err := resolver.ResolvePage(ctx, spaceKey, link); err != nil {
+			return nil, err
+		}
+		if err := resolver.ResolveAttachment(ctx, page.ID, spaceKey, link); err != nil {
+			return nil, err
+		}
+		if link.Type == LinkURL {
+			link.Status = LinkUnchecked
+		}
+	}
+	if checkExternal {
+		resolver.CheckURLs(ctx, links, timeout)
+	}
+	return links, nil
+}
+
+// GraphPage is a page of a link graph with its outgoing links
+type GraphPage struct {
+	ID       string  `json:"id" yaml:"id"`
+	Title    string  `json:"title" yaml:"title"`
+	SpaceKey string  `json:"space" yaml:"space"`
+	URL      string  `json:"url,omitempty" yaml:"url,omitempty"`
+	Links    []*Link `json:"links,omitempty" yaml:"links,omitempty"`
+
+	page *models.ContentScheme
+}
+
+// LinkGraph holds the links between the pages of one or more spaces
+type LinkGraph struct {
+	Pages    []*GraphPage
+	resolver *LinkResolver
+}
+
+// BuildLinkGraph reads every page of the spaces and parses its links. Page links are resolved;
+// attachments and URLs are only checked by CheckLinks. Pages are fetched with the extra
+// expansions given, for reports that need more than the body.
+func BuildLinkGraph(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, spaceKeys []string, expand ...string) (*LinkGraph, error) {
+	graph := &LinkGraph{resolver: NewLinkResolver(client, cfg)}
+	for _, spaceKey := range spaceKeys {
+		pages, response, err := SpacePages(ctx, client, spaceKey, append([]string{"body.storage"}, expand...))
+		if err != nil {
+			return nil, ResponseError("failed to list pages of space "+spaceKey, response, err)
+		}
+		for _, page := range pages {
+			graph.resolver.addPage(spaceKey, page)
+			node := &GraphPage{ID: page.ID, Title: page.Title, SpaceKey: spaceKey, page: page}
+			if page.Links != nil {
+				node.URL = cfg.WebURL(page.Links.Webui)
+			}
+			graph.Pages = append(graph.Pages, node)
+		}
+	}
+
+	for _, node := range graph.Pages {
+		links, err := ParseLinks(cfg, ContentBody(node.page))
+		if err != nil {
+			continue
+		}
+		for _, link := range links {
+			if err := graph.resolver.ResolvePage(ctx, node.SpaceKey, link); err != nil {
+				return nil, err
+			}
+			if link.Type == LinkAttachment {
+				if _, err := graph.resolver.resolveOwner(ctx, node.ID, node.SpaceKey, link); err != nil {
+					return nil, err
+				}
+			}
+		}
+		node.Links = links
+	}
+	return graph, nil
+}
+
+// Backlink is a page that links to another page or to its attachments
+type Backlink struct {
+	ID          string   `json:"id" yaml:"id"`
+	Title       string   `json:"title" yaml:"title"`
+	SpaceKey    string   `json:"space" yaml:"space"`
+	URL         string   `json:"url,omitempty" yaml:"url,omitempty"`
+	Links       int      `json:"links" yaml:"links"`
+	Attachments []string `json:"attachments,omitempty" yaml:"attachments,omitempty"` // linked attachments of the page
+}
+
+// Backlinks lists the pages of the graph that link to a page, by title
+func (g *LinkGraph) Backlinks(pageID string) []*Backlink {
+	backlinks := []*Backlink{}
+	for _, node := range g.Pages {
+		if node.ID == pageID {
+			continue
+		}
+		var backlink *Backlink
+		for _, link := range node.Links {
+			if link.PageID != pageID {
+				continue
+			}
+			if backlink == nil {
+				backlink = &Backlink{ID: node.ID, Title: node.Title, SpaceKey: node.SpaceKey, URL: node.URL}
+			}
+			backlink.Links += max(link.Count, 1)
+			if link.Type == LinkAttachment {
+				backlink.Attachments = append(backlink.Attachments, link.Filename)
+			}
+		}
+		if backlink != nil {
+			backlinks = append(backlinks, backlink)
+		}
+	}
+	sort.Slice(backlinks, func(i, j int) bool {
+		return strings.ToLower(backlinks[i].Title) < strings.ToLower(backlinks[j].Title)
+	})
+	return backlinks
+}
+
+// InboundCounts returns the number of other pages of the graph linking to each page
+func (g *LinkGraph) InboundCounts() map[string]int {
+	counts := map[string]int{}
+	for _, node := range g.Pages {
+		linked := map[string]bool{}
+		for _, link := range node.Links {
+			if link.Type == LinkPage && link.Status == LinkOK && link.PageID != node.ID && !linked[link.PageID] {
+				linked[link.PageID] = true
+				counts[link.PageID]++
+			}
+		}
+	}
+	return counts
+}
+
+// LinkCheckOptions configures CheckLinks
+type LinkCheckOptions struct {
+	SpaceKey string
+	External bool          // also request external URLs
+	Timeout  time.Duration // per external request; 0 means 10 seconds
+}
+
+// LinkProblem is a link that does not lead anywhere
+type LinkProblem struct {
+	PageID    string `json:"page_id" yaml:"page_id"`
+	PageTitle string `json:"page_title" yaml:"page_title"`
+	PageURL   string `json:"page_url,omitempty" yaml:"page_url,omitempty"`
+	Type      string `json:"type" yaml:"type"`
+	Status    string `json:"status" yaml:"status"`
+	Target    string `json:"target" yaml:"target"`
+	Problem   string `json:"problem" yaml:"problem"`
+}
+
+// LinkReport is the result of CheckLinks
+type LinkReport struct {
+	SpaceKey      string         `json:"space" yaml:"space"`
+	Pages         int            `json:"pages" yaml:"pages"`
+	Links         int            `json:"links" yaml:"links"`
+	BrokenPages   int            `json:"broken_page_links" yaml:"broken_page_links"`
+	Missing       int            `json:"missing_attachments" yaml:"missing_attachments"`
+	DeadURLs      int            `json:"dead_urls" yaml:"dead_urls"`
+	ExternalCheck bool           `json:"external_checked" yaml:"external_checked"`
+	Problems      []*LinkProblem `json:"problems" yaml:"problems"`
+}
+
+// CheckLinks reports the links of the pages of a space to pages that do not exist, to
+// attachments that do not exist and, when asked, to external URLs that do not answer
+func CheckLinks(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, options LinkCheckOptions) (*LinkReport, error) {
+	if options.SpaceKey == "" {
+		return nil, fmt.Errorf("a space key is required")
+	}
+	graph, err := BuildLinkGraph(ctx, client, cfg, []string{options.SpaceKey})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &LinkReport{SpaceKey: options.SpaceKey, Pages: len(graph.Pages), ExternalCheck: options.External, Problems: []*LinkProblem{}}
+	var all []*Link
+	for _, node := range graph.Pages {
+		for _, link := range node.Links {
+			if err := graph.resolver.ResolveAttachment(ctx, node.ID, node.SpaceKey, link); err != nil {
+				return nil, err
+			}
+			if link.Type == LinkURL {
+				link.Status = LinkUnchecked
+			}
+			all = append(all, link)
+		}
+		report.Links += len(node.Links)
+	}
+	if options.External {
+		graph.resolver.CheckURLs(ctx, all, options.Timeout)
+	}
+
+	for _, node := range graph.Pages {
+		for _, link := range node.Links {
+			switch link.Status {
+			case LinkBroken:
+				report.BrokenPages++
+			case LinkMissing:
+				report.Missing++
+			case LinkDead:
+				report.DeadURLs++
+			default:
+				continue
+			}
+			report.Problems = append(report.Problems, &LinkProblem{
+				PageID:    node.ID,
+				PageTitle: node.Title,
+				PageURL:   node.URL,
+				Type:      link.Type,
+				Status:    link.Status,
+				Target:    link.Target,
+				Problem:   link.Problem,
+			})
+		}
+	}
+	sort.SliceStable(report.Problems, func(i, j int) bool {
+		return strings.ToLower(report.Problems[i].PageTitle) < strings.ToLower(report.Problems[j].PageTitle)
+	})
+	return report, nil
+}
+
+// CSV renders the problems with a row per link
+func (r *LinkReport) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write([]string{"page_title", "page_id", "page_url", "type", "status", "target", "problem"}); err != nil {
+		return "", err
+	}
+	for _, p := range r.Problems {
+		if err := w.Write([]string{p.PageTitle, p.PageID, p.PageURL, p.Type, p.Status, p.Target, p.Problem}); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
@@ -0,0 +1,535 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// MarkdownOptions controls how Confluence-specific links are rendered when converting to Markdown
+type MarkdownOptions struct {
+	// PageLink returns the target of a link to another page; by default the page title is used
+	PageLink func(spaceKey, title string) string
+	// AttachmentLink returns the target of an attached file or image; by default the file name is used
+	AttachmentLink func(filename string) string
+}
+
+// storageNode is an element or text node of a parsed storage format document
+type storageNode struct {
+	name     string
+	attrs    map[string]string
+	children []*storageNode
+	text     string
+	isText   bool
+	start    int // byte offset of the start tag in the parsed storage
+}
+
+// attr returns an attribute by its qualified name, e.g. "href" or "ri:content-title"
+func (n *storageNode) attr(name string) string {
+	return n.attrs[name]
+}
+
+// child returns the first direct child element with the given name
+func (n *storageNode) child(name string) *storageNode {
+	for _, c := range n.children {
+		if !c.isText && c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// param returns the value of a named ac:parameter of a macro
+func (n *storageNode) param(name string) string {
+	for _, c := range n.children {
+		if !c.isText && c.name == "ac:parameter" && c.attr("ac:name") == name {
+			return c.textContent()
+		}
+	}
+	return ""
+}
+
+// textContent returns the concatenated text of a node and its descendants
+func (n *storageNode) textContent() string {
+	if n.isText {
+		return n.text
+	}
+	var b strings.Builder
+	for _, c := range n.children {
+		b.WriteString(c.textContent())
+	}
+	return b.String()
+}
+
+// voidElements may appear unclosed in storage written by older editors. xml.HTMLAutoClose
+// is not used as it contains "link", which would also close every ac:link.
+var voidElements = []string{"br", "hr", "img", "col"}
+
+// parseStorage parses storage format XHTML, tolerating HTML entities and unclosed void elements
+func parseStorage(storage string) (*storageNode, error) {
+	const prefix = `<root xmlns:ac="ac" xmlns:ri="ri">`
+	decoder := xml.NewDecoder(strings.NewReader(prefix + storage + `</root>`))
+	decoder.Strict = false
+	decoder.AutoClose = voidElements
+	decoder.Entity = xml.HTMLEntity
+
+	root := &storageNode{name: "root", attrs: map[string]string{}}
+	stack := []*storageNode{root}
+	for {
+		start := int(decoder.InputOffset()) - len(prefix)
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &storageNode{name: qualifiedName(t.Name), attrs: make(map[string]string, len(t.Attr)), start: start}
+			for _, a := range t.Attr {
+				node.attrs[qualifiedName(a.Name)] = a.Value
+			}
+			parent.children = append(parent.children, node)
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			parent.children = append(parent.children, &storageNode{isText: true, text: string(t)})
+		}
+	}
+	if len(root.children) == 1 && root.children[0].name == "root" {
+		return root.children[0], nil
+	}
+	return root, nil
+}
+
+func qualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return strings.ToLower(name.Local)
+	}
+	return name.Space + ":" + strings.ToLower(name.Local)
+}
+
+// StorageToMarkdown converts a Confluence storage format body to Markdown. Macros without
+// a Markdown equivalent keep their rich text body; the rest are dropped.
+func StorageToMarkdown(storage string, options *MarkdownOptions) (string, error) {
+	root, err := parseStorage(storage)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse storage format: %w", err)
+	}
+	r := &markdownRenderer{options: options}
+	if r.options == nil {
+		r.options = &MarkdownOptions{}
+	}
+	return strings.TrimSpace(r.blocks(root.children)) + "\n", nil
+}
+
+type markdownRenderer struct {
+	options *MarkdownOptions
+}
+
+var whitespaceRun = regexp.MustCompile(`\s+`)
+
+// inlineMacros are rendered within a line of text rather than as a block
+var inlineMacros = map[string]bool{"status": true, "jira": true, "anchor": true, "mention": true}
+
+func isBlock(n *storageNode) bool {
+	if n.isText {
+		return false
+	}
+	switch n.name {
+	case "p", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "pre", "blockquote", "hr", "table",
+		"div", "section", "ac:layout", "ac:layout-section", "ac:layout-cell", "ac:rich-text-body", "ac:task-list":
+		return true
+	case "ac:structured-macro", "ac:macro":
+		return !inlineMacros[n.attr("ac:name")]
+	}
+	return false
+}
+
+// blocks renders a sequence of nodes as Markdown blocks separated by blank lines
+func (r *markdownRenderer) blocks(nodes []*storageNode) string {
+	var parts []string
+	var inline []*storageNode
+	flush := func() {
+		if text := strings.TrimSpace(r.inline(inline)); text != "" {
+			parts = append(parts, text)
+		}
+		inline = nil
+	}
+	for _, n := range nodes {
+		if !isBlock(n) {
+			inline = append(inline, n)
+			continue
+		}
+		flush()
+		if text := strings.TrimRight(r.block(n), "\n "); strings.TrimSpace(text) != "" {
+			parts = append(parts, text)
+		}
+	}
+	flush()
+	return strings.Join(parts, "\n\n")
+}
+
+func (r *markdownRenderer) block(n *storageNode) string {
+	switch n.name {
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.name[1] - '0')
+		return strings.Repeat("#", level) + " " + strings.TrimSpace(r.inline(n.children))
+	case "ul", "ol":
+		return r.list(n)
+	case "pre":
+		return fence("", n.textContent())
+	case "blockquote":
+		return quote(r.blocks(n.children))
+	case "hr":
+		return "---"
+	case "table":
+		return r.table(n)
+	case "ac:task-list":
+		return r.taskList(n)
+	case "ac:structured-macro", "ac:macro":
+		return r.macro(n)
+	default:
+		return r.blocks(n.children)
+	}
+}
+
+func (r *markdownRenderer) macro(n *storageNode) string {
+	body := n.child("ac:rich-text-body")
+	switch name := n.attr("ac:name"); name {
+	case "code", "noformat":
+		var code string
+		if plain := n.child("ac:plain-text-body"); plain != nil {
+			code = plain.textContent()
+		}
+		return fence(n.param("language"), code)
+	case "info", "note", "warning", "tip", "panel":
+		label := strings.ToUpper(name[:1]) + name[1:]
+		if title := n.param("title"); title != "" {
+			label += ": " + title
+		}
+		content := ""
+		if body != nil {
+			content = r.blocks(body.children)
+		}
+		return quote("**" + label + "**\n\n" + content)
+	case "expand":
+		content := ""
+		if body != nil {
+			content = r.blocks(body.children)
+		}
+		if title := n.param("title"); title != "" {
+			return "**" + title + "**\n\n" + content
+		}
+		return content
+	default:
+		if body != nil {
+			return r.blocks(body.children)
+		}
+		return ""
+	}
+}
+
+func (r *markdownRenderer) inline(nodes []*storageNode) string {
+	var b strings.Builder
+	for _, n := range nodes {
+		b.WriteString(r.inlineNode(n))
+	}
+	return b.String()
+}
+
+func (r *markdownRenderer) inlineNode(n *storageNode) string {
+	if n.isText {
+		return whitespaceRun.ReplaceAllString(n.text, " ")
+	}
+	switch n.name {
+	case "strong", "b":
+		return wrapInline("**", r.inline(n.children))
+	case "em", "i":
+		return wrapInline("*", r.inline(n.children))
+	case "s", "del", "strike":
+		return wrapInline("~~", r.inline(n.children))
+	case "code", "tt":
+		return "`" + n.textContent() + "`"
+	case "br":
+		return "  \n"
+	case "a":
+		text := strings.TrimSpace(r.inline(n.children))
+		href := n.attr("href")
+		if href == "" {
+			return text
+		}
+		if text == "" {
+			text = href
+		}
+		return "[" + text + "](" + href + ")"
+	case "img":
+		return "![" + n.attr("alt") + "](" + n.attr("src") + ")"
+	case "ac:link":
+		return r.link(n)
+	case "ac:image":
+		return r.image(n)
+	case "ac:emoticon":
+		return ":" + n.attr("ac:name") + ":"
+	case "time":
+		return n.attr("datetime")
+	case "ac:placeholder", "ac:parameter":
+		return ""
+	case "ac:structured-macro", "ac:macro":
+		switch n.attr("ac:name") {
+		case "status":
+			return "`" + strings.ToUpper(n.param("title")) + "`"
+		case "jira":
+			return n.param("key")
+		default:
+			return ""
+		}
+	default:
+		if isBlock(n) {
+			return " " + r.block(n) + " "
+		}
+		return r.inline(n.children)
+	}
+}
+
+// link renders an ac:link to a page, attachment, user or anchor
+func (r *markdownRenderer) link(n *storageNode) string {
+	var text string
+	if body := n.child("ac:link-body"); body != nil {
+		text = strings.TrimSpace(r.inline(body.children))
+	} else if body := n.child("ac:plain-text-link-body"); body != nil {
+		text = strings.TrimSpace(body.textContent())
+	}
+
+	var target string
+	switch {
+	case n.child("ri:page") != nil:
+		page := n.child("ri:page")
+		title := page.attr("ri:content-title")
+		if text == "" {
+			text = title
+		}
+		target = title
+		if r.options.PageLink != nil {
+			target = r.options.PageLink(page.attr("ri:space-key"), title)
+		}
+	case n.child("ri:attachment") != nil:
+		filename := n.child("ri:attachment").attr("ri:filename")
+		if text == "" {
+			text = filename
+		}
+		target = filename
+		if r.options.AttachmentLink != nil {
+			target = r.options.AttachmentLink(filename)
+		}
+	case n.child("ri:user") != nil:
+		user := n.child("ri:user")
+		id := user.attr("ri:account-id")
+		if id == "" {
+			id = user.attr("ri:username")
+		}
+		if id == "" {
+			id = user.attr("ri:userkey")
+		}
+		if text != "" {
+			return "@" + text
+		}
+		return "@" + id
+	case n.child("ri:url") != nil:
+		target = n.child("ri:url").attr("ri:value")
+	}
+
+	if anchor := n.attr("ac:anchor"); anchor != "" {
+		target += "#" + anchor
+		if text == "" {
+			text = anchor
+		}
+	}
+	if target == "" {
+		return text
+	}
+	return "[" + text + "](" + escapeLinkTarget(target) + ")"
+}
+
+func (r *markdownRenderer) image(n *storageNode) string {
+	alt := n.attr("ac:alt")
+	if attachment := n.child("ri:attachment"); attachment != nil {
+		filename := attachment.attr("ri:filename")
+		target := filename
+		if r.options.AttachmentLink != nil {
+			target = r.options.AttachmentLink(filename)
+		}
+		return "![" + alt + "](" + escapeLinkTarget(target) + ")"
+	}
+	if url := n.child("ri:url"); url != nil {
+		return "![" + alt + "](" + url.attr("ri:value") + ")"
+	}
+	return ""
+}
+
+func (r *markdownRenderer) list(n *storageNode) string {
+	ordered := n.name == "ol"
+	var lines []string
+	index := 1
+	for _, item := range n.children {
+		if item.isText || item.name != "li" {
+			continue
+		}
+		marker := "- "
+		if ordered {
+			marker = fmt.Sprintf("%d. ", index)
+			index++
+		}
+		lines = append(lines, indent(marker, r.listItem(item)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// listItem renders the content of a list item: its text on the first line and nested lists below
+func (r *markdownRenderer) listItem(item *storageNode) string {
+	var text []*storageNode
+	var nested []string
+	for _, c := range item.children {
+		if !c.isText && (c.name == "ul" || c.name == "ol" || c.name == "ac:task-list") {
+			nested = append(nested, r.block(c))
+			continue
+		}
+		text = append(text, c)
+	}
+	content := strings.TrimSpace(r.blocks(text))
+	for _, list := range nested {
+		content += "\n" + list
+	}
+	return content
+}
+
+func (r *markdownRenderer) taskList(n *storageNode) string {
+	var lines []string
+	for _, task := range n.children {
+		if task.isText || task.name != "ac:task" {
+			continue
+		}
+		box := "- [ ] "
+		if status := task.child("ac:task-status"); status != nil && strings.TrimSpace(status.textContent()) == "complete" {
+			box = "- [x] "
+		}
+		var body string
+		if b := task.child("ac:task-body"); b != nil {
+			body = strings.TrimSpace(r.blocks(b.children))
+		}
+		lines = append(lines, indent(box, body))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *markdownRenderer) table(n *storageNode) string {
+	var rows [][]string
+	var collect func(node *storageNode)
+	collect = func(node *storageNode) {
+		for _, c := range node.children {
+			if c.isText {
+				continue
+			}
+			switch c.name {
+			case "thead", "tbody", "tfoot":
+				collect(c)
+			case "tr":
+				var cells []string
+				for _, cell := range c.children {
+					if cell.isText || (cell.name != "td" && cell.name != "th") {
+						continue
+					}
+					text := strings.TrimSpace(r.blocks(cell.children))
+					text = strings.ReplaceAll(text, "|", `\|`)
+					text = strings.ReplaceAll(strings.ReplaceAll(text, "  \n", "<br>"), "\n", "<br>")
+					cells = append(cells, text)
+				}
+				rows = append(rows, cells)
+			}
+		}
+	}
+	collect(n)
+	if len(rows) == 0 {
+		return ""
+	}
+
+	columns := 0
+	for _, row := range rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	var b strings.Builder
+	for i, row := range rows {
+		for len(row) < columns {
+			row = append(row, "")
+		}
+		b.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		if i == 0 {
+			b.WriteString("|" + strings.Repeat(" --- |", columns) + "\n")
+		}
+	}
+	return b.String()
+}
+
+// fence renders a fenced code block, lengthening the fence if the code contains one
+func fence(language, code string) string {
+	marker := "```"
+	for strings.Contains(code, marker) {
+		marker += "`"
+	}
+	return marker + language + "\n" + strings.Trim(code, "\n") + "\n" + marker
+}
+
+// quote prefixes every line with a blockquote marker
+func quote(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = ">"
+		} else {
+			lines[i] = "> " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indent prefixes the first line with marker and aligns the following lines under it
+func indent(marker, text string) string {
+	lines := strings.Split(text, "\n")
+	padding := strings.Repeat(" ", len(marker))
+	for i := range lines {
+		if i == 0 {
+			lines[i] = marker + lines[i]
+		} else if lines[i] != "" {
+			lines[i] = padding + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrapInline wraps text in an emphasis marker, keeping surrounding spaces outside the marker
+func wrapInline(marker, text string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	leading := text[:len(text)-len(strings.TrimLeft(text, " "))]
+	trailing := text[len(strings.TrimRight(text, " ")):]
+	return leading + marker + trimmed + marker + trailing
+}
+
+// escapeLinkTarget wraps targets containing spaces or parentheses in angle brackets
+func escapeLinkTarget(target string) string {
+	if strings.ContainsAny(target, " ()") {
+		return "<" + target + ">"
+	}
+	return target
+}
@@ -0,0 +1,472 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	oauthAuthorizeURL = "https://auth.atlassian.com/authorize"
+	oauthTokenURL     = "https://auth.atlassian.com/oauth/token"
+	oauthResourcesURL = "https://api.atlassian.com/oauth/token/accessible-resources"
+	oauthAPIBaseURL   = "https://api.atlassian.com/ex/confluence/"
+)
+
+var defaultOAuthScopes = []string{
+	"offline_access",
+	"read:confluence-content.all",
+	"read:confluence-content.summary",
+	"read:confluence-content.permission",
+	"read:confluence-space.summary",
+	"read:confluence-props",
+	"read:confluence-user",
+	"read:confluence-groups",
+	"write:confluence-content",
+	"write:confluence-props",
+	"write:confluence-space",
+	"write:confluence-file",
+	"readonly:content.attachment:confluence",
+	"search:confluence",
+}
+
+// oauthFlow holds the per-login state of an authorization-code + PKCE flow
+type oauthFlow struct {
+	profile      string
+	siteHint     string
+	clientID     string
+	clientSecret string
+	redirectURI  string
+	state        string
+	verifier     string
+}
+
+type oauthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	Scope        string `json:"scope"`
+	ExpiresIn    int    `json:"expires_in"`
+	Error        string `json:"error"`
+	Description  string `json:"error_description"`
+}
+
+// AccessibleResource is a site the OAuth token grants access to
+type AccessibleResource struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+func randomURLSafe(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func oauthScopes() []string {
+	if scopes := os.Getenv("ATLASSIAN_OAUTH_SCOPES"); scopes != "" {
+		return strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+	}
+	return defaultOAuthScopes
+}
+
+func newOAuthFlow(profile, siteHint, redirectURI string) (*oauthFlow, error) {
+	clientID := os.Getenv("ATLASSIAN_OAUTH_CLIENT_ID")
+	if clientID == "" {
+		return nil, fmt.Errorf("ATLASSIAN_OAUTH_CLIENT_ID is required for OAuth login")
+	}
+
+	state, err := randomURLSafe(16)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to generate state")
+	}
+	verifier, err := randomURLSafe(32)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to generate code verifier")
+	}
+
+	return &oauthFlow{
+		profile:      profile,
+		siteHint:     siteHint,
+		clientID:     clientID,
+		clientSecret: os.Getenv("ATLASSIAN_OAUTH_CLIENT_SECRET"),
+		redirectURI:  redirectURI,
+		state:        state,
+		verifier:     verifier,
+	}, nil
+}
+
+func (f *oauthFlow) authCodeURL() string {
+	challenge := sha256.Sum256([]byte(f.verifier))
+
+	query := url.Values{}
+	query.Set("audience", "api.atlassian.com")
+	query.Set("client_id", f.clientID)
+	query.Set("scope", strings.Join(oauthScopes(), " "))
+	query.Set("redirect_uri", f.redirectURI)
+	query.Set("state", f.state)
+	query.Set("response_type", "code")
+	query.Set("prompt", "consent")
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+
+	return oauthAuthorizeURL + "?" + query.Encode()
+}
+
+func (f *oauthFlow) exchange(ctx context.Context, code string) (*OAuthToken, error) {
+	payload := map[string]string{
+		"grant_type":    "authorization_code",
+		"client_id":     f.clientID,
+		"code":          code,
+		"redirect_uri":  f.redirectURI,
+		"code_verifier": f.verifier,
+	}
+	if f.clientSecret != "" {
+		payload["client_secret"] = f.clientSecret
+	}
+
+	token, err := requestOAuthToken(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	token.ClientID = f.clientID
+	token.ClientSecret = f.clientSecret
+	return token, nil
+}
+
+func requestOAuthToken(ctx context.Context, payload map[string]string) (*OAuthToken, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, oauthTokenURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := DefaultHttpClient().Do(req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "token request failed")
+	}
+	defer resp.Body.Close()
+
+	var result oauthTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, errors.WithMessagef(err, "failed to decode token response (status %d)", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		return nil, fmt.Errorf("token request failed (status %d): %s %s", resp.StatusCode, result.Error, result.Description)
+	}
+
+	return &OAuthToken{
+		AccessToken:  result.AccessToken,
+		RefreshToken: result.RefreshToken,
+		TokenType:    result.TokenType,
+		Scope:        result.Scope,
+		Expiry:       time.Now().Add(time.Duration(result.ExpiresIn) * time.Second),
+	}, nil
+}
+
+// refreshOAuthToken exchanges the refresh token for a new token pair. Atlassian rotates
+// refresh tokens, so the returned token replaces the stored one.
+func refreshOAuthToken(ctx context.Context, current *OAuthToken) (*OAuthToken, error) {
+	if current.RefreshToken == "" {
+		return nil, fmt.Errorf("OAuth token expired and no refresh token is available, run 'confluence-cli login' again")
+	}
+
+	payload := map[string]string{
+		"grant_type":    "refresh_token",
+		"client_id":     current.ClientID,
+		"refresh_token": current.RefreshToken,
+	}
+	if current.ClientSecret != "" {
+		payload["client_secret"] = current.ClientSecret
+	}
+
+	token, err := requestOAuthToken(ctx, payload)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to refresh OAuth token")
+	}
+	if token.RefreshToken == "" {
+		token.RefreshToken = current.RefreshToken
+	}
+	token.ClientID = current.ClientID
+	token.ClientSecret = current.ClientSecret
+	token.CloudID = current.CloudID
+	token.SiteURL = current.SiteURL
+	return token, nil
+}
+
+// AccessibleResources lists the sites the access token can reach
+func AccessibleResources(ctx context.Context, accessToken string) ([]AccessibleResource, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, oauthResourcesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := DefaultHttpClient().Do(req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to list accessible resources")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		data, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to list accessible resources (status %d): %s", resp.StatusCode, string(data))
+	}
+
+	var resources []AccessibleResource
+	if err := json.NewDecoder(resp.Body).Decode(&resources); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode accessible resources")
+	}
+	return resources, nil
+}
+
+// discoverCloudID picks the site matching siteHint (or the only accessible site)
+func discoverCloudID(ctx context.Context, token *OAuthToken, siteHint string) error {
+	resources, err := AccessibleResources(ctx, token.AccessToken)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		return fmt.Errorf("the OAuth token does not grant access to any Confluence site")
+	}
+
+	hint := strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(siteHint, "https://"), "http://"), "/")
+	for _, resource := range resources {
+		host := strings.TrimPrefix(resource.URL, "https://")
+		if hint != "" && (strings.EqualFold(host, hint) || strings.EqualFold(resource.Name, hint)) {
+			token.CloudID = resource.ID
+			token.SiteURL = resource.URL
+			return nil
+		}
+	}
+
+	if hint != "" || len(resources) > 1 {
+		names := make([]string, 0, len(resources))
+		for _, resource := range resources {
+			names = append(names, resource.URL)
+		}
+		return fmt.Errorf("could not pick a site for %q, accessible sites: %s", siteHint, strings.Join(names, ", "))
+	}
+
+	token.CloudID = resources[0].ID
+	token.SiteURL = resources[0].URL
+	return nil
+}
+
+// OAuthHandler serves the login and callback endpoints of the authorization-code flow.
+// It is shared by 'confluence-cli login' and the streamable HTTP server.
+type OAuthHandler struct {
+	redirectURI string
+	onLogin     func(*OAuthToken, error)
+	secret      string // required by ServeLogin; without it ServeLogin refuses every request
+
+	mu      sync.Mutex
+	pending map[string]*oauthFlow
+}
+
+// NewOAuthHandler creates a handler redirecting back to redirectURI. onLogin is called
+// once the callback has been processed, with the saved token or the failure.
+func NewOAuthHandler(redirectURI string, onLogin func(*OAuthToken, error)) *OAuthHandler {
+	return &OAuthHandler{
+		redirectURI: redirectURI,
+		onLogin:     onLogin,
+		pending:     make(map[string]*oauthFlow),
+	}
+}
+
+// LoginURL starts a new flow storing its token under profile and returns the
+// Atlassian authorization URL. siteHint selects the site when several are accessible.
+func (h *OAuthHandler) LoginURL(profile, siteHint string) (string, error) {
+	flow, err := newOAuthFlow(profile, siteHint, h.redirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	h.mu.Lock()
+	h.pending[flow.state] = flow
+	h.mu.Unlock()
+
+	return flow.authCodeURL(), nil
+}
+
+// RequireSecret sets the shared secret ServeLogin requests must carry, in the "secret" query
+// parameter or as a bearer token
+func (h *OAuthHandler) RequireSecret(secret string) {
+	h.secret = secret
+}
+
+func (h *OAuthHandler) authorized(r *http.Request) bool {
+	if h.secret == "" {
+		return false
+	}
+	given := r.URL.Query().Get("secret")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		given = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(given), []byte(h.secret)) == 1
+}
+
+// ServeLogin redirects the browser to the Atlassian consent screen. Requests must carry the
+// secret set with RequireSecret, since the token obtained replaces the stored credentials.
+// The optional "profile" and "site" query parameters select where the token is stored; the
+// profile must exist.
+func (h *OAuthHandler) ServeLogin(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		http.Error(w, "missing or invalid login secret", http.StatusUnauthorized)
+		return
+	}
+
+	profile := r.URL.Query().Get("profile")
+	p, err := lookupProfile(profile)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	siteHint := r.URL.Query().Get("site")
+	if siteHint == "" {
+		siteHint = p.Host
+	}
+
+	loginURL, err := h.LoginURL(profile, siteHint)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, loginURL, http.StatusFound)
+}
+
+// ServeCallback completes the flow: it validates the state, exchanges the code,
+// discovers the cloud ID and stores the token
+func (h *OAuthHandler) ServeCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	h.mu.Lock()
+	flow, ok := h.pending[query.Get("state")]
+	delete(h.pending, query.Get("state"))
+	h.mu.Unlock()
+
+	if !ok {
+		http.Error(w, "unknown or expired login state", http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.complete(r.Context(), flow, query)
+	if h.onLogin != nil {
+		h.onLogin(token, err)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Login failed: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, "<html><body><h3>Logged in to %s</h3><p>You can close this window.</p></body></html>", token.SiteURL)
+}
+
+func (h *OAuthHandler) complete(ctx context.Context, flow *oauthFlow, query url.Values) (*OAuthToken, error) {
+	if errCode := query.Get("error"); errCode != "" {
+		return nil, fmt.Errorf("authorization denied: %s %s", errCode, query.Get("error_description"))
+	}
+
+	code := query.Get("code")
+	if code == "" {
+		return nil, fmt.Errorf("missing authorization code")
+	}
+
+	token, err := flow.exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if err := discoverCloudID(ctx, token, flow.siteHint); err != nil {
+		return nil, err
+	}
+	if err := SaveOAuthToken(flow.profile, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// oauthHttpClient authorizes each request with the stored access token, refreshing
+// and persisting it when it expires
+type oauthHttpClient struct {
+	base    *http.Client
+	profile string
+
+	mu    sync.Mutex
+	token *OAuthToken
+}
+
+func (c *oauthHttpClient) accessToken(ctx context.Context, force bool) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if force || c.token.Expired() {
+		token, err := refreshOAuthToken(ctx, c.token)
+		if err != nil {
+			return "", err
+		}
+		if err := SaveOAuthToken(c.profile, token); err != nil {
+			return "", err
+		}
+		c.token = token
+	}
+	return c.token.AccessToken, nil
+}
+
+func (c *oauthHttpClient) Do(req *http.Request) (*http.Response, error) {
+	accessToken, err := c.accessToken(req.Context(), false)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := c.base.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The token may have been revoked or rotated by another process, retry once
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	accessToken, err = c.accessToken(req.Context(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", "Bearer "+accessToken)
+	return c.base.Do(retry)
+}
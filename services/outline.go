@@ -0,0 +1,255 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// CharsPerToken is the number of characters of page markup counted as one model token
+const CharsPerToken = 4
+
+var storageHeadingPattern = regexp.MustCompile(`(?is)<h([1-6])(?:\s[^>]*)?>(.*?)</h[1-6]\s*>`)
+
+// OutlineSection is a heading of a page with the size of its section, subsections included
+type OutlineSection struct {
+	Level    int               `json:"level" yaml:"level"`
+	Title    string            `json:"title" yaml:"title"`
+	Path     string            `json:"path" yaml:"path"`
+	Offset   int               `json:"offset" yaml:"offset"` // position of the heading in the body, in characters
+	Chars    int               `json:"chars" yaml:"chars"`
+	Tokens   int               `json:"tokens" yaml:"tokens"`
+	Children []*OutlineSection `json:"children,omitempty" yaml:"children,omitempty"`
+	start    int
+	end      int
+}
+
+// Start returns the byte position in the body where the section starts
+func (s *OutlineSection) Start() int {
+	return s.start
+}
+
+// End returns the byte position in the body where the section ends
+func (s *OutlineSection) End() int {
+	return s.end
+}
+
+// OwnEnd returns the byte position where the text of the section before its first subsection ends
+func (s *OutlineSection) OwnEnd() int {
+	if len(s.Children) > 0 {
+		return s.Children[0].start
+	}
+	return s.end
+}
+
+// OutlineStorage returns the heading tree of a storage format body. Headings are read from the
+// parsed markup, so text that only looks like a heading, in the CDATA of a code macro or in an
+// attribute value, is left out.
+func OutlineStorage(body string) []*OutlineSection {
+	var roots, stack []*OutlineSection
+	for _, heading := range storageHeadings(body) {
+		for len(stack) > 0 && stack[len(stack)-1].Level >= heading.Level {
+			closeSection(body, stack[len(stack)-1], heading.start)
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			heading.Path = heading.Title
+			roots = append(roots, heading)
+		} else {
+			parent := stack[len(stack)-1]
+			heading.Path = parent.Path + " > " + heading.Title
+			parent.Children = append(parent.Children, heading)
+		}
+		stack = append(stack, heading)
+	}
+	for _, section := range stack {
+		closeSection(body, section, len(body))
+	}
+	return roots
+}
+
+// storageHeadings returns the h1-h6 elements of a body in page order, with their level, title
+// and position. A body that does not parse is scanned for heading tags instead.
+func storageHeadings(body string) []*OutlineSection {
+	var headings []*OutlineSection
+	add := func(level int, title string, start int) {
+		title = strings.Join(strings.Fields(title), " ")
+		headings = append(headings, &OutlineSection{Level: level, Title: title, Offset: utf8.RuneCountInString(body[:start]), start: start})
+	}
+
+	root, err := parseStorage(body)
+	if err != nil {
+		for _, match := range storageHeadingPattern.FindAllStringSubmatchIndex(body, -1) {
+			add(int(body[match[2]]-'0'), html.UnescapeString(tagPattern.ReplaceAllString(body[match[4]:match[5]], "")), match[0])
+		}
+		return headings
+	}
+	var walk func(n *storageNode)
+	walk = func(n *storageNode) {
+		for _, c := range n.children {
+			if c.isText {
+				continue
+			}
+			if len(c.name) == 2 && c.name[0] == 'h' && c.name[1] >= '1' && c.name[1] <= '6' {
+				add(int(c.name[1]-'0'), c.textContent(), c.start)
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(root)
+	return headings
+}
+
+var tagPattern = regexp.MustCompile(`<[^>]*>`)
+
+func closeSection(body string, section *OutlineSection, end int) {
+	section.end = end
+	section.Chars = utf8.RuneCountInString(body[section.start:end])
+	section.Tokens = (section.Chars + CharsPerToken - 1) / CharsPerToken
+}
+
+// FindSection finds a section by its path of headings, separated by ">" or "/", compared without case.
+// A path that does not start at a top-level heading matches any section it ends with, when only one does.
+func FindSection(sections []*OutlineSection, path string) (*OutlineSection, error) {
+	var wanted []string
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool { return r == '>' || r == '/' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			wanted = append(wanted, part)
+		}
+	}
+	if len(wanted) == 0 {
+		return nil, fmt.Errorf("a section path is required")
+	}
+
+	var matches []*OutlineSection
+	var walk func(sections []*OutlineSection, parents []string)
+	walk = func(sections []*OutlineSection, parents []string) {
+		for _, section := range sections {
+			titles := append(append([]string{}, parents...), section.Title)
+			if len(titles) >= len(wanted) && equalFoldAll(titles[len(titles)-len(wanted):], wanted) {
+				matches = append(matches, section)
+			}
+			walk(section.Children, titles)
+		}
+	}
+	walk(sections, nil)
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		var paths []string
+		walkOutline(sections, func(s *OutlineSection) { paths = append(paths, s.Path) })
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("section %q not found: the page has no headings", path)
+		}
+		return nil, fmt.Errorf("section %q not found; sections are:\n- %s", path, strings.Join(paths, "\n- "))
+	default:
+		// An exact path from the top wins over partial matches
+		for _, match := range matches {
+			if strings.Count(match.Path, " > ")+1 == len(wanted) {
+				return match, nil
+			}
+		}
+		paths := make([]string, len(matches))
+		for i, match := range matches {
+			paths[i] = match.Path
+		}
+		return nil, fmt.Errorf("section %q is ambiguous; use one of:\n- %s", path, strings.Join(paths, "\n- "))
+	}
+}
+
+func equalFoldAll(a, b []string) bool {
+	for i := range a {
+		if !strings.EqualFold(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func walkOutline(sections []*OutlineSection, fn func(*OutlineSection)) {
+	for _, section := range sections {
+		fn(section)
+		walkOutline(section.Children, fn)
+	}
+}
+
+// ContentWindow is a slice of a large body, with the offset to continue from
+type ContentWindow struct {
+	Content    string `json:"content" yaml:"content"`
+	Offset     int    `json:"offset" yaml:"offset"`
+	NextOffset int    `json:"next_offset,omitempty" yaml:"next_offset,omitempty"` // 0 when the end was reached
+	TotalChars int    `json:"total_chars" yaml:"total_chars"`
+	Truncated  bool   `json:"truncated" yaml:"truncated"`
+}
+
+// CharBudget returns the smaller of a character and a token budget, in characters; 0 means unlimited
+func CharBudget(maxChars, maxTokens int) int {
+	budget := maxChars
+	if maxTokens > 0 && (budget <= 0 || maxTokens*CharsPerToken < budget) {
+		budget = maxTokens * CharsPerToken
+	}
+	if budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// WindowContent returns at most maxChars characters of content starting at the character
+// offset. The window ends at the last paragraph break, tag boundary or space it contains, so
+// the same offsets always yield the same windows and markup is not split when it can be avoided.
+func WindowContent(content string, offset, maxChars int) (*ContentWindow, error) {
+	total := utf8.RuneCountInString(content)
+	if offset < 0 || offset > total {
+		return nil, fmt.Errorf("offset %d is outside the content (%d characters)", offset, total)
+	}
+	window := &ContentWindow{Offset: offset, TotalChars: total}
+	start := runeIndex(content, 0, offset)
+	if maxChars <= 0 || total-offset <= maxChars {
+		window.Content = content[start:]
+		return window, nil
+	}
+
+	// From here on offsets are byte positions
+	offset = start
+	limit := runeIndex(content, offset, maxChars)
+	maxBytes := limit - offset
+	rest := content[offset:limit]
+	cut := -1
+	for _, boundary := range []string{"\n\n", "><", "\n", ">", " "} {
+		i := strings.LastIndex(rest, boundary)
+		if i <= 0 {
+			continue
+		}
+		end := offset + i + len(boundary)
+		if boundary == "><" {
+			end = offset + i + 1
+		}
+		cut = max(cut, end)
+		// A strong boundary early in the window gives way to a weaker one later on
+		if cut-offset >= maxBytes/2 {
+			break
+		}
+	}
+	if cut <= offset {
+		cut = limit
+	}
+
+	window.Content = content[offset:cut]
+	window.NextOffset = window.Offset + utf8.RuneCountInString(window.Content)
+	window.Truncated = true
+	return window, nil
+}
+
+// runeIndex returns the byte position n characters after the byte position from
+func runeIndex(content string, from, n int) int {
+	for ; n > 0 && from < len(content); n-- {
+		_, size := utf8.DecodeRuneInString(content[from:])
+		from += size
+	}
+	return from
+}
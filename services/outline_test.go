@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestOutlineStorage(t *testing.T) {
+	body := `<h1>Über</h1><p>Intro</p>` +
+		`<ac:structured-macro ac:name="code"><ac:plain-text-body><![CDATA[<h2>Not a heading</h2>]]></ac:plain-text-body></ac:structured-macro>` +
+		`<h2>Setup &amp; run</h2><p>Steps</p>` +
+		`<h1>End</h1>`
+
+	sections := OutlineStorage(body)
+	if len(sections) != 2 {
+		t.Fatalf("got %d top-level sections, want 2", len(sections))
+	}
+	first := sections[0]
+	if first.Title != "Über" || len(first.Children) != 1 {
+		t.Fatalf("first section = %+v", first)
+	}
+	setup := first.Children[0]
+	if setup.Path != "Über > Setup & run" {
+		t.Errorf("path = %q", setup.Path)
+	}
+
+	// Offsets and sizes count characters; Start and End are byte positions
+	if got := body[setup.Start():setup.End()]; got != `<h2>Setup &amp; run</h2><p>Steps</p>` {
+		t.Errorf("section markup = %q", got)
+	}
+	if want := utf8.RuneCountInString(body[:setup.Start()]); setup.Offset != want {
+		t.Errorf("offset = %d, want %d", setup.Offset, want)
+	}
+	if want := utf8.RuneCountInString(body[:first.End()]); first.Chars != want {
+		t.Errorf("chars = %d, want %d", first.Chars, want)
+	}
+	if first.OwnEnd() != setup.Start() {
+		t.Errorf("own end = %d, want %d", first.OwnEnd(), setup.Start())
+	}
+}
+
+func TestWindowContent(t *testing.T) {
+	content := "<p>héllo wörld</p><p>ünïcode ëverywhere</p>"
+
+	var joined string
+	offset, windows := 0, 0
+	for {
+		window, err := WindowContent(content, offset, 10)
+		if err != nil {
+			t.Fatalf("WindowContent(%d) error = %v", offset, err)
+		}
+		if window.TotalChars != utf8.RuneCountInString(content) {
+			t.Errorf("total = %d, want %d", window.TotalChars, utf8.RuneCountInString(content))
+		}
+		if n := utf8.RuneCountInString(window.Content); n > 10 {
+			t.Errorf("window at %d has %d characters, want at most 10", offset, n)
+		}
+		joined += window.Content
+		windows++
+		if !window.Truncated {
+			break
+		}
+		offset = window.NextOffset
+	}
+	if joined != content {
+		t.Errorf("windows joined = %q, want %q", joined, content)
+	}
+	if windows < 4 {
+		t.Errorf("got %d windows, want the content split", windows)
+	}
+
+	if _, err := WindowContent(content, utf8.RuneCountInString(content)+1, 10); err == nil {
+		t.Errorf("offset past the end: no error")
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// PassageOptions configures a passage retrieval
+type PassageOptions struct {
+	Query      string
+	Spaces     []string
+	PageIDs    []string // pages to retrieve from; found by searching when empty
+	K          int      // passages to return, 5 by default
+	MaxTokens  int      // chunk size, DefaultChunkTokens by default
+	Candidates int      // pages chunked when searching, 10 by default
+}
+
+// Passage is a retrieved chunk of a page
+type Passage struct {
+	PageID    string  `json:"page_id" yaml:"page_id"`
+	PageTitle string  `json:"page_title" yaml:"page_title"`
+	Space     string  `json:"space,omitempty" yaml:"space,omitempty"`
+	Section   string  `json:"section" yaml:"section"`
+	Link      string  `json:"link" yaml:"link"`
+	Score     float64 `json:"score" yaml:"score"`
+	Tokens    int     `json:"tokens" yaml:"tokens"`
+	Text      string  `json:"text" yaml:"text"`
+}
+
+// PassageResult lists the passages most relevant to a query
+type PassageResult struct {
+	Query    string    `json:"query" yaml:"query"`
+	Ranking  string    `json:"ranking" yaml:"ranking"`
+	Source   string    `json:"source" yaml:"source"` // how candidate pages were found
+	Pages    int       `json:"pages_searched" yaml:"pages_searched"`
+	Chunks   int       `json:"chunks_ranked" yaml:"chunks_ranked"`
+	Passages []Passage `json:"passages" yaml:"passages"`
+}
+
+// RetrievePassages finds candidate pages (the given pages, the local index when it covers the
+// spaces, or a CQL text search otherwise), splits them into chunks and returns the best ones
+func RetrievePassages(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, options PassageOptions) (*PassageResult, error) {
+	if options.K <= 0 {
+		options.K = 5
+	}
+	if options.Candidates <= 0 {
+		options.Candidates = 10
+	}
+
+	result := &PassageResult{Query: options.Query, Ranking: "bm25", Passages: []Passage{}}
+	pageIDs := options.PageIDs
+	switch {
+	case len(pageIDs) > 0:
+		result.Source = "pages"
+	default:
+		ids, source, err := candidatePages(ctx, client, cfg, options)
+		if err != nil {
+			return nil, err
+		}
+		pageIDs, result.Source = ids, source
+	}
+
+	documents, err := fetchDocuments(ctx, client, cfg, pageIDs)
+	if err != nil {
+		return nil, err
+	}
+	var chunks []Chunk
+	for _, document := range documents {
+		chunks = append(chunks, ChunkDocument(document, options.MaxTokens)...)
+	}
+	result.Pages, result.Chunks = len(documents), len(chunks)
+
+	embedder := CurrentEmbedder()
+	if embedder != nil {
+		result.Ranking = "embeddings"
+		if embedder.Name() != "" {
+			result.Ranking += ":" + embedder.Name()
+		}
+	}
+	ranked, err := RankChunks(ctx, embedder, options.Query, chunks, options.K)
+	if err != nil {
+		return nil, err
+	}
+	for _, chunk := range ranked {
+		result.Passages = append(result.Passages, Passage{
+			PageID:    chunk.PageID,
+			PageTitle: chunk.PageTitle,
+			Space:     chunk.SpaceKey,
+			Section:   chunk.Breadcrumb(),
+			Link:      chunk.URL,
+			Score:     chunk.Score,
+			Tokens:    chunk.Tokens,
+			Text:      chunk.Text,
+		})
+	}
+	return result, nil
+}
+
+// candidatePages searches the local index when it covers the requested spaces, or CQL otherwise
+func candidatePages(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, options PassageOptions) ([]string, string, error) {
+	if ix, err := OpenSearchIndex(cfg); err == nil {
+		indexed := ix.IndexedSpaces()
+		covered := len(indexed) > 0
+		for _, key := range options.Spaces {
+			if _, ok := indexed[key]; !ok {
+				covered = false
+			}
+		}
+		if covered {
+			var ids []string
+			for _, hit := range ix.Search(LocalSearchQuery{Text: options.Query, Spaces: options.Spaces, Limit: options.Candidates}) {
+				ids = append(ids, hit.Page.ID)
+			}
+			return ids, "local_index", nil
+		}
+	}
+
+	cql, err := BuildCQL(CQLQuery{Text: options.Query, Types: []string{"page"}, Spaces: options.Spaces})
+	if err != nil {
+		return nil, "", err
+	}
+	results, response, err := SearchContent(ctx, client, cfg, cql, &models.SearchContentOptions{Limit: options.Candidates})
+	if err != nil {
+		return nil, "", ResponseError("failed to search candidate pages", response, err)
+	}
+	var ids []string
+	for _, result := range results.Results {
+		if result.Content != nil && result.Content.ID != "" {
+			ids = append(ids, result.Content.ID)
+		}
+	}
+	return ids, "cql", nil
+}
+
+// fetchDocuments fetches pages as Markdown, a few at a time, keeping the order of the IDs
+func fetchDocuments(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, pageIDs []string) ([]*PageDocument, error) {
+	documents := make([]*PageDocument, len(pageIDs))
+	errs := make([]error, len(pageIDs))
+	semaphore := make(chan struct{}, 4)
+	var wg sync.WaitGroup
+	for i, id := range pageIDs {
+		wg.Add(1)
+		go func(i int, id string) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+			document, response, err := GetPageDocument(ctx, client, cfg, id)
+			if err != nil {
+				errs[i] = ResponseError("failed to get page "+id, response, err)
+				return
+			}
+			documents[i] = document
+		}(i, id)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return documents, nil
+}
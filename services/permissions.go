@@ -0,0 +1,291 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"sort"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// PermissionMatrix lists which operations each user and group holds in a space
+type PermissionMatrix struct {
+	SpaceKey   string                `json:"space_key" yaml:"space_key"`
+	Operations []string              `json:"operations" yaml:"operations"`
+	Anonymous  []string              `json:"anonymous_operations,omitempty" yaml:"anonymous_operations,omitempty"`
+	Subjects   []PermissionMatrixRow `json:"subjects" yaml:"subjects"`
+}
+
+// PermissionMatrixRow is the set of operations granted to one user or group
+type PermissionMatrixRow struct {
+	Type       string   `json:"type" yaml:"type"`
+	Name       string   `json:"name" yaml:"name"`
+	ID         string   `json:"id,omitempty" yaml:"id,omitempty"`
+	Operations []string `json:"operations" yaml:"operations"`
+}
+
+// PermissionDiff compares the permission sets of two spaces
+type PermissionDiff struct {
+	SpaceA    string              `json:"space_a" yaml:"space_a"`
+	SpaceB    string              `json:"space_b" yaml:"space_b"`
+	Subjects  []PermissionDiffRow `json:"subjects" yaml:"subjects"`
+	Identical bool                `json:"identical" yaml:"identical"`
+}
+
+// PermissionDiffRow lists the operations a subject holds in only one of the two spaces
+type PermissionDiffRow struct {
+	Type    string   `json:"type" yaml:"type"`
+	Name    string   `json:"name" yaml:"name"`
+	ID      string   `json:"id,omitempty" yaml:"id,omitempty"`
+	OnlyInA []string `json:"only_in_a,omitempty" yaml:"only_in_a,omitempty"`
+	OnlyInB []string `json:"only_in_b,omitempty" yaml:"only_in_b,omitempty"`
+}
+
+// subjectKey identifies a subject across spaces
+func (r PermissionMatrixRow) subjectKey() string {
+	if r.ID != "" {
+		return r.Type + ":" + r.ID
+	}
+	return r.Type + ":" + r.Name
+}
+
+// userIdentity returns the display name and stable identifier of a user on Cloud or Data Center
+func userIdentity(user *models.ContentUserScheme) (string, string) {
+	name := user.DisplayName
+	if name == "" {
+		name = user.PublicName
+	}
+	if name == "" {
+		name = user.Username
+	}
+
+	id := user.AccountID
+	if id == "" {
+		id = user.UserKey
+	}
+	if id == "" {
+		id = user.Username
+	}
+	return name, id
+}
+
+// BuildPermissionMatrix pivots the space permission list into one row per subject
+func BuildPermissionMatrix(spaceKey string, permissions []*models.SpacePermissionScheme) *PermissionMatrix {
+	matrix := &PermissionMatrix{SpaceKey: spaceKey}
+	rows := make(map[string]*PermissionMatrixRow)
+	operations := make(map[string]bool)
+	anonymous := make(map[string]bool)
+
+	grant := func(row PermissionMatrixRow, operation string) {
+		key := row.subjectKey()
+		existing, ok := rows[key]
+		if !ok {
+			existing = &row
+			rows[key] = existing
+		}
+		for _, op := range existing.Operations {
+			if op == operation {
+				return
+			}
+		}
+		existing.Operations = append(existing.Operations, operation)
+	}
+
+	for _, permission := range permissions {
+		operation := PermissionOperation(permission)
+		operations[operation] = true
+
+		if permission.AnonymousAccess {
+			anonymous[operation] = true
+		}
+		if permission.Subject == nil {
+			continue
+		}
+		if permission.Subject.User != nil {
+			for _, user := range permission.Subject.User.Results {
+				name, id := userIdentity(user)
+				grant(PermissionMatrixRow{Type: "user", Name: name, ID: id}, operation)
+			}
+		}
+		if permission.Subject.Group != nil {
+			for _, group := range permission.Subject.Group.Results {
+				grant(PermissionMatrixRow{Type: "group", Name: group.Name, ID: group.ID}, operation)
+			}
+		}
+	}
+
+	matrix.Operations = sortedKeys(operations)
+	matrix.Anonymous = sortedKeys(anonymous)
+	matrix.Subjects = make([]PermissionMatrixRow, 0, len(rows))
+	for _, row := range rows {
+		sort.Strings(row.Operations)
+		matrix.Subjects = append(matrix.Subjects, *row)
+	}
+	sort.Slice(matrix.Subjects, func(i, j int) bool {
+		if matrix.Subjects[i].Type != matrix.Subjects[j].Type {
+			return matrix.Subjects[i].Type > matrix.Subjects[j].Type // users before groups
+		}
+		return strings.ToLower(matrix.Subjects[i].Name) < strings.ToLower(matrix.Subjects[j].Name)
+	})
+
+	return matrix
+}
+
+// GetSpacePermissionMatrix fetches the permissions of a space and builds its matrix
+func GetSpacePermissionMatrix(ctx context.Context, client *confluence.Client, spaceKey string) (*PermissionMatrix, *models.ResponseScheme, error) {
+	space, response, err := client.Space.Get(ctx, spaceKey, []string{"permissions"})
+	if err != nil {
+		return nil, response, err
+	}
+	return BuildPermissionMatrix(space.Key, space.Permissions), response, nil
+}
+
+// CSV renders the matrix with one column per operation, "x" marking a grant
+func (m *PermissionMatrix) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	header := append([]string{"type", "name", "id"}, m.Operations...)
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+
+	for _, row := range m.Subjects {
+		granted := make(map[string]bool, len(row.Operations))
+		for _, op := range row.Operations {
+			granted[op] = true
+		}
+		record := []string{row.Type, row.Name, row.ID}
+		for _, op := range m.Operations {
+			if granted[op] {
+				record = append(record, "x")
+			} else {
+				record = append(record, "")
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	if len(m.Anonymous) > 0 {
+		record := []string{"anonymous", "anonymous", ""}
+		anonymous := make(map[string]bool, len(m.Anonymous))
+		for _, op := range m.Anonymous {
+			anonymous[op] = true
+		}
+		for _, op := range m.Operations {
+			if anonymous[op] {
+				record = append(record, "x")
+			} else {
+				record = append(record, "")
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// DiffPermissionMatrices reports the subjects whose grants differ between two spaces
+func DiffPermissionMatrices(a, b *PermissionMatrix) *PermissionDiff {
+	diff := &PermissionDiff{SpaceA: a.SpaceKey, SpaceB: b.SpaceKey}
+
+	rowsA := make(map[string]PermissionMatrixRow, len(a.Subjects))
+	for _, row := range a.Subjects {
+		rowsA[row.subjectKey()] = row
+	}
+	rowsB := make(map[string]PermissionMatrixRow, len(b.Subjects))
+	for _, row := range b.Subjects {
+		rowsB[row.subjectKey()] = row
+	}
+
+	keys := make(map[string]bool)
+	for key := range rowsA {
+		keys[key] = true
+	}
+	for key := range rowsB {
+		keys[key] = true
+	}
+
+	for _, key := range sortedKeys(keys) {
+		rowA, inA := rowsA[key]
+		rowB, inB := rowsB[key]
+
+		row := PermissionDiffRow{
+			OnlyInA: subtract(rowA.Operations, rowB.Operations),
+			OnlyInB: subtract(rowB.Operations, rowA.Operations),
+		}
+		if inA {
+			row.Type, row.Name, row.ID = rowA.Type, rowA.Name, rowA.ID
+		} else if inB {
+			row.Type, row.Name, row.ID = rowB.Type, rowB.Name, rowB.ID
+		}
+
+		if len(row.OnlyInA) > 0 || len(row.OnlyInB) > 0 {
+			diff.Subjects = append(diff.Subjects, row)
+		}
+	}
+
+	if anonA, anonB := subtract(a.Anonymous, b.Anonymous), subtract(b.Anonymous, a.Anonymous); len(anonA) > 0 || len(anonB) > 0 {
+		diff.Subjects = append(diff.Subjects, PermissionDiffRow{Type: "anonymous", Name: "anonymous", OnlyInA: anonA, OnlyInB: anonB})
+	}
+
+	diff.Identical = len(diff.Subjects) == 0
+	return diff
+}
+
+// CSV renders the diff with one line per differing grant
+func (d *PermissionDiff) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write([]string{"type", "name", "id", "operation", d.SpaceA, d.SpaceB}); err != nil {
+		return "", err
+	}
+	for _, row := range d.Subjects {
+		for _, op := range row.OnlyInA {
+			if err := w.Write([]string{row.Type, row.Name, row.ID, op, "x", ""}); err != nil {
+				return "", err
+			}
+		}
+		for _, op := range row.OnlyInB {
+			if err := w.Write([]string{row.Type, row.Name, row.ID, op, "", "x"}); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	w.Flush()
+	return buf.String(), w.Error()
+}
+
+// subtract returns the values of a missing from b
+func subtract(a, b []string) []string {
+	present := make(map[string]bool, len(b))
+	for _, value := range b {
+		present[value] = true
+	}
+	var result []string
+	for _, value := range a {
+		if !present[value] {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
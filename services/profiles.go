@@ -0,0 +1,226 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// profileNamePattern is what a profile name may contain; names end up in file names
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateProfileName rejects profile names other than letters, digits, - and _.
+// "" is the environment profile and is valid.
+func ValidateProfileName(name string) error {
+	if name != "" && !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// Profile describes how to reach one Confluence site
+type Profile struct {
+	Host         string `yaml:"host" json:"host"`
+	Deployment   string `yaml:"deployment,omitempty" json:"deployment,omitempty"`
+	ContextPath  string `yaml:"context_path,omitempty" json:"context_path,omitempty"`
+	AuthType     string `yaml:"auth_type,omitempty" json:"auth_type,omitempty"`
+	Email        string `yaml:"email,omitempty" json:"email,omitempty"`
+	Token        string `yaml:"token,omitempty" json:"token,omitempty"`
+	TokenEnv     string `yaml:"token_env,omitempty" json:"token_env,omitempty"`
+	DefaultSpace string `yaml:"default_space,omitempty" json:"default_space,omitempty"`
+	Output       string `yaml:"output,omitempty" json:"output,omitempty"`
+}
+
+// ConfigFile is the on-disk list of named profiles
+type ConfigFile struct {
+	CurrentProfile string              `yaml:"current_profile,omitempty" json:"current_profile,omitempty"`
+	Profiles       map[string]*Profile `yaml:"profiles" json:"profiles"`
+}
+
+// envProfile builds the unnamed profile from the ATLASSIAN_* environment variables
+func envProfile() *Profile {
+	return &Profile{
+		Host:        os.Getenv("ATLASSIAN_HOST"),
+		Deployment:  os.Getenv("ATLASSIAN_DEPLOYMENT"),
+		ContextPath: os.Getenv("ATLASSIAN_CONTEXT_PATH"),
+		AuthType:    os.Getenv("ATLASSIAN_AUTH_TYPE"),
+		Email:       os.Getenv("ATLASSIAN_EMAIL"),
+		Token:       os.Getenv("ATLASSIAN_TOKEN"),
+	}
+}
+
+func (p *Profile) deployment() string {
+	if strings.EqualFold(p.Deployment, DeploymentDataCenter) {
+		return DeploymentDataCenter
+	}
+	return DeploymentCloud
+}
+
+func (p *Profile) token() string {
+	if p.Token == "" && p.TokenEnv != "" {
+		return os.Getenv(p.TokenEnv)
+	}
+	return p.Token
+}
+
+// authType resolves the authentication method of the profile stored under name.
+// Data Center defaults to personal access tokens. Cloud defaults to email + API token,
+// or to the token stored by 'confluence-cli login' when no API token is configured.
+func (p *Profile) authType(name string) string {
+	switch strings.ToLower(p.AuthType) {
+	case AuthBasic:
+		return AuthBasic
+	case AuthPAT:
+		return AuthPAT
+	case AuthOAuth:
+		return AuthOAuth
+	}
+	if p.deployment() == DeploymentDataCenter {
+		return AuthPAT
+	}
+	if p.token() == "" && HasOAuthToken(name) {
+		return AuthOAuth
+	}
+	return AuthBasic
+}
+
+// ConfigFilePath returns the location of the profiles file, CONFLUENCE_MCP_CONFIG
+// or config.yaml in the user config directory
+func ConfigFilePath() (string, error) {
+	if path := os.Getenv("CONFLUENCE_MCP_CONFIG"); path != "" {
+		return path, nil
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yaml"), nil
+}
+
+// LoadConfigFile reads the profiles file, returning an empty configuration when it does not exist
+func LoadConfigFile() (*ConfigFile, error) {
+	path, err := ConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	file := &ConfigFile{Profiles: make(map[string]*Profile)}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return file, nil
+		}
+		return nil, errors.WithMessagef(err, "failed to read config file %s", path)
+	}
+
+	if err := yaml.Unmarshal(data, file); err != nil {
+		return nil, errors.WithMessagef(err, "failed to parse config file %s", path)
+	}
+	if file.Profiles == nil {
+		file.Profiles = make(map[string]*Profile)
+	}
+	for name := range file.Profiles {
+		if err := ValidateProfileName(name); err != nil {
+			return nil, errors.WithMessagef(err, "config file %s", path)
+		}
+	}
+	return file, nil
+}
+
+// SaveConfigFile writes the profiles file
+func SaveConfigFile(file *ConfigFile) error {
+	path, err := ConfigFilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.WithMessage(err, "failed to create config directory")
+	}
+
+	data, err := yaml.Marshal(file)
+	if err != nil {
+		return errors.WithMessage(err, "failed to encode config file")
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return errors.WithMessagef(err, "failed to write config file %s", path)
+	}
+	return nil
+}
+
+// ProfileNames returns the configured profile names in sorted order
+func (f *ConfigFile) ProfileNames() []string {
+	names := make([]string, 0, len(f.Profiles))
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActiveProfileName resolves which profile an empty profile argument refers to:
+// CONFLUENCE_PROFILE, then the ATLASSIAN_* environment variables (returned as ""),
+// then the current profile of the config file
+func ActiveProfileName() string {
+	if name := os.Getenv("CONFLUENCE_PROFILE"); name != "" {
+		return name
+	}
+	if os.Getenv("ATLASSIAN_HOST") != "" {
+		return ""
+	}
+	file, err := LoadConfigFile()
+	if err != nil {
+		return ""
+	}
+	return file.CurrentProfile
+}
+
+// lookupProfile returns the profile stored under name, or the environment profile for ""
+func lookupProfile(name string) (*Profile, error) {
+	if name == "" {
+		return envProfile(), nil
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return nil, err
+	}
+
+	file, err := LoadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := file.Profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("profile %q not found, available profiles: %s", name, strings.Join(file.ProfileNames(), ", "))
+	}
+	return profile, nil
+}
+
+// ProfileForSite resolves the site of a resource URI to a profile: a profile name,
+// or the host name of the active or a stored profile
+func ProfileForSite(site string) (string, error) {
+	if site == "" {
+		return "", nil
+	}
+	if cfg, err := Config(); err == nil && (cfg.SiteName() == site || cfg.hostName() == site) {
+		return "", nil
+	}
+
+	file, err := LoadConfigFile()
+	if err != nil {
+		return "", err
+	}
+	if _, ok := file.Profiles[site]; ok {
+		return site, nil
+	}
+	for _, name := range file.ProfileNames() {
+		if cfg, err := ConfigFor(name); err == nil && cfg.hostName() == site {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unknown site %q: use a profile name or the host of a configured site", site)
+}
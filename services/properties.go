@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// ContentProperty is a JSON value stored under a key on a page or blog post
+type ContentProperty struct {
+	Key     string      `json:"key" yaml:"key"`
+	Value   interface{} `json:"value" yaml:"value"`
+	Version int         `json:"version" yaml:"version"`
+	Updated string      `json:"updated,omitempty" yaml:"updated,omitempty"`
+}
+
+func newContentProperty(property *models.ContentPropertyScheme) *ContentProperty {
+	result := &ContentProperty{Key: property.Key, Value: property.Value}
+	if property.Version != nil {
+		result.Version = property.Version.Number
+		result.Updated = property.Version.When
+	}
+	return result
+}
+
+// GetContentProperties returns the properties of content with the given keys, or all of them
+// when no key is given. Keys that are not set are left out.
+func GetContentProperties(ctx context.Context, client *confluence.Client, contentID string, keys []string) ([]*ContentProperty, *models.ResponseScheme, error) {
+	properties := []*ContentProperty{}
+	if len(keys) > 0 {
+		for _, key := range keys {
+			property, response, err := client.Content.Property.Get(ctx, contentID, key)
+			if err != nil {
+				if response != nil && response.Code == http.StatusNotFound {
+					continue
+				}
+				return nil, response, err
+			}
+			properties = append(properties, newContentProperty(property))
+		}
+		return properties, nil, nil
+	}
+
+	const limit = 100
+	for start := 0; ; start += limit {
+		page, response, err := client.Content.Property.Gets(ctx, contentID, []string{"version"}, start, limit)
+		if err != nil {
+			return nil, response, err
+		}
+		for _, property := range page.Results {
+			properties = append(properties, newContentProperty(property))
+		}
+		if len(page.Results) < limit {
+			break
+		}
+	}
+	sort.Slice(properties, func(i, j int) bool { return properties[i].Key < properties[j].Key })
+	return properties, nil, nil
+}
+
+// PropertyConflictError reports that a property changed since the version the caller read
+type PropertyConflictError struct {
+	Key      string
+	Expected int
+	Current  int
+}
+
+func (e *PropertyConflictError) Error() string {
+	if e.Current == 0 {
+		return fmt.Sprintf("property %q was expected at version %d but is not set; read it again before writing", e.Key, e.Expected)
+	}
+	return fmt.Sprintf("property %q is at version %d, not %d: it was changed since it was read; read it again before writing", e.Key, e.Current, e.Expected)
+}
+
+type contentPropertyPayload struct {
+	Key     string                               `json:"key"`
+	Value   interface{}                          `json:"value"`
+	Version *models.ContentPropertyVersionScheme `json:"version,omitempty"`
+}
+
+// SetContentProperty creates or replaces a property with a JSON value. When expectedVersion is
+// not 0 the write only happens if the property is still at that version, so concurrent updates
+// are not lost; -1 requires the property not to exist yet.
+func SetContentProperty(ctx context.Context, client *confluence.Client, contentID, key string, value interface{}, expectedVersion int) (*ContentProperty, *models.ResponseScheme, error) {
+	current, response, err := client.Content.Property.Get(ctx, contentID, key)
+	if err != nil && (response == nil || response.Code != http.StatusNotFound) {
+		return nil, response, err
+	}
+	version := 0
+	if err == nil && current.Version != nil {
+		version = current.Version.Number
+	}
+	switch {
+	case expectedVersion == -1 && err == nil:
+		return nil, nil, fmt.Errorf("property %q already exists at version %d", key, version)
+	case expectedVersion > 0 && expectedVersion != version:
+		return nil, nil, &PropertyConflictError{Key: key, Expected: expectedVersion, Current: version}
+	}
+
+	payload := contentPropertyPayload{Key: key, Value: value}
+	method, endpoint := http.MethodPost, "wiki/rest/api/content/"+url.PathEscape(contentID)+"/property"
+	if err == nil {
+		payload.Version = &models.ContentPropertyVersionScheme{Number: version + 1}
+		method, endpoint = http.MethodPut, endpoint+"/"+url.PathEscape(key)
+	}
+
+	request, err := client.NewRequest(ctx, method, endpoint, "", payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	property := new(models.ContentPropertyScheme)
+	response, err = client.Call(request, property)
+	if err != nil {
+		if response != nil && response.Code == http.StatusConflict {
+			return nil, nil, &PropertyConflictError{Key: key, Expected: version}
+		}
+		return nil, response, err
+	}
+	return newContentProperty(property), response, nil
+}
+
+// DeleteContentProperty removes a property from content
+func DeleteContentProperty(ctx context.Context, client *confluence.Client, contentID, key string) (*models.ResponseScheme, error) {
+	return client.Content.Property.Delete(ctx, contentID, key)
+}
+
+// PropertyValue parses a property value given on the command line or in a tool call: JSON when
+// it is valid JSON, a plain string otherwise
+func PropertyValue(text string) interface{} {
+	var value interface{}
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return text
+	}
+	return value
+}
+
+// PropertyQuery selects the pages of a space by the value of a content property
+type PropertyQuery struct {
+	SpaceKey string
+	Key      string
+	Field    string      // dotted path inside an object value, e.g. "review.status"
+	Value    interface{} // nil matches every page that has the property
+	Limit    int
+}
+
+// PropertyMatch is a page whose property matched a query
+type PropertyMatch struct {
+	ID      string      `json:"id" yaml:"id"`
+	Title   string      `json:"title" yaml:"title"`
+	URL     string      `json:"url,omitempty" yaml:"url,omitempty"`
+	Value   interface{} `json:"value" yaml:"value"`
+	Version int         `json:"property_version" yaml:"property_version"`
+}
+
+type propertyPageScheme struct {
+	Results []struct {
+		ID       string             `json:"id"`
+		Title    string             `json:"title"`
+		Links    *models.LinkScheme `json:"_links"`
+		Metadata struct {
+			Properties map[string]*models.ContentPropertyScheme `json:"properties"`
+		} `json:"metadata"`
+	} `json:"results"`
+}
+
+// FindPagesByProperty lists the pages of a space whose property, or a field of it, equals a value.
+// Content properties are not searchable with CQL unless an app indexes them, so the pages of the
+// space are read with the property expanded.
+func FindPagesByProperty(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, query PropertyQuery) ([]*PropertyMatch, *models.ResponseScheme, error) {
+	if query.SpaceKey == "" || query.Key == "" {
+		return nil, nil, fmt.Errorf("a space key and a property key are required")
+	}
+
+	matches := []*PropertyMatch{}
+	var response *models.ResponseScheme
+	const limit = 100
+	for start := 0; ; start += limit {
+		params := url.Values{}
+		params.Set("type", "page")
+		params.Set("spaceKey", query.SpaceKey)
+		params.Set("expand", "metadata.properties."+query.Key)
+		params.Set("start", strconv.Itoa(start))
+		params.Set("limit", strconv.Itoa(limit))
+		request, err := client.NewRequest(ctx, http.MethodGet, "wiki/rest/api/content?"+params.Encode(), "", nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		page := new(propertyPageScheme)
+		if response, err = client.Call(request, page); err != nil {
+			return nil, response, err
+		}
+
+		for _, content := range page.Results {
+			property := content.Metadata.Properties[query.Key]
+			if property == nil {
+				continue
+			}
+			value, ok := propertyField(property.Value, query.Field)
+			if !ok || (query.Value != nil && !propertyEqual(value, query.Value)) {
+				continue
+			}
+			match := &PropertyMatch{ID: content.ID, Title: content.Title, Value: value}
+			if property.Version != nil {
+				match.Version = property.Version.Number
+			}
+			if content.Links != nil {
+				match.URL = cfg.WebURL(content.Links.Webui)
+			}
+			matches = append(matches, match)
+			if query.Limit > 0 && len(matches) >= query.Limit {
+				return matches, response, nil
+			}
+		}
+		if len(page.Results) < limit {
+			break
+		}
+	}
+	return matches, response, nil
+}
+
+// propertyField follows a dotted path into an object value
+func propertyField(value interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return value, true
+	}
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[name]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// propertyEqual compares JSON values; strings compare without case, and a wanted scalar also
+// matches a list that contains it
+func propertyEqual(value, wanted interface{}) bool {
+	if list, ok := value.([]interface{}); ok {
+		if _, wantedList := wanted.([]interface{}); !wantedList {
+			for _, item := range list {
+				if propertyEqual(item, wanted) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	if a, ok := value.(string); ok {
+		if b, ok := wanted.(string); ok {
+			return strings.EqualFold(a, b)
+		}
+		// A number or boolean given as text, e.g. "3" for 3
+		return a == fmt.Sprint(wanted)
+	}
+	if b, ok := wanted.(string); ok {
+		return fmt.Sprint(value) == b
+	}
+	return reflect.DeepEqual(value, wanted)
+}
@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// PageReferenceHelp describes the page references ResolvePageID accepts, for flag and tool descriptions
+const PageReferenceHelp = "page ID, page URL, tiny link (/x/AbCd), SPACE:Title or title"
+
+var (
+	pageIDPattern      = regexp.MustCompile(`^\d+$`)
+	urlPageIDPattern   = regexp.MustCompile(`/(?:pages|blog)/(?:edit-v2/|edit/|\d{4}/\d{2}/\d{2}/)?(\d+)(?:/|$)`)
+	tinyLinkPattern    = regexp.MustCompile(`/x/([A-Za-z0-9_-]+)/?$`)
+	displayPathPattern = regexp.MustCompile(`/display/([^/]+)/([^/]+)/?$`)
+	spaceTitlePattern  = regexp.MustCompile(`^(~?[A-Za-z0-9_]+):(.+)$`)
+)
+
+// PageCandidate is one of the pages a reference could mean
+type PageCandidate struct {
+	ID       string `json:"id" yaml:"id"`
+	Title    string `json:"title" yaml:"title"`
+	SpaceKey string `json:"space,omitempty" yaml:"space,omitempty"`
+	URL      string `json:"url,omitempty" yaml:"url,omitempty"`
+}
+
+// AmbiguousPageError is returned when a title matches several pages, or none but similar ones
+type AmbiguousPageError struct {
+	Reference  string
+	Candidates []PageCandidate
+	NotFound   bool // no exact match; the candidates are similar titles
+}
+
+func (e *AmbiguousPageError) Error() string {
+	var b strings.Builder
+	if e.NotFound {
+		fmt.Fprintf(&b, "no page titled %q; similar pages:", e.Reference)
+	} else {
+		fmt.Fprintf(&b, "%q matches %d pages; use an ID or SPACE:Title:", e.Reference, len(e.Candidates))
+	}
+	for _, c := range e.Candidates {
+		fmt.Fprintf(&b, "\n- %s: %s:%s", c.ID, c.SpaceKey, c.Title)
+		if c.URL != "" {
+			fmt.Fprintf(&b, " (%s)", c.URL)
+		}
+	}
+	return b.String()
+}
+
+// ResolvePageID turns a page reference into a page ID. It accepts numeric IDs, page URLs (Cloud
+// /spaces/KEY/pages/ID/..., Data Center viewpage.action?pageId=ID and /display/KEY/Title), tiny
+// links (/x/AbCd), SPACE:Title and bare titles. Titles matching several pages return an
+// *AmbiguousPageError listing them.
+func ResolvePageID(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, reference string) (string, error) {
+	reference = strings.TrimSpace(reference)
+	switch {
+	case reference == "":
+		return "", fmt.Errorf("a page reference is required")
+	case pageIDPattern.MatchString(reference):
+		return reference, nil
+	case isPageURL(reference):
+		return resolvePageURL(ctx, client, cfg, reference)
+	}
+	if match := spaceTitlePattern.FindStringSubmatch(reference); match != nil {
+		id, err := resolvePageTitle(ctx, client, cfg, match[1], strings.TrimSpace(match[2]))
+		var ambiguous *AmbiguousPageError
+		if err == nil || errors.As(err, &ambiguous) && !ambiguous.NotFound {
+			return id, err
+		}
+		// Titles such as "Retro: Q3" look like SPACE:Title too
+		if id, titleErr := resolvePageTitle(ctx, client, cfg, "", reference); titleErr == nil {
+			return id, nil
+		}
+		return "", err
+	}
+	return resolvePageTitle(ctx, client, cfg, "", reference)
+}
+
+// ResolvePageIDs resolves each reference with ResolvePageID
+func ResolvePageIDs(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, references []string) ([]string, error) {
+	ids := make([]string, 0, len(references))
+	for _, reference := range references {
+		id, err := ResolvePageID(ctx, client, cfg, reference)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func isPageURL(reference string) bool {
+	if strings.HasPrefix(reference, "http://") || strings.HasPrefix(reference, "https://") {
+		return true
+	}
+	for _, prefix := range []string{"/wiki/", "/x/", "/spaces/", "/display/", "/pages/"} {
+		if strings.HasPrefix(reference, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolvePageURL(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, reference string) (string, error) {
+	u, err := url.Parse(reference)
+	if err != nil {
+		return "", fmt.Errorf("invalid page URL %q: %w", reference, err)
+	}
+	if id := u.Query().Get("pageId"); pageIDPattern.MatchString(id) {
+		return id, nil
+	}
+	if match := urlPageIDPattern.FindStringSubmatch(u.Path); match != nil {
+		return match[1], nil
+	}
+	if match := tinyLinkPattern.FindStringSubmatch(u.Path); match != nil {
+		return DecodeTinyLink(match[1])
+	}
+	if match := displayPathPattern.FindStringSubmatch(u.EscapedPath()); match != nil {
+		spaceKey, _ := url.PathUnescape(match[1])
+		title, err := url.QueryUnescape(match[2])
+		if err != nil {
+			return "", fmt.Errorf("invalid page URL %q: %w", reference, err)
+		}
+		return resolvePageTitle(ctx, client, cfg, spaceKey, title)
+	}
+	return "", fmt.Errorf("%q is not a link to a page", reference)
+}
+
+// DecodeTinyLink returns the page ID encoded in the identifier of a tiny link (/x/<identifier>):
+// the little-endian bytes of the ID in base64, with trailing zero digits dropped, - for / and _ for +
+func DecodeTinyLink(identifier string) (string, error) {
+	encoded := strings.NewReplacer("-", "/", "_", "+").Replace(identifier)
+	for len(encoded)%4 != 0 {
+		encoded += "A"
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid tiny link %q: %w", identifier, err)
+	}
+	var id uint64
+	for i, b := range data {
+		if i >= 8 {
+			if b != 0 {
+				return "", fmt.Errorf("invalid tiny link %q: the ID is too large", identifier)
+			}
+			continue
+		}
+		id |= uint64(b) << (8 * i)
+	}
+	if id == 0 {
+		return "", fmt.Errorf("invalid tiny link %q", identifier)
+	}
+	return fmt.Sprintf("%d", id), nil
+}
+
+// EncodeTinyLink returns the tiny link identifier of a page ID
+func EncodeTinyLink(pageID string) (string, error) {
+	var id uint64
+	if _, err := fmt.Sscan(pageID, &id); err != nil || !pageIDPattern.MatchString(pageID) {
+		return "", fmt.Errorf("invalid page ID %q", pageID)
+	}
+	data := make([]byte, 8)
+	for i := range data {
+		data[i] = byte(id >> (8 * i))
+	}
+	encoded := strings.TrimRight(base64.StdEncoding.EncodeToString(data), "=A")
+	return strings.NewReplacer("/", "-", "+", "_").Replace(encoded), nil
+}
+
+// resolvePageTitle finds the page with an exact title, in a space or anywhere
+func resolvePageTitle(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, spaceKey, title string) (string, error) {
+	query := CQLQuery{Title: title, ExactTitle: true, Types: []string{"page"}}
+	if spaceKey != "" {
+		query.Spaces = []string{spaceKey}
+	}
+	candidates, err := searchPageCandidates(ctx, client, cfg, query)
+	if err != nil {
+		return "", err
+	}
+	// title = is not case-sensitive on every deployment; prefer exact matches
+	var exact []PageCandidate
+	for _, candidate := range candidates {
+		if candidate.Title == title {
+			exact = append(exact, candidate)
+		}
+	}
+	if len(exact) > 0 {
+		candidates = exact
+	}
+
+	reference := title
+	if spaceKey != "" {
+		reference = spaceKey + ":" + title
+	}
+	switch len(candidates) {
+	case 1:
+		return candidates[0].ID, nil
+	case 0:
+		query.ExactTitle = false
+		similar, err := searchPageCandidates(ctx, client, cfg, query)
+		if err != nil || len(similar) == 0 {
+			return "", fmt.Errorf("no page titled %q", reference)
+		}
+		return "", &AmbiguousPageError{Reference: reference, Candidates: similar, NotFound: true}
+	default:
+		return "", &AmbiguousPageError{Reference: reference, Candidates: candidates}
+	}
+}
+
+func searchPageCandidates(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, query CQLQuery) ([]PageCandidate, error) {
+	cql, err := BuildCQL(query)
+	if err != nil {
+		return nil, err
+	}
+	results, response, err := SearchContent(ctx, client, cfg, cql, &models.SearchContentOptions{Limit: 10, Expand: []string{"content.space"}})
+	if err != nil {
+		return nil, ResponseError("failed to search pages by title", response, err)
+	}
+	var candidates []PageCandidate
+	for _, result := range results.Results {
+		if result.Content == nil || result.Content.ID == "" {
+			continue
+		}
+		candidate := PageCandidate{ID: result.Content.ID, Title: result.Content.Title, URL: cfg.WebURL(result.URL)}
+		if result.Content.Space != nil {
+			candidate.SpaceKey = result.Content.Space.Key
+		} else if result.Space != nil {
+			candidate.SpaceKey = result.Space.Key
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// Restriction operations supported by Confluence pages
+const (
+	RestrictionRead   = "read"
+	RestrictionUpdate = "update"
+)
+
+// RestrictionSubjects lists the users and groups a restriction operation is limited to
+type RestrictionSubjects struct {
+	Users  []string `json:"users,omitempty" yaml:"users,omitempty"`
+	Groups []string `json:"groups,omitempty" yaml:"groups,omitempty"`
+}
+
+// Empty reports whether no user or group is listed
+func (s RestrictionSubjects) Empty() bool {
+	return len(s.Users) == 0 && len(s.Groups) == 0
+}
+
+// InheritedRestriction is a read restriction set on an ancestor, which also limits who can view the page
+type InheritedRestriction struct {
+	AncestorID    string              `json:"ancestor_id" yaml:"ancestor_id"`
+	AncestorTitle string              `json:"ancestor_title" yaml:"ancestor_title"`
+	Read          RestrictionSubjects `json:"read" yaml:"read"`
+}
+
+// PageRestrictions describes the direct and inherited restrictions of a page
+type PageRestrictions struct {
+	PageID     string                 `json:"page_id" yaml:"page_id"`
+	Title      string                 `json:"title" yaml:"title"`
+	Restricted bool                   `json:"restricted" yaml:"restricted"`
+	Read       RestrictionSubjects    `json:"read" yaml:"read"`
+	Update     RestrictionSubjects    `json:"update" yaml:"update"`
+	Inherited  []InheritedRestriction `json:"inherited,omitempty" yaml:"inherited,omitempty"`
+}
+
+// RestrictionChange is the set of users and groups to restrict each operation to.
+// Users are account IDs on Cloud and usernames on Data Center; groups are group names.
+type RestrictionChange struct {
+	Read   RestrictionSubjects
+	Update RestrictionSubjects
+}
+
+// Empty reports whether the change names no user or group
+func (c RestrictionChange) Empty() bool {
+	return c.Read.Empty() && c.Update.Empty()
+}
+
+var restrictionExpand = []string{"restrictions.user", "restrictions.group"}
+
+// restrictionSubjects collects the users and groups of each operation from a restriction page
+func restrictionSubjects(page *models.ContentRestrictionPageScheme) map[string]RestrictionSubjects {
+	subjects := make(map[string]RestrictionSubjects)
+	if page == nil {
+		return subjects
+	}
+	for _, restriction := range page.Results {
+		if restriction == nil || restriction.Restrictions == nil {
+			continue
+		}
+		var entry RestrictionSubjects
+		if restriction.Restrictions.User != nil {
+			for _, user := range restriction.Restrictions.User.Results {
+				name, id := userIdentity(user)
+				if id != "" && id != name {
+					name = name + " (" + id + ")"
+				}
+				entry.Users = append(entry.Users, name)
+			}
+		}
+		if restriction.Restrictions.Group != nil {
+			for _, group := range restriction.Restrictions.Group.Results {
+				entry.Groups = append(entry.Groups, group.Name)
+			}
+		}
+		subjects[restriction.Operation] = entry
+	}
+	return subjects
+}
+
+// GetPageRestrictions fetches the restrictions set on a page and the read restrictions inherited from its ancestors
+func GetPageRestrictions(ctx context.Context, client *confluence.Client, pageID string) (*PageRestrictions, *models.ResponseScheme, error) {
+	page, response, err := client.Content.Get(ctx, pageID, []string{"ancestors"}, 0)
+	if err != nil {
+		return nil, response, err
+	}
+
+	direct, response, err := client.Content.Restriction.Gets(ctx, pageID, restrictionExpand, 0, 100)
+	if err != nil {
+		return nil, response, err
+	}
+
+	subjects := restrictionSubjects(direct)
+	result := &PageRestrictions{
+		PageID: page.ID,
+		Title:  page.Title,
+		Read:   subjects[RestrictionRead],
+		Update: subjects[RestrictionUpdate],
+	}
+
+	for _, ancestor := range page.Ancestors {
+		restrictions, response, err := client.Content.Restriction.Gets(ctx, ancestor.ID, restrictionExpand, 0, 100)
+		if err != nil {
+			return nil, response, err
+		}
+		read := restrictionSubjects(restrictions)[RestrictionRead]
+		if read.Empty() {
+			continue
+		}
+		result.Inherited = append(result.Inherited, InheritedRestriction{
+			AncestorID:    ancestor.ID,
+			AncestorTitle: ancestor.Title,
+			Read:          read,
+		})
+	}
+
+	result.Restricted = !result.Read.Empty() || !result.Update.Empty() || len(result.Inherited) > 0
+	return result, response, nil
+}
+
+// restrictionPayload builds the update payload for a change, identifying users the way the deployment expects.
+// Operations without subjects are only included when includeEmpty is set, which lifts their restriction.
+func restrictionPayload(cfg *AtlassianConfig, change RestrictionChange, includeEmpty bool) *models.ContentRestrictionUpdatePayloadScheme {
+	payload := &models.ContentRestrictionUpdatePayloadScheme{}
+	for _, operation := range []struct {
+		name     string
+		subjects RestrictionSubjects
+	}{
+		{RestrictionRead, change.Read},
+		{RestrictionUpdate, change.Update},
+	} {
+		if operation.subjects.Empty() && !includeEmpty {
+			continue
+		}
+		restrictions := &models.ContentRestrictionRestrictionUpdateScheme{
+			User:  []*models.ContentUserScheme{},
+			Group: []*models.SpaceGroupScheme{},
+		}
+		for _, user := range operation.subjects.Users {
+			if cfg.IsDataCenter() {
+				restrictions.User = append(restrictions.User, &models.ContentUserScheme{Type: "known", Username: user})
+			} else {
+				restrictions.User = append(restrictions.User, &models.ContentUserScheme{Type: "known", AccountID: user})
+			}
+		}
+		for _, group := range operation.subjects.Groups {
+			restrictions.Group = append(restrictions.Group, &models.SpaceGroupScheme{Type: "group", Name: group})
+		}
+		payload.Results = append(payload.Results, &models.ContentRestrictionUpdateScheme{
+			Operation:    operation.name,
+			Restrictions: restrictions,
+		})
+	}
+	return payload
+}
+
+// SetPageRestrictions applies a restriction change to a page. With replace, the listed subjects
+// become the only ones allowed for each operation and an empty operation is unrestricted;
+// otherwise the subjects are added to the existing restrictions.
+func SetPageRestrictions(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, pageID string, change RestrictionChange, replace bool) (*models.ResponseScheme, error) {
+	payload := restrictionPayload(cfg, change, replace)
+	if replace {
+		_, response, err := client.Content.Restriction.Update(ctx, pageID, payload, nil)
+		return response, err
+	}
+	_, response, err := client.Content.Restriction.Add(ctx, pageID, payload, nil)
+	return response, err
+}
+
+// ClearPageRestrictions removes every restriction set directly on a page
+func ClearPageRestrictions(ctx context.Context, client *confluence.Client, pageID string) (*models.ResponseScheme, error) {
+	_, response, err := client.Content.Restriction.Delete(ctx, pageID, nil)
+	return response, err
+}
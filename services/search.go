@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// SearchContent runs a CQL query and returns the matching results.
+// Data Center instances older than 7.x do not serve /rest/api/search, so the
+// content search endpoint is used there and its results are adapted to the
+// Cloud search result shape.
+func SearchContent(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, cql string, options *models.SearchContentOptions) (*models.SearchPageScheme, *models.ResponseScheme, error) {
+	if !cfg.IsDataCenter() {
+		return client.Search.Content(ctx, cql, options)
+	}
+
+	limit := 25
+	params := url.Values{}
+	expand := []string{"space", "version", "history", "extensions"}
+	if options != nil {
+		if options.Limit != 0 {
+			limit = options.Limit
+		}
+		if options.Start > 0 {
+			params.Set("start", strconv.Itoa(options.Start))
+		}
+		if options.Cursor != "" {
+			params.Set("cursor", options.Cursor)
+		}
+		for _, e := range options.Expand {
+			if e = strings.TrimPrefix(e, "content."); !slices.Contains(expand, e) {
+				expand = append(expand, e)
+			}
+		}
+	}
+	params.Set("cql", cql)
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("expand", strings.Join(expand, ","))
+
+	request, err := client.NewRequest(ctx, http.MethodGet, "wiki/rest/api/content/search?"+params.Encode(), "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	contents := new(models.ContentPageScheme)
+	response, err := client.Call(request, contents)
+	if err != nil {
+		return nil, response, err
+	}
+
+	page := &models.SearchPageScheme{
+		Results:  make([]*models.SearchResultScheme, 0, len(contents.Results)),
+		Start:    contents.Start,
+		Limit:    contents.Limit,
+		Size:     contents.Size,
+		CqlQuery: cql,
+	}
+	if links := contents.Links; links != nil {
+		page.Links = &models.SearchPageLinksScheme{Base: links.Base, Context: links.Context, Next: links.Next, Self: links.Self}
+	}
+
+	for _, content := range contents.Results {
+		result := &models.SearchResultScheme{
+			Content:    content,
+			Space:      content.Space,
+			Title:      content.Title,
+			EntityType: content.Type,
+		}
+		if content.Links != nil {
+			result.URL = content.Links.Webui
+		}
+		if content.Version != nil {
+			result.LastModified = content.Version.When
+		}
+		page.Results = append(page.Results, result)
+	}
+
+	return page, response, nil
+}
+
+// NextSearchPage returns where the page of results after this one starts: the cursor of the next
+// link, or its start offset when the site pages by offset. Both are empty on the last page.
+func NextSearchPage(results *models.SearchPageScheme) (cursor string, start int) {
+	if results == nil || results.Links == nil || results.Links.Next == "" {
+		return "", 0
+	}
+	next, err := url.Parse(results.Links.Next)
+	if err != nil {
+		return "", 0
+	}
+	if cursor = next.Query().Get("cursor"); cursor != "" {
+		return cursor, 0
+	}
+	start, _ = strconv.Atoi(next.Query().Get("start"))
+	return "", start
+}
+
+// ResultLink returns the best available link for a search result
+func ResultLink(cfg *AtlassianConfig, result *models.SearchResultScheme) string {
+	if result.Content != nil && result.Content.Links != nil && result.Content.Links.Self != "" {
+		return result.Content.Links.Self
+	}
+	return cfg.WebURL(result.URL)
+}
+
+// SearchExpand expands the content of search results with what ResultDetails reads
+var SearchExpand = []string{"content.space", "content.history", "content.extensions"}
+
+// ResultDetails holds the fields of a search result that depend on its type: the author of blog
+// posts, the page or blog post a comment or attachment belongs to and the file of attachments
+type ResultDetails struct {
+	Space         string `json:"space,omitempty" yaml:"space,omitempty"`
+	Author        string `json:"author,omitempty" yaml:"author,omitempty"`
+	Published     string `json:"published,omitempty" yaml:"published,omitempty"`
+	Container     string `json:"container,omitempty" yaml:"container,omitempty"`
+	ContainerLink string `json:"container_link,omitempty" yaml:"container_link,omitempty"`
+	MediaType     string `json:"media_type,omitempty" yaml:"media_type,omitempty"`
+	FileSize      int    `json:"file_size,omitempty" yaml:"file_size,omitempty"`
+	DownloadLink  string `json:"download_link,omitempty" yaml:"download_link,omitempty"`
+}
+
+// SearchResultDetails returns the type-specific fields of a search result
+func SearchResultDetails(cfg *AtlassianConfig, result *models.SearchResultScheme) ResultDetails {
+	var details ResultDetails
+	if result.Space != nil {
+		details.Space = result.Space.Key
+	}
+	content := result.Content
+	if content == nil {
+		return details
+	}
+	if content.Space != nil && content.Space.Key != "" {
+		details.Space = content.Space.Key
+	}
+
+	switch content.Type {
+	case ContentTypeBlogPost:
+		if content.History != nil {
+			details.Author = UserName(content.History.CreatedBy)
+			details.Published = content.History.CreatedDate
+		}
+	case "comment", "attachment":
+		if content.History != nil {
+			details.Author = UserName(content.History.CreatedBy)
+		}
+		if result.ResultParentContainer != nil {
+			details.Container = result.ResultParentContainer.Title
+			details.ContainerLink = cfg.WebURL(result.ResultParentContainer.DisplayURL)
+		}
+		if content.Type == "attachment" {
+			if content.Extensions != nil {
+				details.MediaType = content.Extensions.MediaType
+				details.FileSize = content.Extensions.FileSize
+			}
+			if content.Links != nil && content.Links.Download != "" {
+				details.DownloadLink = cfg.WebURL(content.Links.Download)
+			}
+		}
+	}
+	return details
+}
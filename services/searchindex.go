@@ -0,0 +1,509 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Fields of an indexed page
+const (
+	FieldTitle    = "title"
+	FieldHeadings = "headings"
+	FieldLabels   = "labels"
+	FieldBody     = "body"
+)
+
+var indexFields = []string{FieldTitle, FieldHeadings, FieldLabels, FieldBody}
+
+// DefaultFieldBoosts weigh matches in the title, headings and labels above the body
+var DefaultFieldBoosts = map[string]float64{FieldTitle: 4, FieldHeadings: 2, FieldLabels: 2, FieldBody: 1}
+
+// fieldAliases maps the field prefixes accepted in queries to fields
+var fieldAliases = map[string]string{
+	"title": FieldTitle, "heading": FieldHeadings, "headings": FieldHeadings,
+	"label": FieldLabels, "labels": FieldLabels, "body": FieldBody,
+}
+
+// multiValueGap separates the values of a multi-valued field so phrases do not match across them
+const multiValueGap = 10
+
+// IndexedPage is a page as stored in the local search index
+type IndexedPage struct {
+	ID       string
+	SpaceKey string
+	Title    string
+	Headings []string
+	Labels   []string
+	Body     string
+	Version  int
+	Modified time.Time
+	URL      string
+	Lengths  map[string]int // number of tokens per field
+}
+
+// Posting holds the positions of a term in each field of a page
+type Posting map[string][]int
+
+// SearchIndex is an inverted index of pages. It is safe for concurrent use.
+type SearchIndex struct {
+	Site     string
+	Spaces   map[string]time.Time          // space key -> start of the last crawl
+	Pages    map[string]*IndexedPage       // page ID -> page
+	Postings map[string]map[string]Posting // term -> page ID -> positions
+
+	mu sync.RWMutex
+}
+
+// NewSearchIndex returns an empty index for a site
+func NewSearchIndex(site string) *SearchIndex {
+	return &SearchIndex{
+		Site:     site,
+		Spaces:   make(map[string]time.Time),
+		Pages:    make(map[string]*IndexedPage),
+		Postings: make(map[string]map[string]Posting),
+	}
+}
+
+// token is a normalized word with its byte offsets in the source text
+type token struct {
+	term       string
+	start, end int
+}
+
+// tokenize splits text into lower-cased words of letters and digits
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		} else if !word && start >= 0 {
+			tokens = append(tokens, token{term: strings.ToLower(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, token{term: strings.ToLower(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// fieldValues returns the values of a field of a page
+func (p *IndexedPage) fieldValues(field string) []string {
+	switch field {
+	case FieldTitle:
+		return []string{p.Title}
+	case FieldHeadings:
+		return p.Headings
+	case FieldLabels:
+		return p.Labels
+	default:
+		return []string{p.Body}
+	}
+}
+
+// fieldTerms returns the terms of a field with their positions
+func (p *IndexedPage) fieldTerms(field string) map[string][]int {
+	terms := make(map[string][]int)
+	position := 0
+	for _, value := range p.fieldValues(field) {
+		for _, t := range tokenize(value) {
+			terms[t.term] = append(terms[t.term], position)
+			position++
+		}
+		position += multiValueGap
+	}
+	return terms
+}
+
+// Put adds a page to the index, replacing any previous version of it
+func (ix *SearchIndex) Put(page *IndexedPage) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	ix.remove(page.ID)
+	page.Lengths = make(map[string]int, len(indexFields))
+	for _, field := range indexFields {
+		for term, positions := range page.fieldTerms(field) {
+			postings := ix.Postings[term]
+			if postings == nil {
+				postings = make(map[string]Posting)
+				ix.Postings[term] = postings
+			}
+			posting := postings[page.ID]
+			if posting == nil {
+				posting = make(Posting)
+				postings[page.ID] = posting
+			}
+			posting[field] = positions
+			page.Lengths[field] += len(positions)
+		}
+	}
+	ix.Pages[page.ID] = page
+}
+
+// Remove drops a page from the index
+func (ix *SearchIndex) Remove(pageID string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(pageID)
+}
+
+func (ix *SearchIndex) remove(pageID string) {
+	page, ok := ix.Pages[pageID]
+	if !ok {
+		return
+	}
+	for _, field := range indexFields {
+		for term := range page.fieldTerms(field) {
+			delete(ix.Postings[term], pageID)
+			if len(ix.Postings[term]) == 0 {
+				delete(ix.Postings, term)
+			}
+		}
+	}
+	delete(ix.Pages, pageID)
+}
+
+// SpacePageIDs returns the IDs of the indexed pages of a space
+func (ix *SearchIndex) SpacePageIDs(spaceKey string) []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	var ids []string
+	for id, page := range ix.Pages {
+		if page.SpaceKey == spaceKey {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// IndexedSpaces returns the indexed space keys with the time of their last crawl
+func (ix *SearchIndex) IndexedSpaces() map[string]time.Time {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	spaces := make(map[string]time.Time, len(ix.Spaces))
+	for key, crawled := range ix.Spaces {
+		spaces[key] = crawled
+	}
+	return spaces
+}
+
+// LocalSearchQuery is a query against the local index. The text supports "quoted phrases",
+// field prefixes (title:, heading:, label:, body:), +required and -excluded terms.
+type LocalSearchQuery struct {
+	Text   string
+	Spaces []string
+	Limit  int
+	Boosts map[string]float64 // overrides DefaultFieldBoosts
+}
+
+// LocalSearchHit is a page matching a local search
+type LocalSearchHit struct {
+	Page    *IndexedPage
+	Score   float64
+	Fields  []string // fields with a match
+	Snippet string   // body excerpt with matches in **bold**
+}
+
+// queryClause is a term or phrase of a parsed query
+type queryClause struct {
+	terms    []string
+	field    string // empty for every field
+	required bool
+	excluded bool
+}
+
+// parseLocalQuery splits query text into clauses. Bare single terms are optional and only
+// rank results; phrases and fielded or "+" terms are required.
+func parseLocalQuery(text string) []queryClause {
+	var clauses []queryClause
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		if unicode.IsSpace(r) {
+			i += size
+			continue
+		}
+
+		clause := queryClause{}
+		switch text[i] {
+		case '-':
+			clause.excluded = true
+			i++
+		case '+':
+			clause.required = true
+			i++
+		}
+		if colon := strings.IndexByte(text[i:], ':'); colon > 0 {
+			if field, ok := fieldAliases[strings.ToLower(text[i:i+colon])]; ok {
+				clause.field = field
+				i += colon + 1
+			}
+		}
+
+		var value string
+		if i < len(text) && text[i] == '"' {
+			end := strings.IndexByte(text[i+1:], '"')
+			if end < 0 {
+				value, i = text[i+1:], len(text)
+			} else {
+				value, i = text[i+1:i+1+end], i+end+2
+			}
+		} else {
+			end := strings.IndexFunc(text[i:], unicode.IsSpace)
+			if end < 0 {
+				end = len(text) - i
+			}
+			value, i = text[i:i+end], i+end
+		}
+
+		for _, t := range tokenize(value) {
+			clause.terms = append(clause.terms, t.term)
+		}
+		if len(clause.terms) == 0 {
+			continue
+		}
+		if len(clause.terms) > 1 || clause.field != "" {
+			clause.required = true
+		}
+		clauses = append(clauses, clause)
+	}
+	return clauses
+}
+
+// matchCount counts the occurrences of a clause in a field of a page
+func (ix *SearchIndex) matchCount(clause queryClause, pageID, field string) int {
+	first := ix.Postings[clause.terms[0]][pageID][field]
+	if len(clause.terms) == 1 {
+		return len(first)
+	}
+	count := 0
+	for _, start := range first {
+		matched := true
+		for offset, term := range clause.terms[1:] {
+			if !containsPosition(ix.Postings[term][pageID][field], start+offset+1) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			count++
+		}
+	}
+	return count
+}
+
+func containsPosition(positions []int, position int) bool {
+	i := sort.SearchInts(positions, position)
+	return i < len(positions) && positions[i] == position
+}
+
+// candidates returns the pages containing every term of a clause
+func (ix *SearchIndex) candidates(clause queryClause) map[string]bool {
+	pages := make(map[string]bool)
+	for pageID := range ix.Postings[clause.terms[0]] {
+		pages[pageID] = true
+	}
+	for _, term := range clause.terms[1:] {
+		postings := ix.Postings[term]
+		for pageID := range pages {
+			if _, ok := postings[pageID]; !ok {
+				delete(pages, pageID)
+			}
+		}
+	}
+	return pages
+}
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Search ranks the indexed pages matching a query with BM25 over boosted fields
+func (ix *SearchIndex) Search(query LocalSearchQuery) []LocalSearchHit {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	boosts := make(map[string]float64, len(DefaultFieldBoosts))
+	for field, boost := range DefaultFieldBoosts {
+		boosts[field] = boost
+	}
+	for field, boost := range query.Boosts {
+		if boost > 0 {
+			boosts[field] = boost
+		}
+	}
+	spaces := make(map[string]bool, len(query.Spaces))
+	for _, key := range query.Spaces {
+		spaces[key] = true
+	}
+	if query.Limit <= 0 {
+		query.Limit = 10
+	}
+
+	clauses := parseLocalQuery(query.Text)
+	if len(clauses) == 0 || len(ix.Pages) == 0 {
+		return nil
+	}
+
+	averages := make(map[string]float64, len(indexFields))
+	for _, page := range ix.Pages {
+		for field, length := range page.Lengths {
+			averages[field] += float64(length)
+		}
+	}
+	for field := range averages {
+		averages[field] /= float64(len(ix.Pages))
+	}
+
+	scores := make(map[string]float64)
+	matchedFields := make(map[string]map[string]bool)
+	required := make(map[string]int)
+	requiredClauses := 0
+	excluded := make(map[string]bool)
+
+	for _, clause := range clauses {
+		found := ix.candidates(clause)
+		if clause.excluded {
+			for pageID := range found {
+				excluded[pageID] = true
+			}
+			continue
+		}
+		if clause.required {
+			requiredClauses++
+		}
+
+		fields := indexFields
+		if clause.field != "" {
+			fields = []string{clause.field}
+		}
+		counts := make(map[string]map[string]int, len(found))
+		for pageID := range found {
+			for _, field := range fields {
+				if n := ix.matchCount(clause, pageID, field); n > 0 {
+					if counts[pageID] == nil {
+						counts[pageID] = make(map[string]int)
+					}
+					counts[pageID][field] = n
+				}
+			}
+		}
+
+		df := float64(len(counts))
+		idf := math.Log(1 + (float64(len(ix.Pages))-df+0.5)/(df+0.5))
+		for pageID, fieldCounts := range counts {
+			page := ix.Pages[pageID]
+			weighted := 0.0
+			for field, n := range fieldCounts {
+				norm := 1.0
+				if averages[field] > 0 {
+					norm = (1 - bm25B) + bm25B*float64(page.Lengths[field])/averages[field]
+				}
+				weighted += boosts[field] * float64(n) / norm
+				if matchedFields[pageID] == nil {
+					matchedFields[pageID] = make(map[string]bool)
+				}
+				matchedFields[pageID][field] = true
+			}
+			scores[pageID] += idf * weighted / (bm25K1 + weighted)
+			if clause.required {
+				required[pageID]++
+			}
+		}
+	}
+
+	var terms []string
+	for _, clause := range clauses {
+		if !clause.excluded {
+			terms = append(terms, clause.terms...)
+		}
+	}
+
+	var hits []LocalSearchHit
+	for pageID, score := range scores {
+		page := ix.Pages[pageID]
+		if excluded[pageID] || required[pageID] < requiredClauses || len(spaces) > 0 && !spaces[page.SpaceKey] {
+			continue
+		}
+		hit := LocalSearchHit{Page: page, Score: math.Round(score*1000) / 1000}
+		for _, field := range indexFields {
+			if matchedFields[pageID][field] {
+				hit.Fields = append(hit.Fields, field)
+			}
+		}
+		hits = append(hits, hit)
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Page.Title < hits[j].Page.Title
+	})
+	if len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	for i := range hits {
+		hits[i].Snippet = Snippet(hits[i].Page.Body, terms, 30)
+	}
+	return hits
+}
+
+// Snippet returns the window of about width words of text that contains the most distinct
+// query terms, with the matching words in **bold**
+func Snippet(text string, terms []string, width int) string {
+	tokens := tokenize(text)
+	if len(tokens) == 0 {
+		return ""
+	}
+	wanted := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		wanted[term] = true
+	}
+
+	best, bestCount := 0, -1
+	for start := 0; start < len(tokens); start++ {
+		if start > 0 && !wanted[tokens[start].term] {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, t := range tokens[start:min(start+width, len(tokens))] {
+			if wanted[t.term] {
+				seen[t.term] = true
+			}
+		}
+		if len(seen) > bestCount {
+			best, bestCount = start, len(seen)
+		}
+	}
+
+	// Start a few words before the first match for context
+	first := max(best-3, 0)
+	last := min(first+width, len(tokens)) - 1
+	var b strings.Builder
+	if first > 0 {
+		b.WriteString("…")
+	}
+	offset := tokens[first].start
+	for _, t := range tokens[first : last+1] {
+		if !wanted[t.term] {
+			continue
+		}
+		b.WriteString(text[offset:t.start])
+		b.WriteString("**" + text[t.start:t.end] + "**")
+		offset = t.end
+	}
+	b.WriteString(text[offset:tokens[last].end])
+	if last < len(tokens)-1 {
+		b.WriteString("…")
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// SpaceDetails is a space with the expansions go-atlassian's SpaceScheme does not model
+type SpaceDetails struct {
+	models.SpaceScheme
+	Description *SpaceDescriptionScheme `json:"description,omitempty"`
+	Metadata    *models.MetadataScheme  `json:"metadata,omitempty"`
+}
+
+// SpaceDescriptionScheme holds the plain text description of a space
+type SpaceDescriptionScheme struct {
+	Plain *models.BodyNodeScheme `json:"plain,omitempty"`
+}
+
+// SpacePermissionSummary counts the subjects granted one space operation
+type SpacePermissionSummary struct {
+	Operation string `json:"operation" yaml:"operation"`
+	Users     int    `json:"users" yaml:"users"`
+	Groups    int    `json:"groups" yaml:"groups"`
+	Anonymous bool   `json:"anonymous,omitempty" yaml:"anonymous,omitempty"`
+}
+
+// GetSpace fetches a space with arbitrary expansions, e.g. description.plain or metadata.labels
+func GetSpace(ctx context.Context, client *confluence.Client, spaceKey string, expand []string) (*SpaceDetails, *models.ResponseScheme, error) {
+	query := url.Values{}
+	if len(expand) != 0 {
+		query.Add("expand", strings.Join(expand, ","))
+	}
+
+	endpoint := fmt.Sprintf("wiki/rest/api/space/%v?%v", url.PathEscape(spaceKey), query.Encode())
+	request, err := client.NewRequest(ctx, http.MethodGet, endpoint, "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	space := new(SpaceDetails)
+	response, err := client.Call(request, space)
+	if err != nil {
+		return nil, response, err
+	}
+
+	return space, response, nil
+}
+
+// PermissionOperation returns the "operation target" key of a space permission, e.g. "create page"
+func PermissionOperation(permission *models.SpacePermissionScheme) string {
+	if permission.Operation == nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(permission.Operation.Operation + " " + permission.Operation.TargetType)
+}
+
+// SummarizeSpacePermissions counts the users and groups granted each operation
+func SummarizeSpacePermissions(permissions []*models.SpacePermissionScheme) []SpacePermissionSummary {
+	byOperation := make(map[string]*SpacePermissionSummary)
+	for _, permission := range permissions {
+		key := PermissionOperation(permission)
+		summary, ok := byOperation[key]
+		if !ok {
+			summary = &SpacePermissionSummary{Operation: key}
+			byOperation[key] = summary
+		}
+		if permission.AnonymousAccess {
+			summary.Anonymous = true
+		}
+		if permission.Subject == nil {
+			continue
+		}
+		if permission.Subject.User != nil {
+			summary.Users += len(permission.Subject.User.Results)
+		}
+		if permission.Subject.Group != nil {
+			summary.Groups += len(permission.Subject.Group.Results)
+		}
+	}
+
+	summaries := make([]SpacePermissionSummary, 0, len(byOperation))
+	for _, summary := range byOperation {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Operation < summaries[j].Operation })
+	return summaries
+}
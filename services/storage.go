@@ -0,0 +1,522 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// StorageOptions controls how Markdown links are turned into Confluence links
+type StorageOptions struct {
+	// PageTitle resolves a relative link target such as "../Guide.md" to the title of the page
+	// it refers to; unresolved targets are kept as plain hyperlinks
+	PageTitle func(target string) (string, bool)
+	// AttachmentName returns the attachment file name a relative image or file target is
+	// uploaded as; by default the base name of the target is used
+	AttachmentName func(target string) string
+}
+
+// attachmentName returns the attachment file name of a relative target
+func (o *StorageOptions) attachmentName(target string) string {
+	if o.AttachmentName != nil {
+		return o.AttachmentName(target)
+	}
+	return path.Base(target)
+}
+
+var (
+	fencePattern     = regexp.MustCompile("^\\s*(```+|~~~+)\\s*([\\w+#.-]*)\\s*$")
+	headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	rulePattern      = regexp.MustCompile(`^\s*(-\s*){3,}$|^\s*(\*\s*){3,}$|^\s*(_\s*){3,}$`)
+	listItemPattern  = regexp.MustCompile(`^(\s*)([-*+]|\d+[.)])\s+(.*)$`)
+	taskPattern      = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+	tableSeparator   = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	admonitionLabels = map[string]string{"info": "info", "note": "note", "warning": "warning", "tip": "tip", "panel": "panel"}
+)
+
+// MarkdownToStorage converts Markdown to Confluence storage format. Fenced code becomes a code
+// macro, task lists become Confluence tasks, blockquotes starting with **Info**, **Note**,
+// **Warning** or **Tip** become the matching macro, and lines starting with "<" pass through as
+// raw storage format.
+func MarkdownToStorage(markdown string, options *StorageOptions) string {
+	c := &storageConverter{options: options}
+	if c.options == nil {
+		c.options = &StorageOptions{}
+	}
+	lines := strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n")
+	return c.blocks(lines)
+}
+
+type storageConverter struct {
+	options *StorageOptions
+}
+
+func (c *storageConverter) blocks(lines []string) string {
+	var b strings.Builder
+	var paragraph []string
+
+	flush := func() {
+		if len(paragraph) == 0 {
+			return
+		}
+		b.WriteString("<p>" + c.paragraph(paragraph) + "</p>")
+		paragraph = nil
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+
+		case fencePattern.MatchString(line):
+			flush()
+			match := fencePattern.FindStringSubmatch(line)
+			var code []string
+			for i++; i < len(lines); i++ {
+				if strings.HasPrefix(strings.TrimSpace(lines[i]), match[1]) && strings.Trim(strings.TrimSpace(lines[i]), match[1][:1]) == "" {
+					break
+				}
+				code = append(code, lines[i])
+			}
+			b.WriteString(codeMacro(match[2], strings.Join(code, "\n")))
+
+		case headingPattern.MatchString(line) && !strings.HasPrefix(line, " "):
+			flush()
+			match := headingPattern.FindStringSubmatch(line)
+			level := len(match[1])
+			fmt.Fprintf(&b, "<h%d>%s</h%d>", level, c.inline(match[2]), level)
+
+		case rulePattern.MatchString(line) && len(paragraph) == 0:
+			flush()
+			b.WriteString("<hr/>")
+
+		case strings.HasPrefix(trimmed, ">"):
+			flush()
+			var quoted []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+				content := strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")
+				quoted = append(quoted, strings.TrimPrefix(content, " "))
+			}
+			i--
+			b.WriteString(c.quote(quoted))
+
+		case strings.HasPrefix(trimmed, "|") && i+1 < len(lines) && tableSeparator.MatchString(lines[i+1]):
+			flush()
+			var rows []string
+			for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|"); i++ {
+				rows = append(rows, lines[i])
+			}
+			i--
+			b.WriteString(c.table(rows))
+
+		case listItemPattern.MatchString(line):
+			flush()
+			start := i
+			indent := leadingSpaces(line)
+			ordered := isOrderedItem(line)
+			sameList := func(next string) bool {
+				return listItemPattern.MatchString(next) && leadingSpaces(next) == indent && isOrderedItem(next) == ordered
+			}
+			for i++; i < len(lines); i++ {
+				next := lines[i]
+				if strings.TrimSpace(next) == "" {
+					// A blank line continues the list only if the next line is indented or another item
+					if i+1 < len(lines) && (leadingSpaces(lines[i+1]) > indent || sameList(lines[i+1])) {
+						continue
+					}
+					break
+				}
+				if leadingSpaces(next) <= indent && !sameList(next) {
+					break
+				}
+			}
+			b.WriteString(c.list(lines[start:i]))
+			i--
+
+		case strings.HasPrefix(trimmed, "<") && len(paragraph) == 0:
+			for ; i < len(lines) && strings.TrimSpace(lines[i]) != ""; i++ {
+				b.WriteString(lines[i])
+			}
+
+		default:
+			paragraph = append(paragraph, line)
+		}
+	}
+	flush()
+	return b.String()
+}
+
+// paragraph joins the lines of a paragraph, honouring hard line breaks
+func (c *storageConverter) paragraph(lines []string) string {
+	var parts []string
+	for i, line := range lines {
+		hardBreak := strings.HasSuffix(line, "  ") || strings.HasSuffix(line, `\`)
+		text := c.inline(strings.TrimSpace(strings.TrimSuffix(strings.TrimRight(line, " "), `\`)))
+		if hardBreak && i < len(lines)-1 {
+			text += "<br/>"
+		}
+		parts = append(parts, text)
+	}
+	return strings.ReplaceAll(strings.Join(parts, " "), "<br/> ", "<br/>")
+}
+
+func (c *storageConverter) quote(lines []string) string {
+	if len(lines) > 0 {
+		first := strings.TrimSpace(lines[0])
+		if strings.HasPrefix(first, "**") && strings.HasSuffix(first, "**") && len(first) > 4 {
+			label := strings.Trim(first, "*")
+			name, title, _ := strings.Cut(label, ":")
+			if macro, ok := admonitionLabels[strings.ToLower(strings.TrimSpace(name))]; ok {
+				var b strings.Builder
+				fmt.Fprintf(&b, `<ac:structured-macro ac:name="%s">`, macro)
+				if title = strings.TrimSpace(title); title != "" {
+					fmt.Fprintf(&b, `<ac:parameter ac:name="title">%s</ac:parameter>`, html.EscapeString(title))
+				}
+				b.WriteString("<ac:rich-text-body>" + c.blocks(lines[1:]) + "</ac:rich-text-body></ac:structured-macro>")
+				return b.String()
+			}
+		}
+	}
+	return "<blockquote>" + c.blocks(lines) + "</blockquote>"
+}
+
+func (c *storageConverter) table(rows []string) string {
+	var b strings.Builder
+	b.WriteString("<table><tbody>")
+	for i, row := range rows {
+		if i == 1 {
+			continue // separator
+		}
+		cell := "td"
+		if i == 0 {
+			cell = "th"
+		}
+		b.WriteString("<tr>")
+		for _, value := range splitTableRow(row) {
+			value = strings.ReplaceAll(value, "<br>", "\n")
+			var parts []string
+			for _, part := range strings.Split(value, "\n") {
+				parts = append(parts, c.inline(strings.TrimSpace(part)))
+			}
+			fmt.Fprintf(&b, "<%s>%s</%s>", cell, strings.Join(parts, "<br/>"), cell)
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</tbody></table>")
+	return b.String()
+}
+
+// splitTableRow splits a table row on unescaped pipes
+func splitTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	if strings.HasSuffix(row, "|") && !strings.HasSuffix(row, `\|`) {
+		row = row[:len(row)-1]
+	}
+	var cells []string
+	var current strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			current.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(current.String()))
+			current.Reset()
+		default:
+			current.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(current.String()))
+}
+
+// list converts the lines of a list, whose first line is an item, including nested lists
+func (c *storageConverter) list(lines []string) string {
+	indent := leadingSpaces(lines[0])
+	first := listItemPattern.FindStringSubmatch(lines[0])
+	ordered := isOrderedItem(lines[0])
+
+	type item struct {
+		text string
+		body []string
+	}
+	var items []*item
+	for _, line := range lines {
+		if match := listItemPattern.FindStringSubmatch(line); match != nil && len(match[1]) == indent {
+			items = append(items, &item{text: match[3]})
+			continue
+		}
+		if len(items) == 0 {
+			continue
+		}
+		current := items[len(items)-1]
+		current.body = append(current.body, dedent(line, indent+len(first[2])+1))
+	}
+
+	tasks := !ordered
+	for _, it := range items {
+		if !taskPattern.MatchString(it.text) {
+			tasks = false
+		}
+	}
+
+	var b strings.Builder
+	switch {
+	case tasks:
+		b.WriteString("<ac:task-list>")
+		for _, it := range items {
+			match := taskPattern.FindStringSubmatch(it.text)
+			status := "incomplete"
+			if match[1] != " " {
+				status = "complete"
+			}
+			body := c.itemBody(append([]string{match[2]}, it.body...))
+			fmt.Fprintf(&b, "<ac:task><ac:task-status>%s</ac:task-status><ac:task-body>%s</ac:task-body></ac:task>", status, body)
+		}
+		b.WriteString("</ac:task-list>")
+	case ordered:
+		b.WriteString("<ol>")
+		for _, it := range items {
+			b.WriteString("<li>" + c.itemBody(append([]string{it.text}, it.body...)) + "</li>")
+		}
+		b.WriteString("</ol>")
+	default:
+		b.WriteString("<ul>")
+		for _, it := range items {
+			b.WriteString("<li>" + c.itemBody(append([]string{it.text}, it.body...)) + "</li>")
+		}
+		b.WriteString("</ul>")
+	}
+	return b.String()
+}
+
+// itemBody converts the content of a list item, unwrapping a leading paragraph so tight lists stay tight
+func (c *storageConverter) itemBody(lines []string) string {
+	body := c.blocks(lines)
+	if strings.HasPrefix(body, "<p>") {
+		if end := strings.Index(body, "</p>"); end >= 0 {
+			body = body[3:end] + body[end+4:]
+		}
+	}
+	return body
+}
+
+// inline converts inline Markdown: emphasis, code spans, links, images and hard breaks
+func (c *storageConverter) inline(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		switch {
+		case rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*_{}[]()#+-.!|~<>", rune(rest[1])):
+			b.WriteString(html.EscapeString(rest[1:2]))
+			i += 2
+
+		case rest[0] == '`':
+			ticks := len(rest) - len(strings.TrimLeft(rest, "`"))
+			marker := rest[:ticks]
+			if end := strings.Index(rest[ticks:], marker); end >= 0 {
+				code := strings.TrimSpace(rest[ticks : ticks+end])
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i += ticks + end + ticks
+				continue
+			}
+			b.WriteString(html.EscapeString(marker))
+			i += ticks
+
+		case strings.HasPrefix(rest, "!["):
+			if alt, target, length, ok := parseLink(rest[1:]); ok {
+				b.WriteString(c.image(alt, target))
+				i += 1 + length
+				continue
+			}
+			b.WriteString("!")
+			i++
+
+		case rest[0] == '[':
+			if label, target, length, ok := parseLink(rest); ok {
+				b.WriteString(c.link(label, target))
+				i += length
+				continue
+			}
+			b.WriteString("[")
+			i++
+
+		case strings.HasPrefix(rest, "**") || strings.HasPrefix(rest, "__"):
+			if end := strings.Index(rest[2:], rest[:2]); end > 0 {
+				b.WriteString("<strong>" + c.inline(rest[2:2+end]) + "</strong>")
+				i += 2 + end + 2
+				continue
+			}
+			b.WriteString(rest[:2])
+			i += 2
+
+		case strings.HasPrefix(rest, "~~"):
+			if end := strings.Index(rest[2:], "~~"); end > 0 {
+				b.WriteString(`<span style="text-decoration: line-through;">` + c.inline(rest[2:2+end]) + "</span>")
+				i += 2 + end + 2
+				continue
+			}
+			b.WriteString("~~")
+			i += 2
+
+		case rest[0] == '*' || rest[0] == '_' && (i == 0 || !isWordByte(text[i-1])):
+			marker := rest[:1]
+			if end := strings.Index(rest[1:], marker); end > 0 && rest[1] != ' ' {
+				closing := 1 + end + 1
+				if marker == "*" || closing >= len(rest) || !isWordByte(rest[closing]) {
+					b.WriteString("<em>" + c.inline(rest[1:1+end]) + "</em>")
+					i += closing
+					continue
+				}
+			}
+			b.WriteString(marker)
+			i++
+
+		case rest[0] == '<':
+			if end := strings.Index(rest, ">"); end > 0 && (strings.HasPrefix(rest, "<http://") || strings.HasPrefix(rest, "<https://")) {
+				url := rest[1:end]
+				b.WriteString(`<a href="` + html.EscapeString(url) + `">` + html.EscapeString(url) + "</a>")
+				i += end + 1
+				continue
+			}
+			b.WriteString("&lt;")
+			i++
+
+		default:
+			b.WriteString(html.EscapeString(rest[:1]))
+			i++
+		}
+	}
+	return b.String()
+}
+
+// link converts a Markdown link, turning links to other Markdown files into page links
+func (c *storageConverter) link(label, target string) string {
+	if isRelativeTarget(target) {
+		file, anchor, _ := strings.Cut(target, "#")
+		var title string
+		var ok bool
+		if file != "" && c.options.PageTitle != nil {
+			title, ok = c.options.PageTitle(file)
+		}
+		if ok || file == "" && anchor != "" {
+			var b strings.Builder
+			b.WriteString("<ac:link")
+			if anchor != "" {
+				fmt.Fprintf(&b, ` ac:anchor="%s"`, html.EscapeString(anchor))
+			}
+			b.WriteString(">")
+			if ok {
+				fmt.Fprintf(&b, `<ri:page ri:content-title="%s"/>`, html.EscapeString(title))
+			}
+			b.WriteString("<ac:plain-text-link-body>" + cdata(label) + "</ac:plain-text-link-body></ac:link>")
+			return b.String()
+		}
+		if !strings.HasSuffix(strings.ToLower(file), ".md") && file != "" {
+			return `<ac:link><ri:attachment ri:filename="` + html.EscapeString(c.options.attachmentName(file)) + `"/><ac:plain-text-link-body>` + cdata(label) + "</ac:plain-text-link-body></ac:link>"
+		}
+	}
+	return `<a href="` + html.EscapeString(target) + `">` + c.inline(label) + "</a>"
+}
+
+// image converts an image: relative targets refer to page attachments
+func (c *storageConverter) image(alt, target string) string {
+	altAttr := ""
+	if alt != "" {
+		altAttr = ` ac:alt="` + html.EscapeString(alt) + `"`
+	}
+	if isRelativeTarget(target) {
+		return "<ac:image" + altAttr + `><ri:attachment ri:filename="` + html.EscapeString(c.options.attachmentName(target)) + `"/></ac:image>`
+	}
+	return "<ac:image" + altAttr + `><ri:url ri:value="` + html.EscapeString(target) + `"/></ac:image>`
+}
+
+// parseLink parses "[label](target)" at the start of text and returns its length
+func parseLink(text string) (string, string, int, bool) {
+	depth := 0
+	closeLabel := -1
+	for i := 0; i < len(text); i++ {
+		if text[i] == '[' {
+			depth++
+		} else if text[i] == ']' {
+			depth--
+			if depth == 0 {
+				closeLabel = i
+				break
+			}
+		}
+	}
+	if closeLabel < 0 || closeLabel+1 >= len(text) || text[closeLabel+1] != '(' {
+		return "", "", 0, false
+	}
+
+	rest := text[closeLabel+2:]
+	var target string
+	var length int
+	if strings.HasPrefix(rest, "<") {
+		end := strings.Index(rest, ">)")
+		if end < 0 {
+			return "", "", 0, false
+		}
+		target, length = rest[1:end], end+2
+	} else {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return "", "", 0, false
+		}
+		target, length = rest[:end], end+1
+		if space := strings.Index(target, " "); space >= 0 {
+			target = target[:space] // drop a link title
+		}
+	}
+	return text[1:closeLabel], target, closeLabel + 2 + length, true
+}
+
+// isOrderedItem reports whether a list item line uses a numbered marker
+func isOrderedItem(line string) bool {
+	match := listItemPattern.FindStringSubmatch(line)
+	return match != nil && match[2][0] >= '0' && match[2][0] <= '9'
+}
+
+func isRelativeTarget(target string) bool {
+	return !strings.Contains(target, "://") && !strings.HasPrefix(target, "mailto:") && !strings.HasPrefix(target, "/")
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func leadingSpaces(line string) int {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+// dedent removes up to n leading spaces
+func dedent(line string, n int) string {
+	line = strings.ReplaceAll(line, "\t", "    ")
+	spaces := len(line) - len(strings.TrimLeft(line, " "))
+	if spaces > n {
+		spaces = n
+	}
+	return line[spaces:]
+}
+
+func codeMacro(language, code string) string {
+	var b strings.Builder
+	b.WriteString(`<ac:structured-macro ac:name="code">`)
+	if language != "" {
+		fmt.Fprintf(&b, `<ac:parameter ac:name="language">%s</ac:parameter>`, html.EscapeString(language))
+	}
+	b.WriteString("<ac:plain-text-body>" + cdata(code) + "</ac:plain-text-body></ac:structured-macro>")
+	return b.String()
+}
+
+// cdata wraps text in a CDATA section, splitting any "]]>" it contains
+func cdata(text string) string {
+	return "<![CDATA[" + strings.ReplaceAll(text, "]]>", "]]]]><![CDATA[>") + "]]>"
+}
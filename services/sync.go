@@ -0,0 +1,626 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"gopkg.in/yaml.v3"
+)
+
+// SyncStateFile is the name of the state file kept at the root of a synced directory
+const SyncStateFile = ".confluence-sync.json"
+
+// Sync actions reported in a plan
+const (
+	SyncCreate    = "create"
+	SyncUpdate    = "update"
+	SyncUnchanged = "unchanged"
+	SyncConflict  = "conflict"
+	SyncSkip      = "skip"
+)
+
+// PageFrontMatter is the YAML front matter of a synced Markdown file
+type PageFrontMatter struct {
+	Title   string `yaml:"title"`
+	ID      string `yaml:"id,omitempty"`
+	Version int    `yaml:"version,omitempty"`
+	Parent  string `yaml:"parent,omitempty"`
+	Space   string `yaml:"space,omitempty"`
+}
+
+// SyncState records what was last synced, so local and remote changes can be told apart
+type SyncState struct {
+	Site       string                 `json:"site"`
+	SpaceKey   string                 `json:"space_key"`
+	RootPageID string                 `json:"root_page_id,omitempty"`
+	ParentID   string                 `json:"parent_id,omitempty"`
+	Pages      map[string]*SyncedPage `json:"pages"` // keyed by page ID
+}
+
+// SyncedPage is the last synced state of one page
+type SyncedPage struct {
+	Path    string `json:"path"`
+	Version int    `json:"version"`
+	Hash    string `json:"hash"`
+}
+
+// SyncAction is one step of a pull or push plan
+type SyncAction struct {
+	Action  string `json:"action" yaml:"action"`
+	Path    string `json:"path" yaml:"path"`
+	ID      string `json:"id,omitempty" yaml:"id,omitempty"`
+	Title   string `json:"title" yaml:"title"`
+	Version int    `json:"version,omitempty" yaml:"version,omitempty"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// SyncPlan lists what a pull or push did, or would do with a dry run
+type SyncPlan struct {
+	Direction string         `json:"direction" yaml:"direction"`
+	Dir       string         `json:"dir" yaml:"dir"`
+	DryRun    bool           `json:"dry_run" yaml:"dry_run"`
+	Actions   []SyncAction   `json:"actions" yaml:"actions"`
+	Summary   map[string]int `json:"summary" yaml:"summary"`
+}
+
+func (p *SyncPlan) add(action SyncAction) {
+	p.Actions = append(p.Actions, action)
+	p.Summary[action.Action]++
+}
+
+// HasConflicts reports whether the plan skipped pages because of conflicts
+func (p *SyncPlan) HasConflicts() bool {
+	return p.Summary[SyncConflict] > 0
+}
+
+// PullOptions configures a pull of a page tree into a directory
+type PullOptions struct {
+	RootPageID string
+	Dir        string
+	DryRun     bool
+	Force      bool
+}
+
+// PushOptions configures a push of a directory into a page tree
+type PushOptions struct {
+	Dir      string
+	SpaceKey string
+	ParentID string
+	DryRun   bool
+	Force    bool
+}
+
+// ParsePageFile splits a Markdown file into its front matter and body
+func ParsePageFile(data []byte) (*PageFrontMatter, string, error) {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	frontMatter := &PageFrontMatter{}
+	if !strings.HasPrefix(text, "---\n") {
+		return frontMatter, text, nil
+	}
+	end := strings.Index(text[4:], "\n---\n")
+	if end < 0 {
+		return nil, "", fmt.Errorf("unterminated front matter")
+	}
+	if err := yaml.Unmarshal([]byte(text[4:4+end]), frontMatter); err != nil {
+		return nil, "", fmt.Errorf("invalid front matter: %w", err)
+	}
+	return frontMatter, strings.TrimPrefix(text[4+end+5:], "\n"), nil
+}
+
+// FormatPageFile renders a Markdown file with its front matter
+func FormatPageFile(frontMatter *PageFrontMatter, body string) []byte {
+	data, _ := yaml.Marshal(frontMatter)
+	return []byte("---\n" + string(data) + "---\n\n" + body)
+}
+
+// contentHash fingerprints a Markdown body, ignoring surrounding whitespace
+func contentHash(body string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(body)))
+	return hex.EncodeToString(sum[:])
+}
+
+var unsafeFileChars = regexp.MustCompile(`[/\\:*?"<>|\x00-\x1f]+`)
+
+// FileSlug turns a page title into a file name without extension
+func FileSlug(title string) string {
+	slug := strings.TrimSpace(unsafeFileChars.ReplaceAllString(title, "-"))
+	slug = strings.Trim(slug, ". ")
+	if slug == "" {
+		slug = "untitled"
+	}
+	return slug
+}
+
+// LoadSyncState reads the state file of a directory, returning an empty state if there is none
+func LoadSyncState(dir string) (*SyncState, error) {
+	state := &SyncState{Pages: make(map[string]*SyncedPage)}
+	data, err := os.ReadFile(filepath.Join(dir, SyncStateFile))
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %w", SyncStateFile, err)
+	}
+	if state.Pages == nil {
+		state.Pages = make(map[string]*SyncedPage)
+	}
+	return state, nil
+}
+
+// Save writes the state file of a directory
+func (s *SyncState) Save(dir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, SyncStateFile), append(data, '\n'), 0o644)
+}
+
+// assignPaths maps every page of the trees to a relative path under dir: a page is "<slug><ext>"
+// and its children live in the "<slug>/" directory next to it
+func assignPaths(roots []*PageNode, dir, ext string) map[string]string {
+	paths := make(map[string]string)
+	var assign func(nodes []*PageNode, dir string)
+	assign = func(nodes []*PageNode, dir string) {
+		used := make(map[string]bool)
+		for _, node := range nodes {
+			slug := FileSlug(node.Page.Title)
+			if used[strings.ToLower(slug)] {
+				slug += "-" + node.Page.ID
+			}
+			used[strings.ToLower(slug)] = true
+			paths[node.Page.ID] = path.Join(dir, slug+ext)
+			assign(node.Children, path.Join(dir, slug))
+		}
+	}
+	assign(roots, dir)
+	return paths
+}
+
+// relativeLink returns the link target from the file at "from" to the file at "to"
+func relativeLink(from, to string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Pull mirrors a page tree into a directory of Markdown files
+func Pull(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, options PullOptions) (*SyncPlan, error) {
+	state, err := LoadSyncState(options.Dir)
+	if err != nil {
+		return nil, err
+	}
+	rootID := options.RootPageID
+	if rootID == "" {
+		rootID = state.RootPageID
+	}
+	if rootID == "" {
+		return nil, fmt.Errorf("no root page: pass a page ID or pull into a directory that was synced before")
+	}
+
+	tree, response, err := GetPageTree(ctx, client, rootID, []string{"body.storage", "version", "space", "ancestors"})
+	if err != nil {
+		return nil, ResponseError("failed to fetch page tree", response, err)
+	}
+
+	paths := assignPaths([]*PageNode{tree}, "", ".md")
+	titles := make(map[string]string) // lower-case title -> path
+	tree.Walk(func(node *PageNode, depth int) error {
+		titles[strings.ToLower(node.Page.Title)] = paths[node.Page.ID]
+		return nil
+	})
+
+	plan := &SyncPlan{Direction: "pull", Dir: options.Dir, DryRun: options.DryRun, Summary: make(map[string]int)}
+	parents := make(map[string]string)
+	seen := make(map[string]bool)
+
+	err = tree.Walk(func(node *PageNode, depth int) error {
+		page := node.Page
+		seen[page.ID] = true
+		for _, child := range node.Children {
+			parents[child.Page.ID] = page.ID
+		}
+		relPath := paths[page.ID]
+
+		markdown, err := StorageToMarkdown(ContentBody(page), &MarkdownOptions{
+			PageLink: func(spaceKey, title string) string {
+				if target, ok := titles[strings.ToLower(title)]; ok {
+					return relativeLink(relPath, target)
+				}
+				return title
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to convert %q: %w", page.Title, err)
+		}
+
+		frontMatter := &PageFrontMatter{Title: page.Title, ID: page.ID, Parent: parents[page.ID]}
+		if page.Version != nil {
+			frontMatter.Version = page.Version.Number
+		}
+		if page.Space != nil {
+			frontMatter.Space = page.Space.Key
+		}
+		if depth == 0 && len(page.Ancestors) > 0 {
+			frontMatter.Parent = page.Ancestors[len(page.Ancestors)-1].ID
+			state.ParentID = frontMatter.Parent
+		}
+
+		action := SyncAction{Path: relPath, ID: page.ID, Title: page.Title, Version: frontMatter.Version}
+		synced := state.Pages[page.ID]
+
+		localPath := relPath
+		if synced != nil {
+			localPath = synced.Path
+		}
+		data, readErr := os.ReadFile(filepath.Join(options.Dir, filepath.FromSlash(localPath)))
+		switch {
+		case os.IsNotExist(readErr):
+			action.Action = SyncCreate
+		case readErr != nil:
+			return readErr
+		default:
+			localMatter, localBody, err := ParsePageFile(data)
+			if err != nil {
+				return fmt.Errorf("%s: %w", localPath, err)
+			}
+			localChanged := synced == nil || contentHash(localBody) != synced.Hash
+			remoteChanged := synced == nil || frontMatter.Version > synced.Version
+			moved := localPath != relPath
+			switch {
+			case synced == nil && localMatter.ID == page.ID && localMatter.Version == frontMatter.Version:
+				// A file of the current version that is not in the state yet, such as one from
+				// an export or a lost state file: adopt it so later pulls and pushes compare
+				// against it
+				action.Action = SyncUnchanged
+				if !options.DryRun {
+					state.Pages[page.ID] = &SyncedPage{Path: relPath, Version: frontMatter.Version, Hash: contentHash(localBody)}
+				}
+			case localChanged && remoteChanged && !options.Force:
+				action.Action = SyncConflict
+				action.Reason = "changed locally and in Confluence; push or merge the local changes, or pull with --force to overwrite them"
+			case !remoteChanged && !moved:
+				action.Action = SyncUnchanged
+				if localChanged {
+					action.Reason = "local changes not pushed yet"
+				}
+			case !remoteChanged && localChanged:
+				action.Action = SyncConflict
+				action.Reason = fmt.Sprintf("page was renamed or moved to %s but %s has local changes", relPath, localPath)
+			default:
+				action.Action = SyncUpdate
+				if moved {
+					action.Reason = "moved from " + localPath
+				}
+			}
+		}
+		plan.add(action)
+
+		if options.DryRun || (action.Action != SyncCreate && action.Action != SyncUpdate) {
+			return nil
+		}
+		target := filepath.Join(options.Dir, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, FormatPageFile(frontMatter, markdown), 0o644); err != nil {
+			return err
+		}
+		if localPath != relPath {
+			os.Remove(filepath.Join(options.Dir, filepath.FromSlash(localPath)))
+		}
+		state.Pages[page.ID] = &SyncedPage{Path: relPath, Version: frontMatter.Version, Hash: contentHash(markdown)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Pages that left the tree keep their local file; the user decides whether to delete it
+	for _, id := range sortedPageIDs(state.Pages) {
+		if !seen[id] {
+			plan.add(SyncAction{Action: SyncSkip, Path: state.Pages[id].Path, ID: id, Reason: "page is no longer part of the tree; local file kept"})
+		}
+	}
+
+	if options.DryRun {
+		return plan, nil
+	}
+	state.Site = cfg.SiteName()
+	state.RootPageID = rootID
+	if tree.Page.Space != nil {
+		state.SpaceKey = tree.Page.Space.Key
+	}
+	if err := os.MkdirAll(options.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	return plan, state.Save(options.Dir)
+}
+
+func sortedPageIDs(pages map[string]*SyncedPage) []string {
+	ids := make([]string, 0, len(pages))
+	for id := range pages {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// localPage is a Markdown file found in a synced directory
+type localPage struct {
+	relPath     string
+	frontMatter *PageFrontMatter
+	body        string
+}
+
+// Push creates and updates pages from a directory of Markdown files. Top-level files become
+// children of the parent page, files in "<name>/" children of the page in "<name>.md".
+func Push(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, options PushOptions) (*SyncPlan, error) {
+	state, err := LoadSyncState(options.Dir)
+	if err != nil {
+		return nil, err
+	}
+	spaceKey := firstNonEmpty(options.SpaceKey, state.SpaceKey, cfg.DefaultSpace)
+	if spaceKey == "" {
+		return nil, fmt.Errorf("no space: pass a space key or push a directory that was pulled before")
+	}
+	parentID := firstNonEmpty(options.ParentID, state.ParentID)
+
+	// Read every file up front so links can be resolved to page titles
+	pages := make(map[string]*localPage)
+	err = filepath.WalkDir(options.Dir, func(file string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".") && file != options.Dir {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(file), ".md") {
+			return nil
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		frontMatter, body, err := ParsePageFile(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		rel, _ := filepath.Rel(options.Dir, file)
+		rel = filepath.ToSlash(rel)
+		if frontMatter.Title == "" {
+			frontMatter.Title = strings.TrimSuffix(path.Base(rel), path.Ext(rel))
+		}
+		pages[rel] = &localPage{relPath: rel, frontMatter: frontMatter, body: body}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &pusher{
+		ctx:     ctx,
+		client:  client,
+		options: options,
+		state:   state,
+		space:   spaceKey,
+		pages:   pages,
+		plan:    &SyncPlan{Direction: "push", Dir: options.Dir, DryRun: options.DryRun, Summary: make(map[string]int)},
+	}
+	if err := p.pushDir("", parentID); err != nil {
+		return nil, err
+	}
+
+	if options.DryRun {
+		return p.plan, nil
+	}
+	state.Site = cfg.SiteName()
+	state.SpaceKey = spaceKey
+	state.ParentID = parentID
+	return p.plan, state.Save(options.Dir)
+}
+
+type pusher struct {
+	ctx     context.Context
+	client  *confluence.Client
+	options PushOptions
+	state   *SyncState
+	space   string
+	pages   map[string]*localPage
+	plan    *SyncPlan
+}
+
+// pushDir pushes the files of one directory under parentID, then recurses into their folders
+func (p *pusher) pushDir(dir, parentID string) error {
+	entries, err := os.ReadDir(filepath.Join(p.options.Dir, filepath.FromSlash(dir)))
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if entry.IsDir() {
+			names[entry.Name()] = true
+		} else if strings.EqualFold(filepath.Ext(entry.Name()), ".md") {
+			names[strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	for _, name := range sorted {
+		relPath := path.Join(dir, name+".md")
+		page, ok := p.pages[relPath]
+		if !ok {
+			// A folder without a page file gets an empty page so its children have a parent
+			page = &localPage{relPath: relPath, frontMatter: &PageFrontMatter{Title: name}}
+			p.pages[relPath] = page
+		}
+
+		pageID, err := p.pushPage(page, parentID)
+		if err != nil {
+			return err
+		}
+
+		folder := filepath.Join(p.options.Dir, filepath.FromSlash(path.Join(dir, name)))
+		if info, err := os.Stat(folder); err == nil && info.IsDir() {
+			if err := p.pushDir(path.Join(dir, name), pageID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pushPage creates or updates the page of one file and returns its ID
+func (p *pusher) pushPage(page *localPage, parentID string) (string, error) {
+	frontMatter := page.frontMatter
+	action := SyncAction{Path: page.relPath, ID: frontMatter.ID, Title: frontMatter.Title}
+
+	storage := MarkdownToStorage(page.body, &StorageOptions{
+		PageTitle: func(target string) (string, bool) {
+			resolved := path.Clean(path.Join(path.Dir(page.relPath), target))
+			if linked, ok := p.pages[resolved]; ok {
+				return linked.frontMatter.Title, true
+			}
+			return "", false
+		},
+	})
+
+	if frontMatter.ID == "" {
+		action.Action = SyncCreate
+		if p.options.DryRun {
+			p.plan.add(action)
+			return "", nil
+		}
+
+		payload := &models.ContentScheme{
+			Type:  "page",
+			Title: frontMatter.Title,
+			Space: &models.SpaceScheme{Key: p.space},
+			Body:  &models.BodyScheme{Storage: &models.BodyNodeScheme{Value: storage, Representation: "storage"}},
+		}
+		if parentID != "" {
+			payload.Ancestors = []*models.ContentScheme{{ID: parentID}}
+		}
+		created, response, err := p.client.Content.Create(p.ctx, payload)
+		if err != nil {
+			return "", ResponseError(fmt.Sprintf("failed to create %s", page.relPath), response, err)
+		}
+		frontMatter.ID = created.ID
+		if created.Version != nil {
+			frontMatter.Version = created.Version.Number
+		}
+		action.ID, action.Version = created.ID, frontMatter.Version
+		p.plan.add(action)
+		return created.ID, p.record(page, parentID)
+	}
+
+	synced := p.state.Pages[frontMatter.ID]
+	moved := frontMatter.Parent != parentID && parentID != ""
+	if synced != nil && synced.Path == page.relPath && synced.Hash == contentHash(page.body) && !moved {
+		action.Action = SyncUnchanged
+		action.Version = frontMatter.Version
+		p.plan.add(action)
+		return frontMatter.ID, nil
+	}
+
+	remote, response, err := p.client.Content.Get(p.ctx, frontMatter.ID, []string{"version"}, 0)
+	if err != nil {
+		return "", ResponseError(fmt.Sprintf("failed to get page %s for %s", frontMatter.ID, page.relPath), response, err)
+	}
+	remoteVersion := 0
+	if remote.Version != nil {
+		remoteVersion = remote.Version.Number
+	}
+	if remoteVersion != frontMatter.Version && !p.options.Force {
+		action.Action = SyncConflict
+		action.Version = remoteVersion
+		action.Reason = fmt.Sprintf("Confluence has version %d but the file is based on version %d; pull first or push with --force", remoteVersion, frontMatter.Version)
+		p.plan.add(action)
+		return frontMatter.ID, nil
+	}
+
+	action.Action = SyncUpdate
+	action.Version = remoteVersion + 1
+	if moved {
+		action.Reason = "moved under page " + parentID
+	}
+	if p.options.DryRun {
+		p.plan.add(action)
+		return frontMatter.ID, nil
+	}
+
+	payload := &models.ContentScheme{
+		ID:      frontMatter.ID,
+		Type:    "page",
+		Title:   frontMatter.Title,
+		Space:   &models.SpaceScheme{Key: p.space},
+		Body:    &models.BodyScheme{Storage: &models.BodyNodeScheme{Value: storage, Representation: "storage"}},
+		Version: &models.ContentVersionScheme{Number: remoteVersion + 1},
+	}
+	if parentID != "" {
+		payload.Ancestors = []*models.ContentScheme{{ID: parentID}}
+	}
+	updated, response, err := p.client.Content.Update(p.ctx, frontMatter.ID, payload)
+	if err != nil {
+		return "", ResponseError(fmt.Sprintf("failed to update %s", page.relPath), response, err)
+	}
+	frontMatter.Version = remoteVersion + 1
+	if updated.Version != nil {
+		frontMatter.Version = updated.Version.Number
+	}
+	p.plan.add(action)
+	return frontMatter.ID, p.record(page, parentID)
+}
+
+// record writes the updated front matter back to the file and stores the synced state
+func (p *pusher) record(page *localPage, parentID string) error {
+	page.frontMatter.Parent = parentID
+	page.frontMatter.Space = p.space
+
+	data := FormatPageFile(page.frontMatter, page.body)
+	file := filepath.Join(p.options.Dir, filepath.FromSlash(page.relPath))
+	if existing, err := os.ReadFile(file); err != nil || !bytes.Equal(existing, data) {
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			return err
+		}
+	}
+	p.state.Pages[page.frontMatter.ID] = &SyncedPage{Path: page.relPath, Version: page.frontMatter.Version, Hash: contentHash(page.body)}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
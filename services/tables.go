@@ -0,0 +1,470 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PageTable is a table of a page with a row object per data row, keyed by header. Merged cells
+// repeat their value in every row and column they cover.
+type PageTable struct {
+	Index       int                 `json:"index" yaml:"index"`
+	Section     string              `json:"section,omitempty" yaml:"section,omitempty"`
+	Headers     []string            `json:"headers" yaml:"headers"`
+	HeaderRows  int                 `json:"header_rows" yaml:"header_rows"`
+	RowCount    int                 `json:"row_count" yaml:"row_count"`
+	Rows        []map[string]string `json:"rows" yaml:"rows"`
+	MergedCells []MergedCell        `json:"merged_cells,omitempty" yaml:"merged_cells,omitempty"`
+}
+
+// MergedCell is a data cell spanning several rows or columns, by data row and column index
+type MergedCell struct {
+	Row     int `json:"row" yaml:"row"`
+	Column  int `json:"column" yaml:"column"`
+	Rows    int `json:"rows" yaml:"rows"`
+	Columns int `json:"columns" yaml:"columns"`
+}
+
+// tableCell is a th or td element, with the position of its content in the body
+type tableCell struct {
+	header           bool
+	start, end       int
+	rowspan, colspan int
+	row, col         int
+}
+
+type tableRow struct {
+	start, end int
+	inHead     bool
+	cells      []*tableCell
+}
+
+// storageTable is a table with the positions of its rows and cells in the body
+type storageTable struct {
+	start, end int
+	rows       []*tableRow
+	grid       [][]*tableCell
+	headerRows int
+	headers    []string
+	body       string
+}
+
+// scanTables finds the tables of a storage format body with the offsets of their rows and cells,
+// so cells can be replaced without touching the rest of the markup. Nested tables are separate
+// tables, listed after the table that contains them.
+func scanTables(body string) ([]*storageTable, error) {
+	const prefix = `<root xmlns:ac="ac" xmlns:ri="ri">`
+	decoder := xml.NewDecoder(strings.NewReader(prefix + body + `</root>`))
+	decoder.Strict = false
+	decoder.AutoClose = voidElements
+	decoder.Entity = xml.HTMLEntity
+
+	var tables, open []*storageTable
+	var inHead bool
+	for {
+		before := int(decoder.InputOffset()) - len(prefix)
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		after := int(decoder.InputOffset()) - len(prefix)
+
+		var table *storageTable
+		if len(open) > 0 {
+			table = open[len(open)-1]
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch name := qualifiedName(t.Name); name {
+			case "table":
+				table = &storageTable{start: before, body: body}
+				tables = append(tables, table)
+				open = append(open, table)
+			case "thead":
+				inHead = true
+			case "tr":
+				if table != nil {
+					table.rows = append(table.rows, &tableRow{start: before, inHead: inHead})
+				}
+			case "th", "td":
+				if table != nil && len(table.rows) > 0 {
+					row := table.rows[len(table.rows)-1]
+					cell := &tableCell{header: name == "th", start: after, rowspan: 1, colspan: 1}
+					for _, a := range t.Attr {
+						n, err := strconv.Atoi(strings.TrimSpace(a.Value))
+						if err != nil || n < 1 {
+							continue
+						}
+						switch strings.ToLower(a.Name.Local) {
+						case "rowspan":
+							cell.rowspan = n
+						case "colspan":
+							cell.colspan = n
+						}
+					}
+					row.cells = append(row.cells, cell)
+				}
+			}
+		case xml.EndElement:
+			switch qualifiedName(t.Name) {
+			case "table":
+				if table != nil {
+					table.end = after
+					open = open[:len(open)-1]
+				}
+			case "thead":
+				inHead = false
+			case "tr":
+				if table != nil && len(table.rows) > 0 {
+					table.rows[len(table.rows)-1].end = after
+				}
+			case "th", "td":
+				if table != nil && len(table.rows) > 0 {
+					if cells := table.rows[len(table.rows)-1].cells; len(cells) > 0 {
+						cells[len(cells)-1].end = before
+					}
+				}
+			}
+		}
+	}
+
+	for _, table := range tables {
+		table.layout()
+	}
+	return tables, nil
+}
+
+// layout places the cells on a grid, following their row and column spans, and reads the headers
+func (t *storageTable) layout() {
+	width := 0
+	for r, row := range t.rows {
+		for len(t.grid) <= r {
+			t.grid = append(t.grid, nil)
+		}
+		col := 0
+		for _, cell := range row.cells {
+			for col < len(t.grid[r]) && t.grid[r][col] != nil {
+				col++
+			}
+			cell.row, cell.col = r, col
+			for dr := 0; dr < cell.rowspan && r+dr < len(t.rows); dr++ {
+				for len(t.grid) <= r+dr {
+					t.grid = append(t.grid, nil)
+				}
+				for dc := 0; dc < cell.colspan; dc++ {
+					for len(t.grid[r+dr]) <= col+dc {
+						t.grid[r+dr] = append(t.grid[r+dr], nil)
+					}
+					t.grid[r+dr][col+dc] = cell
+				}
+			}
+			col += cell.colspan
+		}
+		width = max(width, len(t.grid[r]))
+	}
+
+	// Header rows are the rows of the thead, or the leading rows made only of th cells
+	for _, row := range t.rows {
+		header := row.inHead || len(row.cells) > 0
+		for _, cell := range row.cells {
+			header = header && (row.inHead || cell.header)
+		}
+		if !header {
+			break
+		}
+		t.headerRows++
+	}
+	if t.headerRows == len(t.rows) {
+		t.headerRows = 0
+	}
+
+	seen := map[string]int{}
+	for c := 0; c < width; c++ {
+		var parts []string
+		var last *tableCell
+		for r := 0; r < t.headerRows; r++ {
+			cell := t.cell(r, c)
+			if cell == nil || cell == last {
+				continue
+			}
+			last = cell
+			if text := strings.ReplaceAll(t.text(cell), "\n", " "); text != "" {
+				parts = append(parts, text)
+			}
+		}
+		header := strings.Join(parts, " / ")
+		if header == "" {
+			header = fmt.Sprintf("Column %d", c+1)
+		}
+		if seen[strings.ToLower(header)]++; seen[strings.ToLower(header)] > 1 {
+			header = fmt.Sprintf("%s (%d)", header, seen[strings.ToLower(header)])
+		}
+		t.headers = append(t.headers, header)
+	}
+}
+
+func (t *storageTable) cell(row, col int) *tableCell {
+	if row < 0 || row >= len(t.grid) || col < 0 || col >= len(t.grid[row]) {
+		return nil
+	}
+	return t.grid[row][col]
+}
+
+// text returns the readable text of a cell
+func (t *storageTable) text(cell *tableCell) string {
+	root, err := parseStorage(t.body[cell.start:cell.end])
+	if err != nil {
+		return strings.TrimSpace(html.UnescapeString(tagPattern.ReplaceAllString(t.body[cell.start:cell.end], "")))
+	}
+	return nodeText(root)
+}
+
+// pageTable converts the table to its row objects
+func (t *storageTable) pageTable(index int) *PageTable {
+	table := &PageTable{Index: index, Headers: t.headers, HeaderRows: t.headerRows, Rows: []map[string]string{}}
+	for r := t.headerRows; r < len(t.grid); r++ {
+		row := map[string]string{}
+		for c, header := range t.headers {
+			if cell := t.cell(r, c); cell != nil {
+				row[header] = t.text(cell)
+				if cell.row == r && cell.col == c && (cell.rowspan > 1 || cell.colspan > 1) {
+					table.MergedCells = append(table.MergedCells, MergedCell{Row: r - t.headerRows, Column: c, Rows: cell.rowspan, Columns: cell.colspan})
+				}
+			}
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	table.RowCount = len(table.Rows)
+	return table
+}
+
+// ParseTables returns the tables of a storage format body, each with the path of the section it is in
+func ParseTables(body string) ([]*PageTable, error) {
+	tables, err := scanTables(body)
+	if err != nil {
+		return nil, err
+	}
+	outline := OutlineStorage(body)
+	result := make([]*PageTable, len(tables))
+	for i, t := range tables {
+		result[i] = t.pageTable(i)
+		walkOutline(outline, func(s *OutlineSection) {
+			if s.Start() <= t.start && t.start < s.End() {
+				result[i].Section = s.Path
+			}
+		})
+	}
+	return result, nil
+}
+
+// TableCellUpdate sets a cell. Row is a data row index (0 is the first row below the headers) or
+// "Key=Value" for the first row whose Key column has that value; Column is a header or an index.
+type TableCellUpdate struct {
+	Row    interface{} `json:"row"`
+	Column interface{} `json:"column"`
+	Value  string      `json:"value"`
+}
+
+// TableEdit describes changes to one table of a page
+type TableEdit struct {
+	Table      int    // index of the table
+	WithColumn string // selects the first table with this header instead of by index
+	Updates    []TableCellUpdate
+	Append     []map[string]string // rows to add at the end, by header
+	Storage    bool                // values are storage format instead of text
+}
+
+// TableEditResult summarizes the changes made to a table
+type TableEditResult struct {
+	Table        int      `json:"table" yaml:"table"`
+	Headers      []string `json:"headers" yaml:"headers"`
+	CellsUpdated int      `json:"cells_updated" yaml:"cells_updated"`
+	RowsAppended int      `json:"rows_appended" yaml:"rows_appended"`
+}
+
+type bodyEdit struct {
+	start, end int
+	text       string
+}
+
+// EditTable applies changes to a table and returns the new body. Only the content of the edited
+// cells and the appended rows change; the rest of the markup is kept as it is.
+func EditTable(body string, edit TableEdit) (string, *TableEditResult, error) {
+	tables, err := scanTables(body)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(tables) == 0 {
+		return "", nil, fmt.Errorf("the page has no tables")
+	}
+
+	index := edit.Table
+	if edit.WithColumn != "" {
+		index = -1
+		for i, t := range tables {
+			if _, ok := t.column(edit.WithColumn); ok {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return "", nil, fmt.Errorf("no table has a column %q", edit.WithColumn)
+		}
+	}
+	if index < 0 || index >= len(tables) {
+		return "", nil, fmt.Errorf("table %d does not exist; the page has %d tables", index, len(tables))
+	}
+	table := tables[index]
+	result := &TableEditResult{Table: index, Headers: table.headers}
+
+	var edits []bodyEdit
+	updated := map[*tableCell]bool{}
+	for _, update := range edit.Updates {
+		row, err := table.row(update.Row)
+		if err != nil {
+			return "", nil, err
+		}
+		col, ok := table.column(fmt.Sprint(update.Column))
+		if !ok {
+			return "", nil, fmt.Errorf("table %d has no column %v; columns are: %s", index, update.Column, strings.Join(table.headers, ", "))
+		}
+		cell := table.cell(row, col)
+		if cell == nil {
+			return "", nil, fmt.Errorf("row %v of table %d has no cell in column %v", update.Row, index, update.Column)
+		}
+		if updated[cell] {
+			return "", nil, fmt.Errorf("the cell at row %v, column %v is changed twice (merged cells count once)", update.Row, update.Column)
+		}
+		updated[cell] = true
+		edits = append(edits, bodyEdit{cell.start, cell.end, cellContent(body[cell.start:cell.end], update.Value, edit.Storage)})
+		result.CellsUpdated++
+	}
+
+	if len(edit.Append) > 0 {
+		if len(table.rows) == 0 {
+			return "", nil, fmt.Errorf("table %d has no rows to append to", index)
+		}
+		rows, err := table.newRows(edit.Append, edit.Storage)
+		if err != nil {
+			return "", nil, err
+		}
+		last := table.rows[len(table.rows)-1]
+		edits = append(edits, bodyEdit{last.end, last.end, rows})
+		result.RowsAppended = len(edit.Append)
+	}
+
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		body = body[:e.start] + e.text + body[e.end:]
+	}
+	if _, err := parseStorage(body); err != nil {
+		return "", nil, fmt.Errorf("the edited page would not be valid storage format: %w", err)
+	}
+	return body, result, nil
+}
+
+// column finds a column by header, compared without case, or by index
+func (t *storageTable) column(name string) (int, bool) {
+	name = strings.TrimSpace(name)
+	for i, header := range t.headers {
+		if strings.EqualFold(header, name) {
+			return i, true
+		}
+	}
+	if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < len(t.headers) {
+		return i, true
+	}
+	return 0, false
+}
+
+// row finds a grid row by data row index or by a "Key=Value" match
+func (t *storageTable) row(selector interface{}) (int, error) {
+	text := strings.TrimSpace(fmt.Sprint(selector))
+	if key, value, ok := strings.Cut(text, "="); ok {
+		col, found := t.column(key)
+		if !found {
+			return 0, fmt.Errorf("no column %q to select a row by; columns are: %s", key, strings.Join(t.headers, ", "))
+		}
+		for r := t.headerRows; r < len(t.grid); r++ {
+			if cell := t.cell(r, col); cell != nil && strings.EqualFold(t.text(cell), strings.TrimSpace(value)) {
+				return r, nil
+			}
+		}
+		return 0, fmt.Errorf("no row has %s", text)
+	}
+	i, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid row %q: use a row index or Key=Value", text)
+	}
+	if i < 0 || t.headerRows+i >= len(t.grid) {
+		return 0, fmt.Errorf("row %d does not exist; the table has %d rows below its headers", i, len(t.grid)-t.headerRows)
+	}
+	return t.headerRows + i, nil
+}
+
+// newRows renders rows to append, with a cell per column and the cell type of the last row
+func (t *storageTable) newRows(rows []map[string]string, storage bool) (string, error) {
+	lastRow := t.rows[len(t.rows)-1]
+	paragraphs := false
+	for _, cell := range lastRow.cells {
+		paragraphs = paragraphs || strings.HasPrefix(strings.TrimSpace(t.body[cell.start:cell.end]), "<p")
+	}
+
+	var b strings.Builder
+	for _, values := range rows {
+		cells := make([]string, len(t.headers))
+		for c := range cells {
+			cells[c] = formatCell("", storage, paragraphs)
+		}
+		for key, value := range values {
+			col, ok := t.column(key)
+			if !ok {
+				return "", fmt.Errorf("no column %q for the new row; columns are: %s", key, strings.Join(t.headers, ", "))
+			}
+			cells[col] = formatCell(value, storage, paragraphs)
+		}
+		b.WriteString("<tr>")
+		for c, content := range cells {
+			tag := "td"
+			if cell := t.cell(len(t.grid)-1, c); cell != nil && cell.header {
+				tag = "th"
+			}
+			b.WriteString("<" + tag + ">" + content + "</" + tag + ">")
+		}
+		b.WriteString("</tr>")
+	}
+	return b.String(), nil
+}
+
+// cellContent renders a new cell value, wrapped in a paragraph when the old content was
+func cellContent(old, value string, storage bool) string {
+	return formatCell(value, storage, strings.HasPrefix(strings.TrimSpace(old), "<p"))
+}
+
+func formatCell(value string, storage, paragraph bool) string {
+	if storage {
+		return value
+	}
+	if value == "" {
+		if paragraph {
+			return "<p />"
+		}
+		return ""
+	}
+	lines := strings.Split(value, "\n")
+	for i, line := range lines {
+		lines[i] = html.EscapeString(line)
+	}
+	if paragraph {
+		return "<p>" + strings.Join(lines, "</p><p>") + "</p>"
+	}
+	return strings.Join(lines, "<br />")
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const tablesBody = `<h1>Team</h1>` +
+	`<p>Intro &amp; context&nbsp;here</p>` +
+	`<table data-layout="default"><colgroup><col /><col /></colgroup>` +
+	`<thead><tr><th><p>Name</p></th><th><p>Role</p></th></tr></thead>` +
+	`<tbody>` +
+	`<tr><td><p>Alice &amp; Co</p></td><td><p>Lead</p></td></tr>` +
+	`<tr><td><p>Bob</p></td><td><p>Dev</p>` +
+	`<table><tbody><tr><th>Tool</th></tr><tr><td>Go</td></tr></tbody></table>` +
+	`</td></tr>` +
+	`</tbody></table>` +
+	`<h2>Rota</h2>` +
+	`<table><tbody>` +
+	`<tr><th>Week</th><th>Mon</th><th>Tue</th></tr>` +
+	`<tr><td rowspan="2">1</td><td colspan="2">Alice</td></tr>` +
+	`<tr><td>Bob</td><td>Carol</td></tr>` +
+	`</tbody></table>` +
+	`<p>Outro</p>`
+
+func parseTestTables(t *testing.T, body string) []*PageTable {
+	t.Helper()
+	tables, err := ParseTables(body)
+	if err != nil {
+		t.Fatalf("ParseTables() error = %v", err)
+	}
+	return tables
+}
+
+func editTestTable(t *testing.T, edit TableEdit) (string, *TableEditResult) {
+	t.Helper()
+	body, result, err := EditTable(tablesBody, edit)
+	if err != nil {
+		t.Fatalf("EditTable() error = %v", err)
+	}
+	return body, result
+}
+
+func TestParseTables(t *testing.T) {
+	tables := parseTestTables(t, tablesBody)
+	if len(tables) != 3 {
+		t.Fatalf("got %d tables, want 3", len(tables))
+	}
+
+	team := tables[0]
+	if !reflect.DeepEqual(team.Headers, []string{"Name", "Role"}) || team.HeaderRows != 1 {
+		t.Errorf("thead: headers %q, %d header rows", team.Headers, team.HeaderRows)
+	}
+	if team.Section != "Team" {
+		t.Errorf("section = %q, want Team", team.Section)
+	}
+	if got := team.Rows[0]["Name"]; got != "Alice & Co" {
+		t.Errorf("entity in cell read as %q, want %q", got, "Alice & Co")
+	}
+
+	// A nested table is listed right after the table it is in
+	nested := tables[1]
+	if !reflect.DeepEqual(nested.Headers, []string{"Tool"}) || nested.RowCount != 1 || nested.Rows[0]["Tool"] != "Go" {
+		t.Errorf("nested table = %+v", nested)
+	}
+
+	rota := tables[2]
+	if rota.Section != "Team > Rota" {
+		t.Errorf("section = %q, want Team > Rota", rota.Section)
+	}
+	wantRows := []map[string]string{
+		{"Week": "1", "Mon": "Alice", "Tue": "Alice"},
+		{"Week": "1", "Mon": "Bob", "Tue": "Carol"},
+	}
+	if !reflect.DeepEqual(rota.Rows, wantRows) {
+		t.Errorf("rowspan/colspan rows = %v, want %v", rota.Rows, wantRows)
+	}
+	wantMerged := []MergedCell{{Row: 0, Column: 0, Rows: 2, Columns: 1}, {Row: 0, Column: 1, Rows: 1, Columns: 2}}
+	if !reflect.DeepEqual(rota.MergedCells, wantMerged) {
+		t.Errorf("merged cells = %v, want %v", rota.MergedCells, wantMerged)
+	}
+}
+
+func TestEditTableCellByHeader(t *testing.T) {
+	body, result := editTestTable(t, TableEdit{Updates: []TableCellUpdate{{Row: 0, Column: "role", Value: "R&D <ops>"}}})
+	if result.Table != 0 || result.CellsUpdated != 1 {
+		t.Errorf("result = %+v", result)
+	}
+	if !strings.Contains(body, `<td><p>R&amp;D &lt;ops&gt;</p></td>`) {
+		t.Errorf("new value is not escaped inside the paragraph of the cell:\n%s", body)
+	}
+	if got := parseTestTables(t, body)[0].Rows[0]; got["Role"] != "R&D <ops>" || got["Name"] != "Alice & Co" {
+		t.Errorf("row 0 = %v", got)
+	}
+}
+
+func TestEditTableRowByKey(t *testing.T) {
+	body, _ := editTestTable(t, TableEdit{Updates: []TableCellUpdate{{Row: "name=alice & co", Column: 1, Value: "Manager"}}})
+	rows := parseTestTables(t, body)[0].Rows
+	if rows[0]["Role"] != "Manager" || !strings.HasPrefix(rows[1]["Role"], "Dev") {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestEditTableWithColumn(t *testing.T) {
+	body, result := editTestTable(t, TableEdit{Table: 2, WithColumn: "Tool", Updates: []TableCellUpdate{{Row: 0, Column: "Tool", Value: "Rust"}}})
+	if result.Table != 1 {
+		t.Errorf("WithColumn selected table %d, want the nested table 1", result.Table)
+	}
+	tables := parseTestTables(t, body)
+	if tables[1].Rows[0]["Tool"] != "Rust" {
+		t.Errorf("nested table rows = %v", tables[1].Rows)
+	}
+	if !strings.Contains(body, `<td><p>Bob</p></td><td><p>Dev</p><table>`) {
+		t.Errorf("the cell around the nested table changed:\n%s", body)
+	}
+}
+
+func TestEditTableMergedCell(t *testing.T) {
+	body, _ := editTestTable(t, TableEdit{Table: 2, Updates: []TableCellUpdate{{Row: 0, Column: "Tue", Value: "Dave"}}})
+	if !strings.Contains(body, `<td colspan="2">Dave</td>`) {
+		t.Errorf("colspan was not kept:\n%s", body)
+	}
+	if row := parseTestTables(t, body)[2].Rows[0]; row["Mon"] != "Dave" || row["Tue"] != "Dave" {
+		t.Errorf("merged cell row = %v, want Dave in both columns", row)
+	}
+}
+
+func TestEditTableAppendRows(t *testing.T) {
+	body, result := editTestTable(t, TableEdit{Append: []map[string]string{{"Name": "Eve & Mallory"}, {"name": "Zoe", "Role": "QA"}}})
+	if result.RowsAppended != 2 {
+		t.Errorf("rows appended = %d, want 2", result.RowsAppended)
+	}
+	team := parseTestTables(t, body)[0]
+	if team.RowCount != 4 {
+		t.Fatalf("row count = %d, want 4", team.RowCount)
+	}
+	if got := team.Rows[2]; got["Name"] != "Eve & Mallory" || got["Role"] != "" {
+		t.Errorf("row 2 = %v", got)
+	}
+	if got := team.Rows[3]; got["Name"] != "Zoe" || got["Role"] != "QA" {
+		t.Errorf("row 3 = %v", got)
+	}
+	// New cells follow the last row: paragraphs inside the tbody
+	if !strings.Contains(body, `<tr><td><p>Eve &amp; Mallory</p></td><td><p /></td></tr><tr><td><p>Zoe</p></td><td><p>QA</p></td></tr></tbody></table><h2>`) {
+		t.Errorf("appended rows are not at the end of the tbody:\n%s", body)
+	}
+}
+
+func TestEditTableAppendStorage(t *testing.T) {
+	body, _ := editTestTable(t, TableEdit{Table: 2, Append: []map[string]string{{"Week": "2", "Mon": "<strong>Eve</strong>"}}, Storage: true})
+	if !strings.Contains(body, `<tr><td>2</td><td><strong>Eve</strong></td><td></td></tr></tbody>`) {
+		t.Errorf("storage values were not inserted as markup:\n%s", body)
+	}
+}
+
+func TestEditTableKeepsMarkup(t *testing.T) {
+	// Markup the storage parser would normalise: entities, attribute quoting, self-closing tags and CDATA
+	const before = `<p class='x'>a&nbsp;&#169; b<br/></p>` +
+		`<ac:structured-macro ac:name="code"><ac:plain-text-body><![CDATA[<table><tr><td>no</td></tr></table>]]></ac:plain-text-body></ac:structured-macro>` +
+		`<table ><tbody><tr><th>Key</th><th>Value</th></tr><tr><td>a</td><td>`
+	const after = `</td></tr></tbody></table><p>&hellip;</p>`
+	body, _, err := EditTable(before+"old"+after, TableEdit{Updates: []TableCellUpdate{{Row: "Key=a", Column: "Value", Value: "new"}}})
+	if err != nil {
+		t.Fatalf("EditTable() error = %v", err)
+	}
+	if body != before+"new"+after {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestEditTableErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		edit TableEdit
+		want string
+	}{
+		{"unknown table", TableEdit{Table: 5}, "table 5 does not exist"},
+		{"unknown column", TableEdit{Updates: []TableCellUpdate{{Row: 0, Column: "Age", Value: "1"}}}, "no column Age"},
+		{"unknown row", TableEdit{Updates: []TableCellUpdate{{Row: "Name=Zoe", Column: "Role", Value: "x"}}}, "no row has Name=Zoe"},
+		{"row out of range", TableEdit{Updates: []TableCellUpdate{{Row: 2, Column: "Role", Value: "x"}}}, "row 2 does not exist"},
+		{"unknown column in new row", TableEdit{Append: []map[string]string{{"Age": "1"}}}, `no column "Age"`},
+		{"merged cell changed twice", TableEdit{Table: 2, Updates: []TableCellUpdate{{Row: 0, Column: "Mon", Value: "a"}, {Row: 0, Column: "Tue", Value: "b"}}}, "changed twice"},
+		{"invalid storage", TableEdit{Updates: []TableCellUpdate{{Row: 0, Column: "Role", Value: "</p></td></tr></tbody></table><table>"}}, Storage: true}, "not be valid storage format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := EditTable(tablesBody, tt.edit)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("EditTable() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// Task statuses as stored in ac:task-status
+const (
+	TaskComplete   = "complete"
+	TaskIncomplete = "incomplete"
+)
+
+// Task is an inline task (ac:task) of a page
+type Task struct {
+	ID          string   `json:"id" yaml:"id"`
+	Status      string   `json:"status" yaml:"status"`
+	Text        string   `json:"text" yaml:"text"`
+	Assignees   []string `json:"assignees,omitempty" yaml:"assignees,omitempty"`
+	AssigneeIDs []string `json:"assignee_ids,omitempty" yaml:"assignee_ids,omitempty"`
+	Due         string   `json:"due,omitempty" yaml:"due,omitempty"`
+	PageID      string   `json:"page_id,omitempty" yaml:"page_id,omitempty"`
+	PageTitle   string   `json:"page_title,omitempty" yaml:"page_title,omitempty"`
+	PageURL     string   `json:"page_url,omitempty" yaml:"page_url,omitempty"`
+
+	assignees              []UserRef
+	hasStatus              bool
+	statusStart, statusEnd int
+	bodyStart, bodyEnd     int
+}
+
+// ParseTasks returns the tasks of a storage format body, subtasks included, in page order
+func ParseTasks(body string) ([]*Task, error) {
+	const prefix = `<root xmlns:ac="ac" xmlns:ri="ri">`
+	decoder := xml.NewDecoder(strings.NewReader(prefix + body + `</root>`))
+	decoder.Strict = false
+	decoder.AutoClose = voidElements
+	decoder.Entity = xml.HTMLEntity
+
+	var tasks, open []*Task
+	var elements []string
+	for {
+		before := int(decoder.InputOffset()) - len(prefix)
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		after := int(decoder.InputOffset()) - len(prefix)
+
+		var task *Task
+		if len(open) > 0 {
+			task = open[len(open)-1]
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := qualifiedName(t.Name)
+			elements = append(elements, name)
+			switch name {
+			case "ac:task":
+				task = &Task{}
+				tasks = append(tasks, task)
+				open = append(open, task)
+			case "ac:task-status":
+				if task != nil {
+					task.hasStatus = true
+					task.statusStart, task.statusEnd = after, after
+				}
+			case "ac:task-body":
+				if task != nil {
+					task.bodyStart = after
+				}
+			}
+		case xml.EndElement:
+			if len(elements) > 0 {
+				elements = elements[:len(elements)-1]
+			}
+			switch qualifiedName(t.Name) {
+			case "ac:task":
+				if len(open) > 0 {
+					open = open[:len(open)-1]
+				}
+			case "ac:task-status":
+				if task != nil {
+					task.statusEnd = before
+					task.Status = strings.TrimSpace(body[task.statusStart:task.statusEnd])
+				}
+			case "ac:task-body":
+				if task != nil {
+					task.bodyEnd = before
+				}
+			}
+		case xml.CharData:
+			if task != nil && len(elements) > 0 && elements[len(elements)-1] == "ac:task-id" {
+				task.ID += strings.TrimSpace(string(t))
+			}
+		}
+	}
+
+	for _, task := range tasks {
+		if task.bodyEnd > task.bodyStart {
+			task.readBody(body[task.bodyStart:task.bodyEnd])
+		}
+	}
+	return tasks, nil
+}
+
+// readBody reads the text, assignees and due date of a task, leaving out its subtasks
+func (t *Task) readBody(markup string) {
+	root, err := parseStorage(markup)
+	if err != nil {
+		t.Text = strings.TrimSpace(tagPattern.ReplaceAllString(markup, ""))
+		return
+	}
+	var prune func(n *storageNode)
+	prune = func(n *storageNode) {
+		children := n.children[:0]
+		for _, c := range n.children {
+			if c.isText || c.name != "ac:task-list" {
+				prune(c)
+				children = append(children, c)
+			}
+		}
+		n.children = children
+	}
+	prune(root)
+
+	var walk func(n *storageNode)
+	walk = func(n *storageNode) {
+		for _, c := range n.children {
+			switch {
+			case c.isText:
+			case c.name == "ri:user":
+				ref := UserRef{AccountID: c.attr("ri:account-id"), UserKey: c.attr("ri:userkey"), Username: c.attr("ri:username")}
+				if ref.ID() != "" {
+					t.assignees = append(t.assignees, ref)
+					t.AssigneeIDs = append(t.AssigneeIDs, ref.ID())
+				}
+			case c.name == "time" && t.Due == "":
+				t.Due = c.attr("datetime")
+			default:
+				walk(c)
+			}
+		}
+	}
+	walk(root)
+	t.Text = strings.Join(strings.Fields(nodeText(root)), " ")
+}
+
+// SetTaskStatus changes the status of the task with an ID, or of the only task whose text
+// contains the given text, and returns the new body. Only the status of the task changes.
+func SetTaskStatus(body, task, status string) (string, *Task, error) {
+	if status != TaskComplete && status != TaskIncomplete {
+		return "", nil, fmt.Errorf("status must be %s or %s, got %q", TaskComplete, TaskIncomplete, status)
+	}
+	tasks, err := ParseTasks(body)
+	if err != nil {
+		return "", nil, err
+	}
+	found, err := findTask(tasks, task)
+	if err != nil {
+		return "", nil, err
+	}
+	if !found.hasStatus {
+		return "", nil, fmt.Errorf("task %s has no status element", found.ID)
+	}
+	body = body[:found.statusStart] + status + body[found.statusEnd:]
+	found.Status = status
+	return body, found, nil
+}
+
+func findTask(tasks []*Task, reference string) (*Task, error) {
+	reference = strings.TrimSpace(reference)
+	if reference == "" {
+		return nil, fmt.Errorf("a task ID or text is required")
+	}
+	for _, task := range tasks {
+		if task.ID == reference {
+			return task, nil
+		}
+	}
+	var matches []*Task
+	for _, task := range tasks {
+		if strings.Contains(strings.ToLower(task.Text), strings.ToLower(reference)) {
+			matches = append(matches, task)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return nil, fmt.Errorf("no task has the ID or text %q", reference)
+	default:
+		lines := make([]string, len(matches))
+		for i, task := range matches {
+			lines[i] = fmt.Sprintf("%s: %s", task.ID, task.Text)
+		}
+		return nil, fmt.Errorf("%d tasks match %q; use the ID of one of them:\n%s", len(matches), reference, strings.Join(lines, "\n"))
+	}
+}
+
+// TaskQuery selects the tasks to list
+type TaskQuery struct {
+	CQL       string    // pages to read the tasks of
+	Status    string    // complete, incomplete or empty for both
+	Assignee  string    // account ID, username or display name; "me" for the current user
+	DueBefore time.Time // zero for any due date
+	Pages     int       // maximum number of pages to read; 0 means 100
+}
+
+// TaskList is the result of ListTasks. Warnings name the pages whose tasks could not be read
+type TaskList struct {
+	Tasks    []*Task  `json:"tasks" yaml:"tasks"`
+	Warnings []string `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// ListTasks reads the tasks of the pages matching a CQL query
+func ListTasks(ctx context.Context, client *confluence.Client, cfg *AtlassianConfig, query TaskQuery) (*TaskList, *models.ResponseScheme, error) {
+	if query.Pages <= 0 {
+		query.Pages = 100
+	}
+	var me *models.ContentUserScheme
+	if strings.EqualFold(query.Assignee, "me") {
+		user, response, err := CurrentUser(ctx, client)
+		if err != nil {
+			return nil, response, err
+		}
+		me = user
+	}
+	users := NewUserDirectory(client)
+
+	list := &TaskList{Tasks: []*Task{}}
+	var response *models.ResponseScheme
+	read := 0
+	cursor := ""
+	for read < query.Pages {
+		contents, searchResponse, err := client.Content.Search(ctx, query.CQL, "", []string{"body.storage"}, cursor, min(query.Pages-read, 50))
+		if err != nil {
+			return nil, searchResponse, err
+		}
+		response = searchResponse
+		for _, content := range contents.Results {
+			read++
+			pageTasks, err := ParseTasks(ContentBody(content))
+			if err != nil {
+				list.Warnings = append(list.Warnings, fmt.Sprintf("page %s (%s) skipped: %v", content.ID, content.Title, err))
+				continue
+			}
+			for _, task := range pageTasks {
+				if query.Status != "" && task.Status != query.Status {
+					continue
+				}
+				if !query.DueBefore.IsZero() && !task.dueBefore(query.DueBefore) {
+					continue
+				}
+				for _, ref := range task.assignees {
+					task.Assignees = append(task.Assignees, users.Name(ctx, ref))
+				}
+				if query.Assignee != "" && !task.assignedTo(query.Assignee, me) {
+					continue
+				}
+				task.PageID, task.PageTitle = content.ID, content.Title
+				if content.Links != nil {
+					task.PageURL = cfg.WebURL(content.Links.Webui)
+				}
+				list.Tasks = append(list.Tasks, task)
+			}
+		}
+		cursor = nextCursor(contents.Links)
+		if cursor == "" || len(contents.Results) == 0 {
+			break
+		}
+	}
+	return list, response, nil
+}
+
+// dueBefore reports whether the task has a due date before the given day. The
+// datetime of a time element is a date, possibly followed by a time of day
+func (t *Task) dueBefore(day time.Time) bool {
+	if len(t.Due) < len("2006-01-02") {
+		return false
+	}
+	due, err := time.Parse("2006-01-02", t.Due[:len("2006-01-02")])
+	return err == nil && due.Before(day)
+}
+
+func (t *Task) assignedTo(assignee string, me *models.ContentUserScheme) bool {
+	for i, ref := range t.assignees {
+		if me != nil {
+			if ref.Matches(me) {
+				return true
+			}
+			continue
+		}
+		if strings.EqualFold(ref.ID(), assignee) || strings.EqualFold(t.Assignees[i], assignee) {
+			return true
+		}
+	}
+	return false
+}
+
+// CSV renders the tasks with a row per task
+func (l *TaskList) CSV() (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write([]string{"page_title", "page_id", "page_url", "task_id", "status", "text", "assignees", "due"}); err != nil {
+		return "", err
+	}
+	for _, t := range l.Tasks {
+		record := []string{t.PageTitle, t.PageID, t.PageURL, t.ID, t.Status, t.Text, strings.Join(t.Assignees, "; "), t.Due}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	return buf.String(), w.Error()
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const tasksBody = `<p>Plan &amp; actions&nbsp;below</p>` +
+	`<ac:task-list>` +
+	`<ac:task><ac:task-id>1</ac:task-id><ac:task-status>incomplete</ac:task-status>` +
+	`<ac:task-body>Write the R&amp;D report <ac:link><ri:user ri:account-id="abc" /></ac:link> by <time datetime="2024-05-01" />` +
+	`<ac:task-list>` +
+	`<ac:task><ac:task-id>2</ac:task-id><ac:task-status>complete</ac:task-status><ac:task-body>Collect figures</ac:task-body></ac:task>` +
+	`<ac:task><ac:task-id>3</ac:task-id><ac:task-status>incomplete</ac:task-status><ac:task-body>Review &lt;draft&gt;</ac:task-body></ac:task>` +
+	`</ac:task-list>` +
+	`</ac:task-body></ac:task>` +
+	`<ac:task><ac:task-id>4</ac:task-id><ac:task-status>incomplete</ac:task-status><ac:task-body>Send report</ac:task-body></ac:task>` +
+	`</ac:task-list>` +
+	`<p>Done</p>`
+
+// taskStatuses returns the status of every task of a body by ID
+func taskStatuses(t *testing.T, body string) map[string]string {
+	t.Helper()
+	tasks, err := ParseTasks(body)
+	if err != nil {
+		t.Fatalf("ParseTasks() error = %v", err)
+	}
+	statuses := map[string]string{}
+	for _, task := range tasks {
+		statuses[task.ID] = task.Status
+	}
+	return statuses
+}
+
+func TestParseTasks(t *testing.T) {
+	tasks, err := ParseTasks(tasksBody)
+	if err != nil {
+		t.Fatalf("ParseTasks() error = %v", err)
+	}
+	type task struct {
+		ID, Status, Text, Due string
+		AssigneeIDs           []string
+	}
+	want := []task{
+		{ID: "1", Status: TaskIncomplete, Text: "Write the R&D report @abc by 2024-05-01", Due: "2024-05-01", AssigneeIDs: []string{"abc"}},
+		{ID: "2", Status: TaskComplete, Text: "Collect figures"},
+		{ID: "3", Status: TaskIncomplete, Text: "Review <draft>"},
+		{ID: "4", Status: TaskIncomplete, Text: "Send report"},
+	}
+	var got []task
+	for _, t := range tasks {
+		got = append(got, task{ID: t.ID, Status: t.Status, Text: t.Text, Due: t.Due, AssigneeIDs: t.AssigneeIDs})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTasks() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetTaskStatusToggle(t *testing.T) {
+	completed, task, err := SetTaskStatus(tasksBody, "1", TaskComplete)
+	if err != nil {
+		t.Fatalf("SetTaskStatus() error = %v", err)
+	}
+	if task.ID != "1" || task.Status != TaskComplete {
+		t.Errorf("task = %s %s", task.ID, task.Status)
+	}
+	// The subtasks of task 1 keep their own status
+	want := map[string]string{"1": TaskComplete, "2": TaskComplete, "3": TaskIncomplete, "4": TaskIncomplete}
+	if got := taskStatuses(t, completed); !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if len(completed) != len(tasksBody)-2 {
+		t.Errorf("length changed by %d, want -2 (incomplete -> complete)", len(completed)-len(tasksBody))
+	}
+
+	// Toggling back restores the original body, entities and all
+	reverted, _, err := SetTaskStatus(completed, "1", TaskIncomplete)
+	if err != nil {
+		t.Fatalf("SetTaskStatus() error = %v", err)
+	}
+	if reverted != tasksBody {
+		t.Errorf("body after toggling back = %s", reverted)
+	}
+}
+
+func TestSetTaskStatusSubtask(t *testing.T) {
+	body, _, err := SetTaskStatus(tasksBody, "2", TaskIncomplete)
+	if err != nil {
+		t.Fatalf("SetTaskStatus() error = %v", err)
+	}
+	want := map[string]string{"1": TaskIncomplete, "2": TaskIncomplete, "3": TaskIncomplete, "4": TaskIncomplete}
+	if got := taskStatuses(t, body); !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses = %v, want %v", got, want)
+	}
+	if !strings.Contains(body, `<ac:task-id>2</ac:task-id><ac:task-status>incomplete</ac:task-status><ac:task-body>Collect figures</ac:task-body>`) {
+		t.Errorf("subtask markup = %s", body)
+	}
+}
+
+func TestSetTaskStatusByText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		id   string
+	}{
+		{"text with an entity", "review <draft>", "3"},
+		{"case-insensitive", "SEND", "4"},
+		// Task 1 contains task 2, but the text of a task leaves out its subtasks
+		{"subtask text", "figures", "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, task, err := SetTaskStatus(tasksBody, tt.text, TaskComplete)
+			if err != nil {
+				t.Fatalf("SetTaskStatus() error = %v", err)
+			}
+			if task.ID != tt.id {
+				t.Errorf("task %q matched %s, want %s", tt.text, task.ID, tt.id)
+			}
+		})
+	}
+}
+
+func TestSetTaskStatusErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		task   string
+		status string
+		want   string
+	}{
+		{"invalid status", "1", "done", "status must be complete or incomplete"},
+		{"no task", "9", TaskComplete, "no task has the ID or text"},
+		{"several tasks", "report", TaskComplete, "2 tasks match"},
+		{"empty reference", " ", TaskComplete, "a task ID or text is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := SetTaskStatus(tasksBody, tt.task, tt.status)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("SetTaskStatus() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTaskDueBefore(t *testing.T) {
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		due  string
+		want bool
+	}{
+		{"2024-04-30", true},
+		{"2024-05-01", false},
+		{"2024-04-30T09:00:00Z", true},
+		// Only YYYY-MM-DD dates count as due dates
+		{"2024-4-30", false},
+		{"", false},
+		{"soon", false},
+	}
+	for _, tt := range tests {
+		if got := (&Task{Due: tt.due}).dueBefore(day); got != tt.want {
+			t.Errorf("dueBefore(%q) = %v, want %v", tt.due, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// Template kinds
+const (
+	TemplatePage      = "page"
+	TemplateBlueprint = "blueprint"
+)
+
+// Template is a Confluence page template or blueprint, global or of a space
+type Template struct {
+	ID          string             `json:"id" yaml:"id"`
+	Name        string             `json:"name" yaml:"name"`
+	Description string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Kind        string             `json:"kind" yaml:"kind"`
+	SpaceKey    string             `json:"space,omitempty" yaml:"space,omitempty"` // empty for global templates
+	Labels      []string           `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Variables   []TemplateVariable `json:"variables,omitempty" yaml:"variables,omitempty"`
+	Body        string             `json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// TemplateVariable is a variable a template declares or uses (<at:var at:name="..."/>)
+type TemplateVariable struct {
+	Name    string   `json:"name" yaml:"name"`
+	Type    string   `json:"type" yaml:"type"` // string, textarea or list
+	Options []string `json:"options,omitempty" yaml:"options,omitempty"`
+}
+
+type templateScheme struct {
+	TemplateID   string `json:"templateId"`
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	TemplateType string `json:"templateType"`
+	Space        *struct {
+		Key string `json:"key"`
+	} `json:"space"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Body *models.BodyScheme `json:"body"`
+}
+
+type templatePageScheme struct {
+	Results []*templateScheme `json:"results"`
+	Size    int               `json:"size"`
+}
+
+func (t *templateScheme) template(kind string) *Template {
+	result := &Template{ID: t.TemplateID, Name: t.Name, Description: t.Description, Kind: kind}
+	if t.Space != nil {
+		result.SpaceKey = t.Space.Key
+	}
+	for _, label := range t.Labels {
+		result.Labels = append(result.Labels, label.Name)
+	}
+	if t.Body != nil && t.Body.Storage != nil {
+		result.Body = t.Body.Storage.Value
+		result.Variables = TemplateVariables(result.Body)
+	}
+	return result
+}
+
+// ListTemplates lists the page templates, and optionally the blueprints, of a space, or the global
+// ones when spaceKey is empty
+func ListTemplates(ctx context.Context, client *confluence.Client, spaceKey string, blueprints bool) ([]*Template, *models.ResponseScheme, error) {
+	kinds := []string{TemplatePage}
+	if blueprints {
+		kinds = append(kinds, TemplateBlueprint)
+	}
+
+	var templates []*Template
+	var response *models.ResponseScheme
+	for _, kind := range kinds {
+		const limit = 100
+		for start := 0; ; start += limit {
+			params := url.Values{}
+			params.Set("start", strconv.Itoa(start))
+			params.Set("limit", strconv.Itoa(limit))
+			if spaceKey != "" {
+				params.Set("spaceKey", spaceKey)
+			}
+			request, err := client.NewRequest(ctx, http.MethodGet, "wiki/rest/api/template/"+kind+"?"+params.Encode(), "", nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			page := new(templatePageScheme)
+			response, err = client.Call(request, page)
+			if err != nil {
+				return nil, response, err
+			}
+			for _, t := range page.Results {
+				templates = append(templates, t.template(kind))
+			}
+			if len(page.Results) < limit {
+				break
+			}
+		}
+	}
+	return templates, response, nil
+}
+
+// GetTemplate fetches a template with its storage body and variables
+func GetTemplate(ctx context.Context, client *confluence.Client, templateID string) (*Template, *models.ResponseScheme, error) {
+	request, err := client.NewRequest(ctx, http.MethodGet, "wiki/rest/api/template/"+url.PathEscape(templateID)+"?expand=body", "", nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	scheme := new(templateScheme)
+	response, err := client.Call(request, scheme)
+	if err != nil {
+		return nil, response, err
+	}
+	kind := TemplatePage
+	if scheme.TemplateType == TemplateBlueprint {
+		kind = TemplateBlueprint
+	}
+	return scheme.template(kind), response, nil
+}
+
+// FindTemplate returns the template with an ID, or with a name among the templates of the space
+// and the global ones
+func FindTemplate(ctx context.Context, client *confluence.Client, spaceKey, reference string) (*Template, error) {
+	if _, err := strconv.ParseInt(reference, 10, 64); err == nil {
+		t, response, err := GetTemplate(ctx, client, reference)
+		if err != nil {
+			return nil, ResponseError("failed to get template", response, err)
+		}
+		return t, nil
+	}
+
+	keys := []string{""}
+	if spaceKey != "" {
+		keys = []string{spaceKey, ""}
+	}
+	var matches []*Template
+	for _, key := range keys {
+		templates, response, err := ListTemplates(ctx, client, key, false)
+		if err != nil {
+			return nil, ResponseError("failed to list templates", response, err)
+		}
+		for _, t := range templates {
+			if strings.EqualFold(t.Name, reference) {
+				matches = append(matches, t)
+			}
+		}
+		if len(matches) > 0 {
+			break
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no template named %q", reference)
+	case 1:
+		t, response, err := GetTemplate(ctx, client, matches[0].ID)
+		if err != nil {
+			return nil, ResponseError("failed to get template", response, err)
+		}
+		return t, nil
+	default:
+		ids := make([]string, len(matches))
+		for i, t := range matches {
+			ids[i] = t.ID
+		}
+		return nil, fmt.Errorf("%d templates are named %q; use one of the IDs %s", len(matches), reference, strings.Join(ids, ", "))
+	}
+}
+
+var (
+	templateDeclarationsPattern = regexp.MustCompile(`(?s)<at:declarations>.*?</at:declarations>`)
+	templateDeclarationPattern  = regexp.MustCompile(`(?s)<at:(string|textarea|list)\s[^>]*?at:name="([^"]+)"[^>]*?(?:/>|>(.*?)</at:(?:string|textarea|list)>)`)
+	templateOptionPattern       = regexp.MustCompile(`<at:option\s[^>]*?at:value="([^"]*)"`)
+	templateVarPattern          = regexp.MustCompile(`<at:var\s[^>]*?at:name="([^"]+)"[^>]*?(?:/>|>\s*</at:var>)`)
+)
+
+// TemplateVariables lists the variables of a template body: the declared ones first, then the
+// ones only used
+func TemplateVariables(body string) []TemplateVariable {
+	var variables []TemplateVariable
+	seen := make(map[string]bool)
+	for _, declarations := range templateDeclarationsPattern.FindAllString(body, -1) {
+		for _, match := range templateDeclarationPattern.FindAllStringSubmatch(declarations, -1) {
+			if seen[match[2]] {
+				continue
+			}
+			seen[match[2]] = true
+			variable := TemplateVariable{Name: html.UnescapeString(match[2]), Type: match[1]}
+			for _, option := range templateOptionPattern.FindAllStringSubmatch(match[3], -1) {
+				variable.Options = append(variable.Options, html.UnescapeString(option[1]))
+			}
+			variables = append(variables, variable)
+		}
+	}
+	for _, match := range templateVarPattern.FindAllStringSubmatch(body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			variables = append(variables, TemplateVariable{Name: html.UnescapeString(match[1]), Type: "string"})
+		}
+	}
+	return variables
+}
+
+// templateValue renders a JSON value as the text of a variable; lists are joined with commas
+func templateValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = templateValue(item)
+		}
+		return strings.Join(parts, ", ")
+	case map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FillTemplate replaces the variables of a template body with values and drops its declarations.
+// Values are escaped unless the variable is marked at:rawxhtml. Variables without a value are an
+// error listing them, unless allowMissing is set, in which case they are left empty.
+func FillTemplate(body string, values map[string]interface{}, allowMissing bool) (string, error) {
+	var missing []string
+	missed := make(map[string]bool)
+	filled := templateVarPattern.ReplaceAllStringFunc(body, func(tag string) string {
+		name := html.UnescapeString(templateVarPattern.FindStringSubmatch(tag)[1])
+		value, ok := values[name]
+		if !ok {
+			if !missed[name] {
+				missed[name] = true
+				missing = append(missing, name)
+			}
+			return ""
+		}
+		text := templateValue(value)
+		if strings.Contains(tag, `at:rawxhtml="true"`) {
+			return text
+		}
+		return html.EscapeString(text)
+	})
+	if len(missing) > 0 && !allowMissing {
+		sort.Strings(missing)
+		return "", fmt.Errorf("missing template variables: %s", strings.Join(missing, ", "))
+	}
+	return strings.TrimSpace(templateDeclarationsPattern.ReplaceAllString(filled, "")), nil
+}
+
+// ExecuteTemplate runs a Go text/template with values. Unknown keys are an error unless allowMissing is set.
+func ExecuteTemplate(name, text string, values map[string]interface{}, allowMissing bool) (string, error) {
+	missingKey := "missingkey=error"
+	if allowMissing {
+		missingKey = "missingkey=zero"
+	}
+	tmpl, err := template.New(name).Option(missingKey).Funcs(template.FuncMap{
+		"join":    templateJoin,
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"default": templateDefault,
+	}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, values); err != nil {
+		return "", err
+	}
+	// missingkey=zero renders absent map entries as "<no value>"
+	return strings.ReplaceAll(b.String(), "<no value>", ""), nil
+}
+
+// templateJoin joins a list of any values, as decoded from JSON: {{join .tags ", "}}
+func templateJoin(values interface{}, sep string) string {
+	switch list := values.(type) {
+	case []string:
+		return strings.Join(list, sep)
+	case []interface{}:
+		parts := make([]string, len(list))
+		for i, value := range list {
+			parts[i] = fmt.Sprint(value)
+		}
+		return strings.Join(parts, sep)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(values)
+}
+
+// templateDefault returns value, or fallback when value is empty: {{default "TBD" .owner}}
+func templateDefault(fallback, value interface{}) interface{} {
+	if value == nil || value == "" {
+		return fallback
+	}
+	return value
+}
+
+// RenderTemplateFile renders a local Go text/template file into storage format. Markdown files
+// (.md, .markdown) are converted after rendering; other files must contain storage format.
+func RenderTemplateFile(path string, values map[string]interface{}, allowMissing bool) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	rendered, err := ExecuteTemplate(filepath.Base(path), string(data), values, allowMissing)
+	if err != nil {
+		return "", err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return MarkdownToStorage(rendered, nil), nil
+	}
+	return rendered, nil
+}
+
+// TemplatePageOptions describes a page created from a Confluence template
+type TemplatePageOptions struct {
+	Template     string // template ID or name
+	SpaceKey     string
+	Title        string
+	ParentID     string
+	Values       map[string]interface{}
+	AllowMissing bool
+}
+
+// CreatePageFromTemplate fills a Confluence template with values, creates the page and adds the
+// labels of the template to it
+func CreatePageFromTemplate(ctx context.Context, client *confluence.Client, options TemplatePageOptions) (*models.ContentScheme, *Template, error) {
+	t, err := FindTemplate(ctx, client, options.SpaceKey, options.Template)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.Kind == TemplateBlueprint {
+		return nil, t, fmt.Errorf("template %q is a blueprint; blueprints are created through their wizard in Confluence", t.Name)
+	}
+	body, err := FillTemplate(t.Body, options.Values, options.AllowMissing)
+	if err != nil {
+		return nil, t, err
+	}
+
+	payload := &models.ContentScheme{
+		Type:  "page",
+		Title: options.Title,
+		Space: &models.SpaceScheme{Key: options.SpaceKey},
+		Body: &models.BodyScheme{
+			Storage: &models.BodyNodeScheme{Value: body, Representation: "storage"},
+		},
+	}
+	if options.ParentID != "" {
+		payload.Ancestors = []*models.ContentScheme{{ID: options.ParentID}}
+	}
+	page, response, err := client.Content.Create(ctx, payload)
+	if err != nil {
+		return nil, t, ResponseError("failed to create page", response, err)
+	}
+
+	if len(t.Labels) > 0 {
+		labels := make([]*models.ContentLabelPayloadScheme, len(t.Labels))
+		for i, name := range t.Labels {
+			labels[i] = &models.ContentLabelPayloadScheme{Prefix: "global", Name: name}
+		}
+		if _, response, err := client.Content.Label.Add(ctx, page.ID, labels, false); err != nil {
+			return page, t, ResponseError("page "+page.ID+" was created but adding the template labels failed", response, err)
+		}
+	}
+	return page, t, nil
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const keyFileName = "oauth-token.key"
+
+// tokenFileName returns the token file of a profile, "" being the environment profile
+func tokenFileName(profile string) (string, error) {
+	if profile == "" {
+		return "oauth-token.enc", nil
+	}
+	if err := ValidateProfileName(profile); err != nil {
+		return "", err
+	}
+	return "oauth-token-" + profile + ".enc", nil
+}
+
+// tokenPath returns the path of the token file of a profile
+func tokenPath(profile string) (string, error) {
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	name, err := tokenFileName(profile)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// OAuthToken is the persisted result of an OAuth 2.0 (3LO) login
+type OAuthToken struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token"`
+	TokenType    string    `json:"token_type"`
+	Scope        string    `json:"scope"`
+	Expiry       time.Time `json:"expiry"`
+	ClientID     string    `json:"client_id"`
+	ClientSecret string    `json:"client_secret,omitempty"`
+	CloudID      string    `json:"cloud_id"`
+	SiteURL      string    `json:"site_url"`
+}
+
+// Expired reports whether the access token is expired or about to expire
+func (t *OAuthToken) Expired() bool {
+	return t.Expiry.IsZero() || time.Now().Add(time.Minute).After(t.Expiry)
+}
+
+// ConfigDir returns the directory holding confluence-mcp user configuration
+func ConfigDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to locate user config directory")
+	}
+	return filepath.Join(dir, "confluence-mcp"), nil
+}
+
+// tokenKey returns the AES-256 key protecting the token file. CONFLUENCE_MCP_TOKEN_KEY
+// takes precedence; otherwise the key is read from a file next to the token file, which is
+// generated when create is set and the file does not exist yet.
+func tokenKey(dir string, create bool) ([]byte, error) {
+	if passphrase := os.Getenv("CONFLUENCE_MCP_TOKEN_KEY"); passphrase != "" {
+		sum := sha256.Sum256([]byte(passphrase))
+		return sum[:], nil
+	}
+
+	keyPath := filepath.Join(dir, keyFileName)
+	key, err := os.ReadFile(keyPath)
+	switch {
+	case err == nil && len(key) == 32:
+		return key, nil
+	case err == nil:
+		return nil, fmt.Errorf("token key %s is corrupt (%d bytes instead of 32); delete it and the token files, then log in again", keyPath, len(key))
+	case !os.IsNotExist(err):
+		return nil, errors.WithMessage(err, "failed to read token key")
+	case !create:
+		return nil, fmt.Errorf("token key %s is missing, so the stored token cannot be decrypted; log in again or set CONFLUENCE_MCP_TOKEN_KEY to the passphrase it was saved with", keyPath)
+	}
+
+	key = make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, errors.WithMessage(err, "failed to generate token key")
+	}
+	if err := os.WriteFile(keyPath, key, 0o600); err != nil {
+		return nil, errors.WithMessage(err, "failed to write token key")
+	}
+	return key, nil
+}
+
+// HasOAuthToken reports whether a stored OAuth token exists for the profile
+func HasOAuthToken(profile string) bool {
+	path, err := tokenPath(profile)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// SaveOAuthToken encrypts and writes the profile's token to the user config directory
+func SaveOAuthToken(profile string, token *OAuthToken) error {
+	path, err := tokenPath(profile)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return errors.WithMessage(err, "failed to create config directory")
+	}
+
+	key, err := tokenKey(dir, true)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(token)
+	if err != nil {
+		return errors.WithMessage(err, "failed to encode token")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return errors.WithMessage(err, "failed to generate nonce")
+	}
+
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	if err := os.WriteFile(path, ciphertext, 0o600); err != nil {
+		return errors.WithMessage(err, "failed to write token file")
+	}
+	return nil
+}
+
+// LoadOAuthToken reads and decrypts the token stored for the profile
+func LoadOAuthToken(profile string) (*OAuthToken, error) {
+	path, err := tokenPath(profile)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+
+	ciphertext, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if profile != "" {
+				return nil, fmt.Errorf("no stored OAuth token for profile %q, run 'confluence-cli login --profile %s' first", profile, profile)
+			}
+			return nil, fmt.Errorf("no stored OAuth token, run 'confluence-cli login' first")
+		}
+		return nil, errors.WithMessage(err, "failed to read token file")
+	}
+
+	key, err := tokenKey(dir, false)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("token file is corrupted")
+	}
+
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to decrypt token file")
+	}
+
+	token := &OAuthToken{}
+	if err := json.Unmarshal(plaintext, token); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode token file")
+	}
+	return token, nil
+}
+
+// DeleteOAuthToken removes the token stored for the profile
+func DeleteOAuthToken(profile string) error {
+	path, err := tokenPath(profile)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.WithMessage(err, "failed to remove token file")
+	}
+	return nil
+}
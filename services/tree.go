@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// PageNode is a page together with its child pages
+type PageNode struct {
+	Page     *models.ContentScheme
+	Children []*PageNode
+}
+
+// Walk visits the node and its descendants depth-first, parents before children
+func (n *PageNode) Walk(visit func(node *PageNode, depth int) error) error {
+	var walk func(node *PageNode, depth int) error
+	walk = func(node *PageNode, depth int) error {
+		if err := visit(node, depth); err != nil {
+			return err
+		}
+		for _, child := range node.Children {
+			if err := walk(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(n, 0)
+}
+
+// ChildPages lists all child pages of a page, following pagination
+func ChildPages(ctx context.Context, client *confluence.Client, pageID string, expand []string) ([]*models.ContentScheme, *models.ResponseScheme, error) {
+	var pages []*models.ContentScheme
+	for start := 0; ; start += 100 {
+		children, response, err := client.Content.ChildrenDescendant.ChildrenByType(ctx, pageID, "page", 0, expand, start, 100)
+		if err != nil {
+			return nil, response, err
+		}
+		pages = append(pages, children.Results...)
+		if len(children.Results) < 100 {
+			return pages, response, nil
+		}
+	}
+}
+
+// GetPageTree fetches a page and all of its descendants with the given expansions
+func GetPageTree(ctx context.Context, client *confluence.Client, rootID string, expand []string) (*PageNode, *models.ResponseScheme, error) {
+	root, response, err := client.Content.Get(ctx, rootID, expand, 0)
+	if err != nil {
+		return nil, response, err
+	}
+
+	node := &PageNode{Page: root}
+	if err := fillChildren(ctx, client, node, expand); err != nil {
+		return nil, nil, err
+	}
+	return node, response, nil
+}
+
+func fillChildren(ctx context.Context, client *confluence.Client, node *PageNode, expand []string) error {
+	children, response, err := ChildPages(ctx, client, node.Page.ID, expand)
+	if err != nil {
+		return ResponseError("failed to list child pages of "+node.Page.ID, response, err)
+	}
+	for _, child := range children {
+		childNode := &PageNode{Page: child}
+		if err := fillChildren(ctx, client, childNode, expand); err != nil {
+			return err
+		}
+		node.Children = append(node.Children, childNode)
+	}
+	return nil
+}
+
+// SpacePages lists every current page of a space, following pagination
+func SpacePages(ctx context.Context, client *confluence.Client, spaceKey string, expand []string) ([]*models.ContentScheme, *models.ResponseScheme, error) {
+	options := &models.GetContentOptionsScheme{ContextType: "page", SpaceKey: spaceKey, Expand: expand}
+	var pages []*models.ContentScheme
+	for start := 0; ; start += 100 {
+		page, response, err := client.Content.Gets(ctx, options, start, 100)
+		if err != nil {
+			return nil, response, err
+		}
+		pages = append(pages, page.Results...)
+		if len(page.Results) < 100 {
+			return pages, response, nil
+		}
+	}
+}
+
+// GetSpaceTree arranges the pages of a space by parent. Pages are fetched with their
+// ancestors; top-level pages (usually the homepage and orphaned pages) are the roots.
+func GetSpaceTree(ctx context.Context, client *confluence.Client, spaceKey string, expand []string) ([]*PageNode, *models.ResponseScheme, error) {
+	pages, response, err := SpacePages(ctx, client, spaceKey, append([]string{"ancestors"}, expand...))
+	if err != nil {
+		return nil, response, err
+	}
+
+	nodes := make(map[string]*PageNode, len(pages))
+	for _, page := range pages {
+		nodes[page.ID] = &PageNode{Page: page}
+	}
+
+	var roots []*PageNode
+	for _, page := range pages {
+		node := nodes[page.ID]
+		var parent *PageNode
+		if len(page.Ancestors) > 0 {
+			parent = nodes[page.Ancestors[len(page.Ancestors)-1].ID]
+		}
+		if parent == nil {
+			roots = append(roots, node)
+			continue
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	sortNodes(roots)
+	return roots, response, nil
+}
+
+// sortNodes orders sibling pages by title, recursively
+func sortNodes(nodes []*PageNode) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return strings.ToLower(nodes[i].Page.Title) < strings.ToLower(nodes[j].Page.Title)
+	})
+	for _, node := range nodes {
+		sortNodes(node.Children)
+	}
+}
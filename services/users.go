@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+)
+
+// UserRef identifies a user as storage format does: by account ID on Cloud, by user key or
+// username on Data Center
+type UserRef struct {
+	AccountID string
+	UserKey   string
+	Username  string
+}
+
+// ID returns the identifier of the user
+func (r UserRef) ID() string {
+	for _, id := range []string{r.AccountID, r.UserKey, r.Username} {
+		if id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// Matches reports whether the reference is the given user
+func (r UserRef) Matches(user *models.ContentUserScheme) bool {
+	return SameUser(&models.ContentUserScheme{AccountID: r.AccountID, UserKey: r.UserKey, Username: r.Username}, user)
+}
+
+// directoryUserScheme is a user with the account status fields some deployments return
+type directoryUserScheme struct {
+	models.ContentUserScheme
+	AccountStatus string `json:"accountStatus,omitempty"`
+	Active        *bool  `json:"active,omitempty"`
+}
+
+// UserDirectory looks users up once per ID
+type UserDirectory struct {
+	client  *confluence.Client
+	users   map[string]*directoryUserScheme
+	removed map[string]bool // users the site does not know
+}
+
+// NewUserDirectory returns an empty directory
+func NewUserDirectory(client *confluence.Client) *UserDirectory {
+	return &UserDirectory{client: client, users: map[string]*directoryUserScheme{}, removed: map[string]bool{}}
+}
+
+// Lookup returns a user, or nil when the user cannot be found
+func (d *UserDirectory) Lookup(ctx context.Context, ref UserRef) *models.ContentUserScheme {
+	if user := d.lookup(ctx, ref); user != nil {
+		return &user.ContentUserScheme
+	}
+	return nil
+}
+
+func (d *UserDirectory) lookup(ctx context.Context, ref UserRef) *directoryUserScheme {
+	id := ref.ID()
+	if id == "" {
+		return nil
+	}
+	if user, ok := d.users[id]; ok {
+		return user
+	}
+
+	params := url.Values{}
+	switch {
+	case ref.AccountID != "":
+		params.Set("accountId", ref.AccountID)
+	case ref.UserKey != "":
+		params.Set("key", ref.UserKey)
+	default:
+		params.Set("username", ref.Username)
+	}
+	var user *directoryUserScheme
+	if request, err := d.client.NewRequest(ctx, http.MethodGet, "wiki/rest/api/user?"+params.Encode(), "", nil); err == nil {
+		found := new(directoryUserScheme)
+		response, err := d.client.Call(request, found)
+		switch {
+		case err == nil:
+			user = found
+		case response != nil && response.Code == http.StatusNotFound:
+			d.removed[id] = true
+		}
+	}
+	d.users[id] = user
+	return user
+}
+
+// Name returns the display name of a user, or its ID when it cannot be found
+func (d *UserDirectory) Name(ctx context.Context, ref UserRef) string {
+	if name := UserName(d.Lookup(ctx, ref)); name != "" {
+		return name
+	}
+	return ref.ID()
+}
+
+// Deactivated reports whether a user no longer has access: the site does not know the user,
+// reports the account as inactive, or shows the name the way Confluence does for removed
+// (Former user) and deactivated (Unlicensed) accounts
+func (d *UserDirectory) Deactivated(ctx context.Context, ref UserRef) bool {
+	user := d.lookup(ctx, ref)
+	if user == nil {
+		return d.removed[ref.ID()]
+	}
+	if user.Type == "unknown" {
+		return true
+	}
+	if user.Active != nil && !*user.Active {
+		return true
+	}
+	if user.AccountStatus != "" && !strings.EqualFold(user.AccountStatus, "active") {
+		return true
+	}
+	name := strings.ToLower(UserName(&user.ContentUserScheme))
+	return name == "former user" || strings.HasSuffix(name, "(unlicensed)") || strings.HasSuffix(name, "(deactivated)")
+}
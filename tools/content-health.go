@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// ContentHealthInput defines the input parameters for a content health report
+type ContentHealthInput struct {
+	SpaceKey  string `json:"space_key,omitempty"`
+	StaleDays int    `json:"stale_days,omitempty"`
+	Checks    string `json:"checks,omitempty"`
+	Format    string `json:"format,omitempty"`
+	Profile   string `json:"profile,omitempty"`
+}
+
+// confluenceContentHealthHandler handles scanning a space for stale, orphaned, ownerless, empty and duplicated pages
+func confluenceContentHealthHandler(ctx context.Context, request mcp.CallToolRequest, input ContentHealthInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	spaceKey := input.SpaceKey
+	if spaceKey == "" {
+		spaceKey = cfg.DefaultSpace
+	}
+	if spaceKey == "" {
+		return mcp.NewToolResultError("space_key is required"), nil
+	}
+
+	report, err := services.ContentHealth(ctx, client, cfg, services.HealthOptions{
+		SpaceKey:  spaceKey,
+		StaleDays: input.StaleDays,
+		Checks:    splitList(input.Checks),
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to check content health: %v", err)), nil
+	}
+
+	responseText, err := formatResult(report, input.Format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(responseText), nil
+}
+
+// RegisterContentHealthTool registers the content_health tool with the MCP server
+func RegisterContentHealthTool(s *server.MCPServer) {
+	tool := mcp.NewTool("content_health",
+		mcp.WithDescription("Scan every page of a space for content that needs cleaning up: pages not modified in stale_days days, "+
+			"orphans (no child pages and no links from other pages), pages last edited by a deactivated user, empty pages and duplicated titles. "+
+			"Returns a summary per check and a row per page and check."),
+		mcp.WithString("space_key", mcp.Description("Space key to scan (default: the default space)")),
+		mcp.WithNumber("stale_days", mcp.Description("Pages not modified for this many days are stale (default: 365)")),
+		mcp.WithString("checks", mcp.Description("Comma-separated checks to run: stale, orphan, deactivated_editor, empty, duplicate_title (default: all)")),
+		mcp.WithString("format", mcp.Description("Output format: yaml (default), json or csv")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceContentHealthHandler))
+}
@@ -0,0 +1,106 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// CreateBlogPostInput defines the input parameters for creating a blog post
+type CreateBlogPostInput struct {
+	SpaceKey string `json:"space_key,omitempty"`
+	Title    string `json:"title" validate:"required"`
+	Content  string `json:"content" validate:"required"`
+	Labels   string `json:"labels,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// confluenceCreateBlogPostHandler handles publishing a new blog post
+func confluenceCreateBlogPostHandler(ctx context.Context, request mcp.CallToolRequest, input CreateBlogPostInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	if input.SpaceKey == "" {
+		input.SpaceKey = cfg.DefaultSpace
+	}
+	if input.SpaceKey == "" {
+		return mcp.NewToolResultError("space_key is required when the profile has no default space"), nil
+	}
+
+	payload := &models.ContentScheme{
+		Type:  services.ContentTypeBlogPost,
+		Title: input.Title,
+		Space: &models.SpaceScheme{Key: input.SpaceKey},
+		Body: &models.BodyScheme{
+			Storage: &models.BodyNodeScheme{
+				Value:          input.Content,
+				Representation: "storage",
+			},
+		},
+	}
+
+	post, response, err := client.Content.Create(ctx, payload)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to create blog post: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create blog post: %v", err)), nil
+	}
+
+	if labels := splitList(input.Labels); len(labels) > 0 {
+		payload := make([]*models.ContentLabelPayloadScheme, len(labels))
+		for i, name := range labels {
+			payload[i] = &models.ContentLabelPayloadScheme{Prefix: "global", Name: name}
+		}
+		if _, response, err := client.Content.Label.Add(ctx, post.ID, payload, false); err != nil {
+			if response != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("blog post %s was created but adding labels failed: %s (endpoint: %s)", post.ID, response.Bytes.String(), response.Endpoint)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("blog post %s was created but adding labels failed: %v", post.ID, err)), nil
+		}
+	}
+
+	output := CreatePageOutput{
+		Success: true,
+		Title:   post.Title,
+		ID:      post.ID,
+	}
+	if post.Version != nil {
+		output.Version = post.Version.Number
+	}
+	if post.Links != nil {
+		output.Link = cfg.WebURL(post.Links.Webui)
+	}
+	output.Message = fmt.Sprintf("Blog post published successfully!\nTitle: %s\nID: %s\nLink: %s", output.Title, output.ID, output.Link)
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterCreateBlogPostTool registers the create_blog_post tool with the MCP server
+func RegisterCreateBlogPostTool(s *server.MCPServer) {
+	tool := mcp.NewTool("create_blog_post",
+		mcp.WithDescription("Publish a new blog post in a Confluence space"),
+		mcp.WithString("space_key", mcp.Description("The key of the space to publish the blog post in (defaults to the profile's default space)")),
+		mcp.WithString("title", mcp.Required(), mcp.Description("Title of the blog post")),
+		mcp.WithString("content", mcp.Required(), mcp.Description("Content of the blog post in storage format (XHTML)")),
+		mcp.WithString("labels", mcp.Description("Comma-separated labels to add to the blog post")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceCreateBlogPostHandler))
+}
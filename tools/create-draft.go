@@ -0,0 +1,98 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// CreateDraftInput defines the input parameters for creating a draft
+type CreateDraftInput struct {
+	SpaceKey string `json:"space_key,omitempty"`
+	Title    string `json:"title" validate:"required"`
+	Content  string `json:"content" validate:"required"`
+	ParentID string `json:"parent_id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// DraftOutput is a saved draft with the links to review it
+type DraftOutput struct {
+	Success bool `json:"success" yaml:"success"`
+
+	services.Draft `yaml:",inline"`
+
+	Message string `json:"message" yaml:"message"`
+}
+
+// confluenceCreateDraftHandler handles saving new content as a draft
+func confluenceCreateDraftHandler(ctx context.Context, request mcp.CallToolRequest, input CreateDraftInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	if input.SpaceKey == "" {
+		input.SpaceKey = cfg.DefaultSpace
+	}
+	if input.SpaceKey == "" {
+		return mcp.NewToolResultError("space_key is required when the profile has no default space"), nil
+	}
+	if input.Type != "" && input.Type != "page" && input.Type != services.ContentTypeBlogPost {
+		return mcp.NewToolResultError(fmt.Sprintf("type must be page or blogpost, got %q", input.Type)), nil
+	}
+
+	if input.ParentID != "" {
+		input.ParentID, err = resolvePage(ctx, client, input.Profile, input.ParentID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to resolve parent page: %v", err)), nil
+		}
+	}
+
+	draft, response, err := services.CreateDraft(ctx, client, services.DraftOptions{
+		Type:     input.Type,
+		SpaceKey: input.SpaceKey,
+		Title:    input.Title,
+		Body:     input.Content,
+		ParentID: input.ParentID,
+	})
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to create draft: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create draft: %v", err)), nil
+	}
+
+	output := DraftOutput{Success: true, Draft: *services.NewDraft(cfg, draft)}
+	output.Message = fmt.Sprintf("Draft saved; it is not published until publish_draft is called.\nTitle: %s\nID: %s\nReview: %s", output.Title, output.ID, output.EditURL)
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterCreateDraftTool registers the create_draft tool with the MCP server
+func RegisterCreateDraftTool(s *server.MCPServer) {
+	tool := mcp.NewTool("create_draft",
+		mcp.WithDescription("Save a new page or blog post as a draft instead of publishing it, so it can be reviewed in Confluence first. "+
+			"Publish it later with publish_draft."),
+		mcp.WithString("space_key", mcp.Description("The key of the space of the draft (defaults to the profile's default space)")),
+		mcp.WithString("title", mcp.Required(), mcp.Description("Title of the draft")),
+		mcp.WithString("content", mcp.Required(), mcp.Description("Content of the draft in storage format (XHTML)")),
+		mcp.WithString("parent_id", mcp.Description("Parent page (optional): "+services.PageReferenceHelp)),
+		mcp.WithString("type", mcp.Description("page (default) or blogpost")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceCreateDraftHandler))
+}
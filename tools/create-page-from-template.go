@@ -0,0 +1,104 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// CreatePageFromTemplateInput defines the input parameters for creating a page from a template
+type CreatePageFromTemplateInput struct {
+	Template     string `json:"template" validate:"required"`
+	Title        string `json:"title" validate:"required"`
+	SpaceKey     string `json:"space_key,omitempty"`
+	ParentID     string `json:"parent_id,omitempty"`
+	Variables    string `json:"variables,omitempty"`
+	AllowMissing bool   `json:"allow_missing,omitempty"`
+	Profile      string `json:"profile,omitempty"`
+}
+
+// confluenceCreatePageFromTemplateHandler handles creating a page from a filled-in template
+func confluenceCreatePageFromTemplateHandler(ctx context.Context, request mcp.CallToolRequest, input CreatePageFromTemplateInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	if input.SpaceKey == "" {
+		input.SpaceKey = cfg.DefaultSpace
+	}
+	if input.SpaceKey == "" {
+		return mcp.NewToolResultError("space_key is required when the profile has no default space"), nil
+	}
+
+	values := map[string]interface{}{}
+	if input.Variables != "" {
+		if err := json.Unmarshal([]byte(input.Variables), &values); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("variables must be a JSON object: %v", err)), nil
+		}
+	}
+
+	if input.ParentID != "" {
+		input.ParentID, err = services.ResolvePageID(ctx, client, cfg, input.ParentID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to resolve parent page: %v", err)), nil
+		}
+	}
+
+	page, template, err := services.CreatePageFromTemplate(ctx, client, services.TemplatePageOptions{
+		Template:     input.Template,
+		SpaceKey:     input.SpaceKey,
+		Title:        input.Title,
+		ParentID:     input.ParentID,
+		Values:       values,
+		AllowMissing: input.AllowMissing,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := CreatePageOutput{
+		Success: true,
+		Title:   page.Title,
+		ID:      page.ID,
+	}
+	if page.Version != nil {
+		output.Version = page.Version.Number
+	}
+	if page.Links != nil {
+		output.Link = cfg.WebURL(page.Links.Webui)
+	}
+	output.Message = fmt.Sprintf("Page created from template %q\nTitle: %s\nID: %s\nLink: %s", template.Name, output.Title, output.ID, output.Link)
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterCreatePageFromTemplateTool registers the create_page_from_template tool with the MCP server
+func RegisterCreatePageFromTemplateTool(s *server.MCPServer) {
+	tool := mcp.NewTool("create_page_from_template",
+		mcp.WithDescription("Create a Confluence page from a page template (e.g. ADR, postmortem, RFC), filling its variables from a JSON object. "+
+			"Use get_template to see the variables; the labels of the template are added to the page."),
+		mcp.WithString("template", mcp.Required(), mcp.Description("Template ID, or name of a template of the space or a global one")),
+		mcp.WithString("title", mcp.Required(), mcp.Description("Title of the new page")),
+		mcp.WithString("space_key", mcp.Description("Space to create the page in (default: the profile's default space)")),
+		mcp.WithString("parent_id", mcp.Description("Parent page (optional): "+services.PageReferenceHelp)),
+		mcp.WithString("variables", mcp.Description("JSON object of variable values, e.g. {\"owner\": \"Jane\", \"status\": \"Proposed\"}; lists are joined with commas")),
+		mcp.WithBoolean("allow_missing", mcp.Description("Leave variables without a value empty instead of failing")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceCreatePageFromTemplateHandler))
+}
@@ -13,29 +13,46 @@ import (
 
 // CreatePageInput defines the input parameters for creating a Confluence page
 type CreatePageInput struct {
-	SpaceKey string `json:"space_key" validate:"required"`
+	SpaceKey string `json:"space_key,omitempty"`
 	Title    string `json:"title" validate:"required"`
 	Content  string `json:"content" validate:"required"`
 	ParentID string `json:"parent_id,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+
+	// Optional restrictions applied right after creation
+	ReadUsers    string `json:"read_users,omitempty"`
+	ReadGroups   string `json:"read_groups,omitempty"`
+	UpdateUsers  string `json:"update_users,omitempty"`
+	UpdateGroups string `json:"update_groups,omitempty"`
 }
 
 // CreatePageOutput defines the output structure for page creation results
 type CreatePageOutput struct {
-	Success       bool   `json:"success"`
-	Title         string `json:"title"`
-	ID            string `json:"id"`
-	Version       int    `json:"version"`
-	Link          string `json:"link"`
-	Message       string `json:"message"`
+	Success    bool   `json:"success"`
+	Title      string `json:"title"`
+	ID         string `json:"id"`
+	Version    int    `json:"version"`
+	Link       string `json:"link"`
+	Restricted bool   `json:"restricted,omitempty"`
+	Message    string `json:"message"`
 }
 
 // confluenceCreatePageHandler handles the creation of new Confluence pages using typed input
 func confluenceCreatePageHandler(ctx context.Context, req mcp.CallToolRequest, input CreatePageInput) (*mcp.CallToolResult, error) {
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(input.Profile)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
 	}
 
+	if input.SpaceKey == "" {
+		if cfg, err := services.ConfigFor(input.Profile); err == nil {
+			input.SpaceKey = cfg.DefaultSpace
+		}
+	}
+	if input.SpaceKey == "" {
+		return mcp.NewToolResultError("space_key is required when the profile has no default space"), nil
+	}
+
 	// Create page payload
 	payload := &models.ContentScheme{
 		Type:  "page",
@@ -53,6 +70,10 @@ func confluenceCreatePageHandler(ctx context.Context, req mcp.CallToolRequest, i
 
 	// Handle optional parent ID
 	if input.ParentID != "" {
+		input.ParentID, err = resolvePage(ctx, client, input.Profile, input.ParentID)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to resolve parent page: %v", err)), nil
+		}
 		payload.Ancestors = []*models.ContentScheme{
 			{
 				ID: input.ParentID,
@@ -69,6 +90,30 @@ func confluenceCreatePageHandler(ctx context.Context, req mcp.CallToolRequest, i
 		return mcp.NewToolResultError(fmt.Sprintf("failed to create page: %v", err)), nil
 	}
 
+	// Apply restrictions before reporting success; if they cannot be applied the page is
+	// removed again so it is never left readable by everyone
+	change := restrictionChange(input.ReadUsers, input.ReadGroups, input.UpdateUsers, input.UpdateGroups)
+	if !change.Empty() {
+		var restrictResponse *models.ResponseScheme
+		cfg, err := services.ConfigFor(input.Profile)
+		if err == nil {
+			restrictResponse, err = services.SetPageRestrictions(ctx, client, cfg, newPage.ID, change, true)
+		}
+		if err != nil {
+			reason := err.Error()
+			if restrictResponse != nil {
+				reason = fmt.Sprintf("%s (endpoint: %s)", restrictResponse.Bytes.String(), restrictResponse.Endpoint)
+			}
+			if _, deleteErr := client.Content.Delete(ctx, newPage.ID, ""); deleteErr != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("page %s was created but restricting it failed: %s; removing the page also failed: %v", newPage.ID, reason, deleteErr)), nil
+			}
+			if _, purgeErr := client.Content.Delete(ctx, newPage.ID, "trashed"); purgeErr != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to apply restrictions: %s; page %s was created and moved to the trash, but purging it failed: %v", reason, newPage.ID, purgeErr)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("failed to apply restrictions, the page was not created: %s", reason)), nil
+		}
+	}
+
 	var versionNumber int
 	if newPage.Version != nil {
 		versionNumber = newPage.Version.Number
@@ -81,11 +126,12 @@ func confluenceCreatePageHandler(ctx context.Context, req mcp.CallToolRequest, i
 	}
 
 	output := CreatePageOutput{
-		Success: true,
-		Title:   newPage.Title,
-		ID:      newPage.ID,
-		Version: versionNumber,
-		Link:    selfLink,
+		Success:    true,
+		Title:      newPage.Title,
+		ID:         newPage.ID,
+		Version:    versionNumber,
+		Link:       selfLink,
+		Restricted: !change.Empty(),
 		Message: fmt.Sprintf("Page created successfully!\nTitle: %s\nID: %s\nVersion: %d\nLink: %s",
 			newPage.Title,
 			newPage.ID,
@@ -105,10 +151,15 @@ func confluenceCreatePageHandler(ctx context.Context, req mcp.CallToolRequest, i
 func RegisterCreatePageTool(s *server.MCPServer) {
 	createPageTool := mcp.NewTool("create_page",
 		mcp.WithDescription("Create a new Confluence page"),
-		mcp.WithString("space_key", mcp.Required(), mcp.Description("The key of the space where the page will be created")),
+		mcp.WithString("space_key", mcp.Description("The key of the space where the page will be created (defaults to the profile's default space)")),
 		mcp.WithString("title", mcp.Required(), mcp.Description("Title of the page")),
 		mcp.WithString("content", mcp.Required(), mcp.Description("Content of the page in storage format (XHTML)")),
-		mcp.WithString("parent_id", mcp.Description("ID of the parent page (optional)")),
+		mcp.WithString("parent_id", mcp.Description("Parent page (optional): "+services.PageReferenceHelp)),
+		mcp.WithString("read_users", mcp.Description("Comma-separated users allowed to view the page, applied right after creation (account IDs on Cloud, usernames on Data Center)")),
+		mcp.WithString("read_groups", mcp.Description("Comma-separated group names allowed to view the page, applied right after creation")),
+		mcp.WithString("update_users", mcp.Description("Comma-separated users allowed to edit the page, applied right after creation")),
+		mcp.WithString("update_groups", mcp.Description("Comma-separated group names allowed to edit the page, applied right after creation")),
+		withProfile(),
 	)
 	s.AddTool(createPageTool, mcp.NewTypedToolHandler(confluenceCreatePageHandler))
-} 
\ No newline at end of file
+}
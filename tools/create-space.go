@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// CreateSpaceInput defines the input parameters for creating a Confluence space
+type CreateSpaceInput struct {
+	Key         string `json:"key" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description,omitempty"`
+	Private     bool   `json:"private,omitempty"`
+	Profile     string `json:"profile,omitempty"`
+}
+
+// CreateSpaceOutput defines the output structure for space creation results
+type CreateSpaceOutput struct {
+	Success bool   `json:"success"`
+	Key     string `json:"key"`
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Link    string `json:"link"`
+	Message string `json:"message"`
+}
+
+// confluenceCreateSpaceHandler handles the creation of new Confluence spaces
+func confluenceCreateSpaceHandler(ctx context.Context, request mcp.CallToolRequest, input CreateSpaceInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	payload := &models.CreateSpaceScheme{
+		Key:  input.Key,
+		Name: input.Name,
+	}
+	if input.Description != "" {
+		payload.Description = &models.CreateSpaceDescriptionScheme{
+			Plain: &models.CreateSpaceDescriptionPlainScheme{
+				Value:          input.Description,
+				Representation: "plain",
+			},
+		}
+	}
+
+	space, response, err := client.Space.Create(ctx, payload, input.Private)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to create space: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to create space: %v", err)), nil
+	}
+
+	var selfLink string
+	if space.Links != nil {
+		selfLink = space.Links.Self
+	}
+
+	output := CreateSpaceOutput{
+		Success: true,
+		Key:     space.Key,
+		ID:      space.ID,
+		Name:    space.Name,
+		Link:    selfLink,
+		Message: fmt.Sprintf("Space created successfully!\nKey: %s\nName: %s\nLink: %s", space.Key, space.Name, selfLink),
+	}
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterCreateSpaceTool registers the create_space tool with the MCP server
+func RegisterCreateSpaceTool(s *server.MCPServer) {
+	tool := mcp.NewTool("create_space",
+		mcp.WithDescription("Create a new Confluence space"),
+		mcp.WithString("key", mcp.Required(), mcp.Description("Key of the new space (letters and numbers only)")),
+		mcp.WithString("name", mcp.Required(), mcp.Description("Name of the new space")),
+		mcp.WithString("description", mcp.Description("Plain text description of the space")),
+		mcp.WithBoolean("private", mcp.Description("Create a private space visible only to the creator (default: false)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceCreateSpaceHandler))
+}
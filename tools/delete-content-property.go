@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// DeleteContentPropertyInput defines the input parameters for deleting a content property
+type DeleteContentPropertyInput struct {
+	PageID  string `json:"page_id" validate:"required"`
+	Key     string `json:"key" validate:"required"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// confluenceDeleteContentPropertyHandler handles removing a content property
+func confluenceDeleteContentPropertyHandler(ctx context.Context, request mcp.CallToolRequest, input DeleteContentPropertyInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	response, err := services.DeleteContentProperty(ctx, client, input.PageID, input.Key)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to delete content property: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to delete content property: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Property %q deleted from page %s", input.Key, input.PageID)), nil
+}
+
+// RegisterDeleteContentPropertyTool registers the delete_content_property tool with the MCP server
+func RegisterDeleteContentPropertyTool(s *server.MCPServer) {
+	tool := mcp.NewTool("delete_content_property",
+		mcp.WithDescription("Delete a content property of a page or blog post"),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithString("key", mcp.Required(), mcp.Description("Property key")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceDeleteContentPropertyHandler))
+}
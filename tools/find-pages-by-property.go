@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// FindPagesByPropertyInput defines the input parameters for finding pages by a content property
+type FindPagesByPropertyInput struct {
+	SpaceKey string `json:"space_key,omitempty"`
+	Key      string `json:"key" validate:"required"`
+	Field    string `json:"field,omitempty"`
+	Value    string `json:"value,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// FindPagesByPropertyOutput lists the pages whose property matched
+type FindPagesByPropertyOutput struct {
+	Space string                    `json:"space" yaml:"space"`
+	Key   string                    `json:"key" yaml:"key"`
+	Pages []*services.PropertyMatch `json:"pages" yaml:"pages"`
+	Count int                       `json:"count" yaml:"count"`
+}
+
+// confluenceFindPagesByPropertyHandler handles listing the pages of a space by the value of a property
+func confluenceFindPagesByPropertyHandler(ctx context.Context, request mcp.CallToolRequest, input FindPagesByPropertyInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	if input.SpaceKey == "" {
+		input.SpaceKey = cfg.DefaultSpace
+	}
+	if input.SpaceKey == "" {
+		return mcp.NewToolResultError("space_key is required when the profile has no default space"), nil
+	}
+
+	query := services.PropertyQuery{
+		SpaceKey: input.SpaceKey,
+		Key:      input.Key,
+		Field:    input.Field,
+		Limit:    input.Limit,
+	}
+	if input.Value != "" {
+		query.Value = services.PropertyValue(input.Value)
+	}
+
+	pages, response, err := services.FindPagesByProperty(ctx, client, cfg, query)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to find pages: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to find pages: %v", err)), nil
+	}
+
+	responseText, err := yaml.Marshal(FindPagesByPropertyOutput{Space: input.SpaceKey, Key: input.Key, Pages: pages, Count: len(pages)})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterFindPagesByPropertyTool registers the find_pages_by_property tool with the MCP server
+func RegisterFindPagesByPropertyTool(s *server.MCPServer) {
+	tool := mcp.NewTool("find_pages_by_property",
+		mcp.WithDescription("List the pages of a space whose content property matches a value, e.g. all pages with status \"in review\". "+
+			"Every page of the space is read, so large spaces take a while."),
+		mcp.WithString("space_key", mcp.Description("Space to look in (defaults to the profile's default space)")),
+		mcp.WithString("key", mcp.Required(), mcp.Description("Property key")),
+		mcp.WithString("field", mcp.Description("Dotted path of a field inside an object value, e.g. review.status")),
+		mcp.WithString("value", mcp.Description("Value to match, as JSON or text; text compares without case and also matches lists containing it. Without a value, every page that has the property is listed")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of pages to return")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceFindPagesByPropertyHandler))
+}
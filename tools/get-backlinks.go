@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// GetBacklinksInput defines the input parameters for finding the pages that link to a page
+type GetBacklinksInput struct {
+	PageID   string `json:"page_id" validate:"required"`
+	SpaceKey string `json:"space_key,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// GetBacklinksOutput lists the pages that link to a page
+type GetBacklinksOutput struct {
+	PageID    string               `json:"page_id" yaml:"page_id"`
+	Title     string               `json:"title" yaml:"title"`
+	Spaces    []string             `json:"spaces_scanned" yaml:"spaces_scanned"`
+	Scanned   int                  `json:"pages_scanned" yaml:"pages_scanned"`
+	Count     int                  `json:"count" yaml:"count"`
+	Backlinks []*services.Backlink `json:"backlinks" yaml:"backlinks"`
+}
+
+// confluenceGetBacklinksHandler handles finding the pages of one or more spaces that link to a page
+func confluenceGetBacklinksHandler(ctx context.Context, request mcp.CallToolRequest, input GetBacklinksInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	page, response, err := client.Content.Get(ctx, input.PageID, []string{"space"}, 0)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %v", err)), nil
+	}
+
+	spaces := splitList(input.SpaceKey)
+	if len(spaces) == 0 && page.Space != nil {
+		spaces = []string{page.Space.Key}
+	}
+	if len(spaces) == 0 {
+		return mcp.NewToolResultError("space_key is required"), nil
+	}
+
+	graph, err := services.BuildLinkGraph(ctx, client, cfg, spaces)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to read links: %v", err)), nil
+	}
+	backlinks := graph.Backlinks(page.ID)
+
+	output := GetBacklinksOutput{
+		PageID:    page.ID,
+		Title:     page.Title,
+		Spaces:    spaces,
+		Scanned:   len(graph.Pages),
+		Count:     len(backlinks),
+		Backlinks: backlinks,
+	}
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterGetBacklinksTool registers the get_backlinks tool with the MCP server
+func RegisterGetBacklinksTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_backlinks",
+		mcp.WithDescription("Find the pages that link to a Confluence page or to its attachments, e.g. before deleting or renaming it. "+
+			"Confluence has no backlink search, so every page of the scanned spaces is read; this takes a while on large spaces."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithString("space_key", mcp.Description("Comma-separated space keys to scan (default: the space of the page)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetBacklinksHandler))
+}
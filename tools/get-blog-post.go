@@ -0,0 +1,77 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// GetBlogPostInput defines the input parameters for getting a blog post
+type GetBlogPostInput struct {
+	BlogPostID string `json:"blog_post_id" validate:"required"`
+	MaxChars   int    `json:"max_chars,omitempty"`
+	MaxTokens  int    `json:"max_tokens,omitempty"`
+	Offset     int    `json:"offset,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+}
+
+// GetBlogPostOutput is a blog post with its content in storage format
+type GetBlogPostOutput struct {
+	services.BlogPost `yaml:",inline"`
+
+	services.ContentWindow `yaml:",inline"`
+}
+
+// confluenceGetBlogPostHandler handles getting a blog post
+func confluenceGetBlogPostHandler(ctx context.Context, request mcp.CallToolRequest, input GetBlogPostInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	input.BlogPostID, err = resolvePage(ctx, client, input.Profile, input.BlogPostID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve blog post: %v", err)), nil
+	}
+
+	post, body, response, err := services.GetBlogPost(ctx, client, cfg, input.BlogPostID)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get blog post: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get blog post: %v", err)), nil
+	}
+
+	window, err := services.WindowContent(body, input.Offset, services.CharBudget(input.MaxChars, input.MaxTokens))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	responseText, err := yaml.Marshal(GetBlogPostOutput{BlogPost: *post, ContentWindow: *window})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterGetBlogPostTool registers the get_blog_post tool with the MCP server
+func RegisterGetBlogPostTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_blog_post",
+		mcp.WithDescription("Get a Confluence blog post with its author, publishing date and content in storage format"),
+		mcp.WithString("blog_post_id", mcp.Required(), mcp.Description("Blog post ID or URL")),
+		mcp.WithNumber("max_chars", mcp.Description("Maximum number of characters of content to return")),
+		mcp.WithNumber("max_tokens", mcp.Description("Maximum number of tokens of content to return (about 4 characters each)")),
+		mcp.WithNumber("offset", mcp.Description("Character offset to start from, as returned in next_offset by the previous call")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetBlogPostHandler))
+}
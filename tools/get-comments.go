@@ -17,6 +17,7 @@ type GetCommentsInput struct {
 	Location   string `json:"location,omitempty"`
 	StartAt    int    `json:"start_at,omitempty"`
 	MaxResults int    `json:"max_results,omitempty"`
+	Profile    string `json:"profile,omitempty"`
 }
 
 // GetCommentsOutput defines the output structure for comments
@@ -40,11 +41,16 @@ type CommentInfo struct {
 
 // confluenceGetCommentsTypedHandler handles retrieving comments for a Confluence page using typed approach
 func confluenceGetCommentsTypedHandler(ctx context.Context, req mcp.CallToolRequest, input GetCommentsInput) (*mcp.CallToolResult, error) {
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(input.Profile)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
 	}
 
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
 	// Set default values
 	if input.MaxResults == 0 {
 		input.MaxResults = 50
@@ -118,11 +124,12 @@ func confluenceGetCommentsTypedHandler(ctx context.Context, req mcp.CallToolRequ
 func RegisterGetCommentsPageTool(s *server.MCPServer) {
 	tool := mcp.NewTool("get_comments",
 		mcp.WithDescription("Get comments from a Confluence page"),
-		mcp.WithString("page_id", mcp.Required(), mcp.Description("Confluence page ID")),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page whose comments to get: "+services.PageReferenceHelp)),
 		mcp.WithString("expand", mcp.Description("Properties to expand in the response (comma-separated)")),
 		mcp.WithString("location", mcp.Description("Comment location filter (inline, footer, resolved)")),
 		mcp.WithNumber("start_at", mcp.Description("Starting index for pagination")),
 		mcp.WithNumber("max_results", mcp.Description("Maximum number of results to return (default: 50)")),
+		withProfile(),
 	)
 	
 	// Use typed tool handler
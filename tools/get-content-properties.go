@@ -0,0 +1,63 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// GetContentPropertiesInput defines the input parameters for getting content properties
+type GetContentPropertiesInput struct {
+	PageID  string `json:"page_id" validate:"required"`
+	Keys    string `json:"keys,omitempty"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// ContentPropertiesOutput lists the properties of a page
+type ContentPropertiesOutput struct {
+	PageID     string                      `json:"page_id" yaml:"page_id"`
+	Properties []*services.ContentProperty `json:"properties" yaml:"properties"`
+}
+
+// confluenceGetContentPropertiesHandler handles reading the content properties of a page
+func confluenceGetContentPropertiesHandler(ctx context.Context, request mcp.CallToolRequest, input GetContentPropertiesInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	properties, response, err := services.GetContentProperties(ctx, client, input.PageID, splitList(input.Keys))
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get content properties: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get content properties: %v", err)), nil
+	}
+
+	responseText, err := yaml.Marshal(ContentPropertiesOutput{PageID: input.PageID, Properties: properties})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterGetContentPropertiesTool registers the get_content_properties tool with the MCP server
+func RegisterGetContentPropertiesTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_content_properties",
+		mcp.WithDescription("Get the content properties of a page or blog post: JSON values stored under keys, e.g. owner, review date or status, with their versions"),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithString("keys", mcp.Description("Comma-separated property keys to get (default: all properties)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetContentPropertiesHandler))
+}
@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// GetOutgoingLinksInput defines the input parameters for listing the links of a page
+type GetOutgoingLinksInput struct {
+	PageID        string `json:"page_id" validate:"required"`
+	CheckExternal bool   `json:"check_external,omitempty"`
+	Profile       string `json:"profile,omitempty"`
+}
+
+// GetOutgoingLinksOutput lists the links of a page
+type GetOutgoingLinksOutput struct {
+	PageID string           `json:"page_id" yaml:"page_id"`
+	Title  string           `json:"title" yaml:"title"`
+	Count  int              `json:"count" yaml:"count"`
+	Links  []*services.Link `json:"links" yaml:"links"`
+}
+
+// confluenceGetOutgoingLinksHandler handles listing and checking the links of a page
+func confluenceGetOutgoingLinksHandler(ctx context.Context, request mcp.CallToolRequest, input GetOutgoingLinksInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	page, response, err := client.Content.Get(ctx, input.PageID, []string{"body.storage", "space"}, 0)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %v", err)), nil
+	}
+
+	links, err := services.PageLinks(ctx, client, cfg, page, input.CheckExternal, 0)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to check links: %v", err)), nil
+	}
+	if links == nil {
+		links = []*services.Link{}
+	}
+
+	output := GetOutgoingLinksOutput{PageID: page.ID, Title: page.Title, Count: len(links), Links: links}
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterGetOutgoingLinksTool registers the get_outgoing_links tool with the MCP server
+func RegisterGetOutgoingLinksTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_outgoing_links",
+		mcp.WithDescription("List the links of a Confluence page to other pages, attachments and URLs, with whether each target exists. "+
+			"Page links are broken when the page does not exist, attachment links missing when the file does not; external URLs are only requested with check_external."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithBoolean("check_external", mcp.Description("Also request external URLs and report those that fail or answer with an error as dead")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetOutgoingLinksHandler))
+}
@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// GetPageRestrictionsInput defines the input parameters for reading page restrictions
+type GetPageRestrictionsInput struct {
+	PageID  string `json:"page_id" validate:"required"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// confluenceGetPageRestrictionsHandler handles reading the direct and inherited restrictions of a page
+func confluenceGetPageRestrictionsHandler(ctx context.Context, request mcp.CallToolRequest, input GetPageRestrictionsInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	restrictions, response, err := services.GetPageRestrictions(ctx, client, input.PageID)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get page restrictions: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get page restrictions: %v", err)), nil
+	}
+
+	responseText, err := yaml.Marshal(restrictions)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterGetPageRestrictionsTool registers the get_page_restrictions tool with the MCP server
+func RegisterGetPageRestrictionsTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_page_restrictions",
+		mcp.WithDescription("Get the view (read) and edit (update) restrictions of a Confluence page, including read restrictions inherited from its ancestors. Check this before quoting a page that may be restricted."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetPageRestrictionsHandler))
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// GetPageSectionInput defines the input parameters for getting a section of a page
+type GetPageSectionInput struct {
+	PageID             string `json:"page_id" validate:"required"`
+	Section            string `json:"section" validate:"required"`
+	WithoutSubsections bool   `json:"without_subsections,omitempty"`
+	MaxChars           int    `json:"max_chars,omitempty"`
+	MaxTokens          int    `json:"max_tokens,omitempty"`
+	Offset             int    `json:"offset,omitempty"`
+	Profile            string `json:"profile,omitempty"`
+}
+
+// GetPageSectionOutput is a section of a page, possibly cut to a budget
+type GetPageSectionOutput struct {
+	PageID      string   `json:"page_id" yaml:"page_id"`
+	Title       string   `json:"title" yaml:"title"`
+	Version     int      `json:"version" yaml:"version"`
+	Section     string   `json:"section" yaml:"section"`
+	Level       int      `json:"level" yaml:"level"`
+	Subsections []string `json:"subsections,omitempty" yaml:"subsections,omitempty"`
+
+	services.ContentWindow `yaml:",inline"`
+}
+
+// confluenceGetPageSectionHandler handles getting one section of a page by its heading path
+func confluenceGetPageSectionHandler(ctx context.Context, request mcp.CallToolRequest, input GetPageSectionInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	page, response, err := client.Content.Get(ctx, input.PageID, []string{"body.storage", "version"}, 0)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %v", err)), nil
+	}
+
+	body := services.ContentBody(page)
+	section, err := services.FindSection(services.OutlineStorage(body), input.Section)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	end := section.End()
+	if input.WithoutSubsections {
+		end = section.OwnEnd()
+	}
+	window, err := services.WindowContent(body[section.Start():end], input.Offset, services.CharBudget(input.MaxChars, input.MaxTokens))
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := GetPageSectionOutput{
+		PageID:        page.ID,
+		Title:         page.Title,
+		Section:       section.Path,
+		Level:         section.Level,
+		ContentWindow: *window,
+	}
+	if page.Version != nil {
+		output.Version = page.Version.Number
+	}
+	for _, child := range section.Children {
+		output.Subsections = append(output.Subsections, child.Title)
+	}
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterGetPageSectionTool registers the get_page_section tool with the MCP server
+func RegisterGetPageSectionTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_page_section",
+		mcp.WithDescription("Get one section of a Confluence page in storage format, from its heading to the next heading of the same or a higher level. "+
+			"Use get_page with outline first to see the sections. Large sections can be paged through with max_chars/max_tokens and offset."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithString("section", mcp.Required(), mcp.Description("Heading path, e.g. \"Deploy > Rollback\"; a trailing part such as \"Rollback\" works when it is unique")),
+		mcp.WithBoolean("without_subsections", mcp.Description("Stop at the first subsection instead of including the subsections")),
+		mcp.WithNumber("max_chars", mcp.Description("Maximum number of characters of content to return")),
+		mcp.WithNumber("max_tokens", mcp.Description("Maximum number of tokens of content to return (about 4 characters each)")),
+		mcp.WithNumber("offset", mcp.Description("Character offset within the section to start from, as returned in next_offset by the previous call")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetPageSectionHandler))
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// GetPageTablesInput defines the input parameters for getting the tables of a page
+type GetPageTablesInput struct {
+	PageID  string `json:"page_id" validate:"required"`
+	Table   *int   `json:"table,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// GetPageTablesOutput lists the tables of a page
+type GetPageTablesOutput struct {
+	PageID  string                `json:"page_id" yaml:"page_id"`
+	Title   string                `json:"title" yaml:"title"`
+	Version int                   `json:"version" yaml:"version"`
+	Tables  []*services.PageTable `json:"tables" yaml:"tables"`
+}
+
+// confluenceGetPageTablesHandler handles parsing the tables of a page into rows
+func confluenceGetPageTablesHandler(ctx context.Context, request mcp.CallToolRequest, input GetPageTablesInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	page, response, err := client.Content.Get(ctx, input.PageID, []string{"body.storage", "version"}, 0)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %v", err)), nil
+	}
+
+	tables, err := services.ParseTables(services.ContentBody(page))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to parse page tables: %v", err)), nil
+	}
+	if input.Table != nil {
+		if *input.Table < 0 || *input.Table >= len(tables) {
+			return mcp.NewToolResultError(fmt.Sprintf("table %d does not exist; the page has %d tables", *input.Table, len(tables))), nil
+		}
+		tables = tables[*input.Table : *input.Table+1]
+	}
+
+	output := GetPageTablesOutput{PageID: page.ID, Title: page.Title, Tables: tables}
+	if page.Version != nil {
+		output.Version = page.Version.Number
+	}
+
+	responseText, err := formatResult(output, input.Format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(responseText), nil
+}
+
+// RegisterGetPageTablesTool registers the get_page_tables tool with the MCP server
+func RegisterGetPageTablesTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_page_tables",
+		mcp.WithDescription("Parse the tables of a Confluence page into rows keyed by column header. "+
+			"Stacked header rows are joined as \"Group / Column\", merged cells repeat their value in every row and column they cover and are listed in merged_cells."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithNumber("table", mcp.Description("Index of a single table to return, 0 being the first table of the page")),
+		mcp.WithString("format", mcp.Description("Output format: yaml (default) or json")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetPageTablesHandler))
+}
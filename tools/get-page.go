@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -13,19 +14,29 @@ import (
 
 // GetPageInput defines the input parameters for getting a Confluence page
 type GetPageInput struct {
-	PageID string `json:"page_id" validate:"required"`
+	PageID    string `json:"page_id" validate:"required"`
+	Outline   bool   `json:"outline,omitempty"`
+	MaxChars  int    `json:"max_chars,omitempty"`
+	MaxTokens int    `json:"max_tokens,omitempty"`
+	Offset    int    `json:"offset,omitempty"`
+	Profile   string `json:"profile,omitempty"`
 }
 
 // GetPageOutput defines the output structure for page retrieval results
 type GetPageOutput struct {
-	Title           string      `json:"title"`
-	ID              string      `json:"id"`
-	Version         int         `json:"version"`
-	Type            string      `json:"type"`
-	Content         string      `json:"content"`
-	DirectChildren  []PageInfo  `json:"direct_children,omitempty"`
-	AllDescendants  []PageInfo  `json:"all_descendants,omitempty"`
-	Message         string      `json:"message"`
+	Title          string                     `json:"title"`
+	ID             string                     `json:"id"`
+	Version        int                        `json:"version"`
+	Type           string                     `json:"type"`
+	Content        string                     `json:"content"`
+	Outline        []*services.OutlineSection `json:"outline,omitempty" yaml:"outline,omitempty"`
+	TotalChars     int                        `json:"total_chars,omitempty" yaml:"total_chars,omitempty"`
+	Offset         int                        `json:"offset,omitempty" yaml:"offset,omitempty"`
+	NextOffset     int                        `json:"next_offset,omitempty" yaml:"next_offset,omitempty"`
+	Truncated      bool                       `json:"truncated,omitempty" yaml:"truncated,omitempty"`
+	DirectChildren []PageInfo                 `json:"direct_children,omitempty"`
+	AllDescendants []PageInfo                 `json:"all_descendants,omitempty"`
+	Message        string                     `json:"message"`
 }
 
 // PageInfo represents basic page information
@@ -36,11 +47,16 @@ type PageInfo struct {
 }
 
 func confluenceGetPageHandler(ctx context.Context, request mcp.CallToolRequest, input GetPageInput) (*mcp.CallToolResult, error) {
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(input.Profile)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
 	}
 
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
 	// Request content with various expanded views
 	expandParams := []string{"body.storage", "body.view", "body"}
 	content, response, err := client.Content.Get(ctx, input.PageID, expandParams, 0)
@@ -69,11 +85,28 @@ func confluenceGetPageHandler(ctx context.Context, request mcp.CallToolRequest,
 	}
 
 	output := GetPageOutput{
-		Title:   content.Title,
-		ID:      content.ID,
-		Version: versionNumber,
-		Type:    content.Type,
-		Content: htmlContent,
+		Title:      content.Title,
+		ID:         content.ID,
+		Version:    versionNumber,
+		Type:       content.Type,
+		Content:    htmlContent,
+		TotalChars: utf8.RuneCountInString(htmlContent),
+	}
+
+	// Outline mode lists the sections and their sizes instead of the body; otherwise the body
+	// is cut to the budget, and offset continues where the previous call stopped
+	if input.Outline {
+		output.Content = ""
+		output.Outline = services.OutlineStorage(htmlContent)
+	} else if budget := services.CharBudget(input.MaxChars, input.MaxTokens); budget > 0 || input.Offset > 0 {
+		window, err := services.WindowContent(htmlContent, input.Offset, budget)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		output.Content = window.Content
+		output.Offset = window.Offset
+		output.NextOffset = window.NextOffset
+		output.Truncated = window.Truncated
 	}
 
 	// Get direct child pages
@@ -149,6 +182,9 @@ func confluenceGetPageHandler(ctx context.Context, request mcp.CallToolRequest,
 	childCount := len(output.DirectChildren)
 	descendantCount := len(output.AllDescendants)
 	output.Message = fmt.Sprintf("Page retrieved successfully with %d direct children and %d other descendants", childCount, descendantCount)
+	if output.Truncated {
+		output.Message += fmt.Sprintf("; content truncated at %d of %d characters, call again with offset %d to continue", output.NextOffset, output.TotalChars, output.NextOffset)
+	}
 
 	// Marshal to YAML
 	responseText, err := yaml.Marshal(output)
@@ -161,8 +197,14 @@ func confluenceGetPageHandler(ctx context.Context, request mcp.CallToolRequest,
 
 func RegisterGetPageTool(s *server.MCPServer) {
 	pageTool := mcp.NewTool("get_page",
-		mcp.WithDescription("Get Confluence page content"),
-		mcp.WithString("page_id", mcp.Required(), mcp.Description("Confluence page ID")),
+		mcp.WithDescription("Get Confluence page content. For large pages, request the outline first (headings with section sizes), "+
+			"then read sections with get_page_section, or page through the body with max_chars/max_tokens and offset."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page to get: "+services.PageReferenceHelp)),
+		mcp.WithBoolean("outline", mcp.Description("Return the heading tree with the size and offset of each section instead of the content")),
+		mcp.WithNumber("max_chars", mcp.Description("Maximum number of characters of content to return")),
+		mcp.WithNumber("max_tokens", mcp.Description("Maximum number of tokens of content to return (about 4 characters each)")),
+		mcp.WithNumber("offset", mcp.Description("Character offset to start from, as returned in next_offset by the previous call")),
+		withProfile(),
 	)
 	s.AddTool(pageTool, mcp.NewTypedToolHandler(confluenceGetPageHandler))
 } 
\ No newline at end of file
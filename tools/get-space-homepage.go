@@ -0,0 +1,88 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// GetSpaceHomepageInput defines the input parameters for getting a space homepage
+type GetSpaceHomepageInput struct {
+	SpaceKey string `json:"space_key" validate:"required"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// GetSpaceHomepageOutput defines the output structure for a space homepage
+type GetSpaceHomepageOutput struct {
+	SpaceKey string `json:"space_key"`
+	Title    string `json:"title"`
+	ID       string `json:"id"`
+	Version  int    `json:"version"`
+	Content  string `json:"content"`
+	Link     string `json:"link"`
+	Message  string `json:"message"`
+}
+
+// confluenceGetSpaceHomepageHandler handles retrieving the homepage of a Confluence space
+func confluenceGetSpaceHomepageHandler(ctx context.Context, request mcp.CallToolRequest, input GetSpaceHomepageInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	space, response, err := client.Space.Get(ctx, input.SpaceKey, []string{"homepage"})
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get space: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get space: %v", err)), nil
+	}
+
+	if space.HomePage == nil || space.HomePage.ID == "" {
+		return mcp.NewToolResultError(fmt.Sprintf("space %s has no homepage", input.SpaceKey)), nil
+	}
+
+	homepage, response, err := client.Content.Get(ctx, space.HomePage.ID, []string{"body.storage", "body.view", "version"}, 0)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get homepage: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get homepage: %v", err)), nil
+	}
+
+	output := GetSpaceHomepageOutput{
+		SpaceKey: space.Key,
+		Title:    homepage.Title,
+		ID:       homepage.ID,
+		Content:  services.ContentBody(homepage),
+		Message:  fmt.Sprintf("Homepage of space %s retrieved successfully", space.Key),
+	}
+	if homepage.Version != nil {
+		output.Version = homepage.Version.Number
+	}
+	if homepage.Links != nil {
+		output.Link = homepage.Links.Self
+	}
+
+	// Marshal to YAML
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterGetSpaceHomepageTool registers the get_space_homepage tool with the MCP server
+func RegisterGetSpaceHomepageTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_space_homepage",
+		mcp.WithDescription("Get the homepage of a Confluence space"),
+		mcp.WithString("space_key", mcp.Required(), mcp.Description("The key of the space")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetSpaceHomepageHandler))
+}
@@ -0,0 +1,66 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// GetSpacePermissionsInput defines the input parameters for inspecting space permissions
+type GetSpacePermissionsInput struct {
+	SpaceKey        string `json:"space_key" validate:"required"`
+	CompareSpaceKey string `json:"compare_space_key,omitempty"`
+	Format          string `json:"format,omitempty"`
+	Profile         string `json:"profile,omitempty"`
+}
+
+// confluenceGetSpacePermissionsHandler handles building the permission matrix of a space,
+// or the difference between the permission sets of two spaces
+func confluenceGetSpacePermissionsHandler(ctx context.Context, request mcp.CallToolRequest, input GetSpacePermissionsInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	matrix, response, err := services.GetSpacePermissionMatrix(ctx, client, input.SpaceKey)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get space permissions: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get space permissions: %v", err)), nil
+	}
+
+	var result interface{} = matrix
+	if input.CompareSpaceKey != "" {
+		other, response, err := services.GetSpacePermissionMatrix(ctx, client, input.CompareSpaceKey)
+		if err != nil {
+			if response != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get space permissions: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get space permissions: %v", err)), nil
+		}
+		result = services.DiffPermissionMatrices(matrix, other)
+	}
+
+	responseText, err := formatResult(result, input.Format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(responseText), nil
+}
+
+// RegisterGetSpacePermissionsTool registers the get_space_permissions tool with the MCP server
+func RegisterGetSpacePermissionsTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_space_permissions",
+		mcp.WithDescription("List the users and groups with their operation grants in a space as a permission matrix, or diff the permission sets of two spaces"),
+		mcp.WithString("space_key", mcp.Required(), mcp.Description("The key of the space")),
+		mcp.WithString("compare_space_key", mcp.Description("Key of a second space; when set, only the differences between the two spaces are returned")),
+		mcp.WithString("format", mcp.Description("Output format: yaml (default), json or csv")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetSpacePermissionsHandler))
+}
@@ -0,0 +1,110 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// GetSpaceInput defines the input parameters for getting a Confluence space
+type GetSpaceInput struct {
+	SpaceKey        string `json:"space_key" validate:"required"`
+	IncludeHomepage bool   `json:"include_homepage_content,omitempty"`
+	Profile         string `json:"profile,omitempty"`
+}
+
+// GetSpaceOutput defines the output structure for space details
+type GetSpaceOutput struct {
+	Key             string                            `json:"key"`
+	ID              int                               `json:"id"`
+	Name            string                            `json:"name"`
+	Type            string                            `json:"type"`
+	Status          string                            `json:"status"`
+	Description     string                            `json:"description,omitempty"`
+	HomepageID      string                            `json:"homepage_id,omitempty"`
+	HomepageTitle   string                            `json:"homepage_title,omitempty"`
+	HomepageContent string                            `json:"homepage_content,omitempty"`
+	Labels          []string                          `json:"labels,omitempty"`
+	Permissions     []services.SpacePermissionSummary `json:"permissions,omitempty"`
+	Link            string                            `json:"link"`
+	Message         string                            `json:"message"`
+}
+
+// confluenceGetSpaceHandler handles retrieving the details of a Confluence space
+func confluenceGetSpaceHandler(ctx context.Context, request mcp.CallToolRequest, input GetSpaceInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	expand := []string{"description.plain", "homepage", "metadata.labels", "permissions"}
+	space, response, err := services.GetSpace(ctx, client, input.SpaceKey, expand)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get space: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get space: %v", err)), nil
+	}
+
+	output := GetSpaceOutput{
+		Key:         space.Key,
+		ID:          space.ID,
+		Name:        space.Name,
+		Type:        space.Type,
+		Status:      space.Status,
+		Permissions: services.SummarizeSpacePermissions(space.Permissions),
+	}
+
+	if space.Links != nil {
+		output.Link = space.Links.Self
+	}
+	if space.Description != nil && space.Description.Plain != nil {
+		output.Description = space.Description.Plain.Value
+	}
+	if space.Metadata != nil && space.Metadata.Labels != nil {
+		for _, label := range space.Metadata.Labels.Results {
+			output.Labels = append(output.Labels, label.Name)
+		}
+	}
+
+	if space.HomePage != nil {
+		output.HomepageID = space.HomePage.ID
+		output.HomepageTitle = space.HomePage.Title
+
+		if input.IncludeHomepage {
+			homepage, response, err := client.Content.Get(ctx, space.HomePage.ID, []string{"body.storage"}, 0)
+			if err != nil {
+				if response != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to get homepage: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+				}
+				return mcp.NewToolResultError(fmt.Sprintf("failed to get homepage: %v", err)), nil
+			}
+			output.HomepageContent = services.ContentBody(homepage)
+		}
+	}
+
+	output.Message = fmt.Sprintf("Space %s retrieved with %d labels and %d permission entries", space.Key, len(output.Labels), len(output.Permissions))
+
+	// Marshal to YAML
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterGetSpaceTool registers the get_space tool with the MCP server
+func RegisterGetSpaceTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_space",
+		mcp.WithDescription("Get details of a Confluence space: description, homepage, labels and a permissions summary"),
+		mcp.WithString("space_key", mcp.Required(), mcp.Description("The key of the space")),
+		mcp.WithBoolean("include_homepage_content", mcp.Description("Include the homepage content in storage format (default: false)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetSpaceHandler))
+}
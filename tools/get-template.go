@@ -0,0 +1,49 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// GetTemplateInput defines the input parameters for getting a template
+type GetTemplateInput struct {
+	Template string `json:"template" validate:"required"`
+	SpaceKey string `json:"space_key,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// confluenceGetTemplateHandler handles getting a template with its body and variables
+func confluenceGetTemplateHandler(ctx context.Context, request mcp.CallToolRequest, input GetTemplateInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	template, err := services.FindTemplate(ctx, client, input.SpaceKey, input.Template)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	responseText, err := yaml.Marshal(template)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterGetTemplateTool registers the get_template tool with the MCP server
+func RegisterGetTemplateTool(s *server.MCPServer) {
+	tool := mcp.NewTool("get_template",
+		mcp.WithDescription("Get a Confluence page template: its storage format body and the variables to fill in with create_page_from_template"),
+		mcp.WithString("template", mcp.Required(), mcp.Description("Template ID, or name of a template of the space or a global one")),
+		mcp.WithString("space_key", mcp.Description("Space to look the template name up in before the global templates")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceGetTemplateHandler))
+}
@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/confluence"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// csvRenderer is implemented by results that can be rendered as CSV
+type csvRenderer interface {
+	CSV() (string, error)
+}
+
+// splitList splits a comma-separated argument into trimmed, non-empty values
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// resolvePage turns a page reference (ID, URL, tiny link or title) into a page ID
+func resolvePage(ctx context.Context, client *confluence.Client, profile, reference string) (string, error) {
+	cfg, err := services.ConfigFor(profile)
+	if err != nil {
+		return "", fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return services.ResolvePageID(ctx, client, cfg, reference)
+}
+
+// formatResult renders a tool result as YAML (default), JSON or, when supported, CSV
+func formatResult(v interface{}, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "yaml":
+		data, err := yaml.Marshal(v)
+		return string(data), err
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		return string(data), err
+	case "csv":
+		renderer, ok := v.(csvRenderer)
+		if !ok {
+			return "", fmt.Errorf("csv output is not supported for this result")
+		}
+		return renderer.CSV()
+	default:
+		return "", fmt.Errorf("unsupported format %q (use yaml, json or csv)", format)
+	}
+}
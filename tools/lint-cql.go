@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// LintCQLInput defines the input parameters for checking a CQL query
+type LintCQLInput struct {
+	Query string `json:"query" validate:"required"`
+}
+
+// confluenceLintCQLHandler checks a CQL query without running it
+func confluenceLintCQLHandler(ctx context.Context, request mcp.CallToolRequest, input LintCQLInput) (*mcp.CallToolResult, error) {
+	responseText, err := yaml.Marshal(services.LintCQL(input.Query))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterLintCQLTool registers the lint_cql tool with the MCP server
+func RegisterLintCQLTool(s *server.MCPServer) {
+	tool := mcp.NewTool("lint_cql",
+		mcp.WithDescription("Check a CQL query without sending it: reports syntax errors, unknown or misspelled fields, "+
+			"operators a field does not support (e.g. ~ on space), unquoted values with spaces and malformed dates, with their column"),
+		mcp.WithString("query", mcp.Required(), mcp.Description("CQL query to check")),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceLintCQLHandler))
+}
@@ -0,0 +1,76 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// ListBlogPostsInput defines the input parameters for listing blog posts
+type ListBlogPostsInput struct {
+	SpaceKey string `json:"space_key,omitempty"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// ListBlogPostsOutput lists blog posts, newest first
+type ListBlogPostsOutput struct {
+	BlogPosts []*services.BlogPost `json:"blog_posts" yaml:"blog_posts"`
+	Count     int                  `json:"count" yaml:"count"`
+}
+
+// confluenceListBlogPostsHandler handles listing the blog posts of spaces in a date range
+func confluenceListBlogPostsHandler(ctx context.Context, request mcp.CallToolRequest, input ListBlogPostsInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	spaces := splitList(input.SpaceKey)
+	if len(spaces) == 0 && cfg.DefaultSpace != "" {
+		spaces = []string{cfg.DefaultSpace}
+	}
+
+	posts, response, err := services.ListBlogPosts(ctx, client, cfg, services.BlogPostQuery{
+		Spaces: spaces,
+		From:   input.From,
+		To:     input.To,
+		Limit:  input.Limit,
+	})
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list blog posts: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list blog posts: %v", err)), nil
+	}
+
+	responseText, err := yaml.Marshal(ListBlogPostsOutput{BlogPosts: posts, Count: len(posts)})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterListBlogPostsTool registers the list_blog_posts tool with the MCP server
+func RegisterListBlogPostsTool(s *server.MCPServer) {
+	tool := mcp.NewTool("list_blog_posts",
+		mcp.WithDescription("List the blog posts of Confluence spaces published in a date range, newest first"),
+		mcp.WithString("space_key", mcp.Description("Comma-separated space keys (default: the profile's default space, or all spaces)")),
+		mcp.WithString("from", mcp.Description("Published on or after: a date (2024-01-31) or a period back from now (7d, 2w, 3M, 1y)")),
+		mcp.WithString("to", mcp.Description("Published before: a date (2024-01-31) or a period back from now")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of blog posts to return (default: 25)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceListBlogPostsHandler))
+}
@@ -0,0 +1,62 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// ListDraftsInput defines the input parameters for listing drafts
+type ListDraftsInput struct {
+	SpaceKey string `json:"space_key,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// ListDraftsOutput lists the drafts of the current user
+type ListDraftsOutput struct {
+	Drafts []*services.Draft `json:"drafts" yaml:"drafts"`
+	Count  int               `json:"count" yaml:"count"`
+}
+
+// confluenceListDraftsHandler handles listing the caller's own drafts
+func confluenceListDraftsHandler(ctx context.Context, request mcp.CallToolRequest, input ListDraftsInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	drafts, response, err := services.ListDrafts(ctx, client, cfg, input.SpaceKey, input.Type)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list drafts: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list drafts: %v", err)), nil
+	}
+
+	responseText, err := yaml.Marshal(ListDraftsOutput{Drafts: drafts, Count: len(drafts)})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterListDraftsTool registers the list_drafts tool with the MCP server
+func RegisterListDraftsTool(s *server.MCPServer) {
+	tool := mcp.NewTool("list_drafts",
+		mcp.WithDescription("List your own unpublished drafts of pages and blog posts"),
+		mcp.WithString("space_key", mcp.Description("Only list the drafts of this space")),
+		mcp.WithString("type", mcp.Description("page or blogpost (default: both)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceListDraftsHandler))
+}
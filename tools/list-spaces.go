@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
 	"github.com/nguyenvanduocit/confluence-mcp/services"
@@ -13,12 +14,22 @@ import (
 // ListSpacesInput defines the input parameters for listing Confluence spaces
 type ListSpacesInput struct {
 	// No required parameters for listing spaces
+	StartAt       int    `json:"start_at,omitempty"`
+	MaxResults    int    `json:"max_results,omitempty"`
+	Type          string `json:"type,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Labels        string `json:"labels,omitempty"`
+	FavouriteOnly bool   `json:"favourite_only,omitempty"`
+	Profile       string `json:"profile,omitempty"`
 }
 
 // ListSpacesOutput defines the output structure for spaces listing results
 type ListSpacesOutput struct {
 	Spaces      []SpaceInfo `json:"spaces"`
 	SpaceCount  int         `json:"space_count"`
+	StartAt     int         `json:"start_at"`
+	HasMore     bool        `json:"has_more"`
+	NextStartAt int         `json:"next_start_at,omitempty"`
 	Message     string      `json:"message"`
 }
 
@@ -34,13 +45,25 @@ type SpaceInfo struct {
 
 // confluenceListSpacesHandler handles listing all Confluence spaces
 func confluenceListSpacesHandler(ctx context.Context, request mcp.CallToolRequest, input ListSpacesInput) (*mcp.CallToolResult, error) {
-    client, err := services.ConfluenceClient()
+    client, err := services.ConfluenceClientFor(input.Profile)
     if err != nil {
         return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
     }
 
-    // Fetch spaces – default options, first 100 results
-    spaces, response, err := client.Space.Gets(ctx, nil, 0, 100)
+    if input.MaxResults == 0 {
+        input.MaxResults = 100
+    }
+
+    options := &models.GetSpacesOptionScheme{
+        SpaceType: input.Type,
+        Status:    input.Status,
+        Favorite:  input.FavouriteOnly,
+    }
+    if input.Labels != "" {
+        options.Labels = splitList(input.Labels)
+    }
+
+    spaces, response, err := client.Space.Gets(ctx, options, input.StartAt, input.MaxResults)
     if err != nil {
         if response != nil {
             return mcp.NewToolResultError(fmt.Sprintf("failed to list spaces: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
@@ -51,6 +74,13 @@ func confluenceListSpacesHandler(ctx context.Context, request mcp.CallToolReques
     output := ListSpacesOutput{
         Spaces:     make([]SpaceInfo, 0, len(spaces.Results)),
         SpaceCount: len(spaces.Results),
+        StartAt:    input.StartAt,
+    }
+
+    // A full page means more spaces may follow
+    if len(spaces.Results) >= input.MaxResults {
+        output.HasMore = true
+        output.NextStartAt = input.StartAt + len(spaces.Results)
     }
 
     if len(spaces.Results) == 0 {
@@ -87,7 +117,14 @@ func confluenceListSpacesHandler(ctx context.Context, request mcp.CallToolReques
 // RegisterListSpacesTool registers the list_spaces tool with the MCP server
 func RegisterListSpacesTool(s *server.MCPServer) {
     tool := mcp.NewTool("list_spaces",
-        mcp.WithDescription("List Confluence spaces"),
+        mcp.WithDescription("List Confluence spaces with optional filters and pagination"),
+        mcp.WithNumber("start_at", mcp.Description("Starting index for pagination")),
+        mcp.WithNumber("max_results", mcp.Description("Maximum number of spaces to return (default: 100)")),
+        mcp.WithString("type", mcp.Description("Filter by space type (global, personal)")),
+        mcp.WithString("status", mcp.Description("Filter by space status (current, archived)")),
+        mcp.WithString("labels", mcp.Description("Filter by space labels (comma-separated)")),
+        mcp.WithBoolean("favourite_only", mcp.Description("Only return spaces favourited by the current user")),
+        withProfile(),
     )
     s.AddTool(tool, mcp.NewTypedToolHandler(confluenceListSpacesHandler))
 } 
\ No newline at end of file
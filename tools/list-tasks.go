@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// ListTasksInput defines the input parameters for listing inline tasks
+type ListTasksInput struct {
+	CQL       string `json:"cql,omitempty"`
+	SpaceKey  string `json:"space_key,omitempty"`
+	Status    string `json:"status,omitempty"`
+	Assignee  string `json:"assignee,omitempty"`
+	DueBefore string `json:"due_before,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Format    string `json:"format,omitempty"`
+	Profile   string `json:"profile,omitempty"`
+}
+
+// confluenceListTasksHandler handles reading the inline tasks of the pages matching a query
+func confluenceListTasksHandler(ctx context.Context, request mcp.CallToolRequest, input ListTasksInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	status := strings.ToLower(input.Status)
+	switch status {
+	case "", "all":
+		status = ""
+	case "open", services.TaskIncomplete:
+		status = services.TaskIncomplete
+	case "done", services.TaskComplete:
+		status = services.TaskComplete
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("invalid status %q: use incomplete, complete or all", input.Status)), nil
+	}
+
+	var dueBefore time.Time
+	if input.DueBefore != "" {
+		dueBefore, err = time.Parse("2006-01-02", input.DueBefore)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid due_before %q: use a date as YYYY-MM-DD", input.DueBefore)), nil
+		}
+	}
+
+	cql := input.CQL
+	if cql == "" {
+		spaces := splitList(input.SpaceKey)
+		if len(spaces) == 0 && cfg.DefaultSpace != "" {
+			spaces = []string{cfg.DefaultSpace}
+		}
+		if len(spaces) == 0 {
+			return mcp.NewToolResultError("cql or space_key is required"), nil
+		}
+		cql, err = services.BuildCQL(services.CQLQuery{Spaces: spaces, Types: []string{"page"}, OrderBy: "lastmodified desc"})
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to build query: %v", err)), nil
+		}
+	}
+
+	tasks, response, err := services.ListTasks(ctx, client, cfg, services.TaskQuery{
+		CQL:       cql,
+		Status:    status,
+		Assignee:  input.Assignee,
+		DueBefore: dueBefore,
+		Pages:     input.Limit,
+	})
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list tasks: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list tasks: %v", err)), nil
+	}
+
+	responseText, err := formatResult(tasks, input.Format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(responseText), nil
+}
+
+// RegisterListTasksTool registers the list_tasks tool with the MCP server
+func RegisterListTasksTool(s *server.MCPServer) {
+	tool := mcp.NewTool("list_tasks",
+		mcp.WithDescription("List the inline tasks (checkbox task lists) of the pages matching a CQL query or of a space, with their status, assignees, due date and page link. "+
+			"Use the task id with set_task_status to tick or untick a task."),
+		mcp.WithString("cql", mcp.Description("CQL query selecting the pages to read, e.g. label = \"meeting-notes\" and created > now(\"-4w\")")),
+		mcp.WithString("space_key", mcp.Description("Comma-separated space keys to read when no cql is given (default: the default space)")),
+		mcp.WithString("status", mcp.Description("incomplete (or open), complete (or done), or all (default)")),
+		mcp.WithString("assignee", mcp.Description("Only tasks assigned to this user: account ID, username, display name, or me")),
+		mcp.WithString("due_before", mcp.Description("Only tasks due before this date (YYYY-MM-DD)")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of pages to read (default: 100)")),
+		mcp.WithString("format", mcp.Description("Output format: yaml (default), json or csv")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceListTasksHandler))
+}
@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// ListTemplatesInput defines the input parameters for listing templates
+type ListTemplatesInput struct {
+	SpaceKey   string `json:"space_key,omitempty"`
+	Blueprints bool   `json:"blueprints,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+}
+
+// ListTemplatesOutput lists the templates of a space or the global ones
+type ListTemplatesOutput struct {
+	Space     string               `json:"space,omitempty" yaml:"space,omitempty"`
+	Templates []*services.Template `json:"templates" yaml:"templates"`
+	Count     int                  `json:"count" yaml:"count"`
+}
+
+// confluenceListTemplatesHandler handles listing page templates
+func confluenceListTemplatesHandler(ctx context.Context, request mcp.CallToolRequest, input ListTemplatesInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	templates, response, err := services.ListTemplates(ctx, client, input.SpaceKey, input.Blueprints)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to list templates: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to list templates: %v", err)), nil
+	}
+
+	output := ListTemplatesOutput{Space: input.SpaceKey, Templates: templates, Count: len(templates)}
+	if output.Templates == nil {
+		output.Templates = []*services.Template{}
+	}
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterListTemplatesTool registers the list_templates tool with the MCP server
+func RegisterListTemplatesTool(s *server.MCPServer) {
+	tool := mcp.NewTool("list_templates",
+		mcp.WithDescription("List the page templates of a Confluence space, or the global templates when no space is given"),
+		mcp.WithString("space_key", mcp.Description("Space whose templates to list (default: global templates)")),
+		mcp.WithBoolean("blueprints", mcp.Description("Include blueprint templates")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceListTemplatesHandler))
+}
@@ -0,0 +1,75 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// LocalSearchInput defines the input parameters for searching the local index
+type LocalSearchInput struct {
+	Query        string  `json:"query" validate:"required"`
+	SpaceKey     string  `json:"space_key,omitempty"`
+	Refresh      bool    `json:"refresh,omitempty"`
+	Limit        int     `json:"limit,omitempty"`
+	TitleBoost   float64 `json:"title_boost,omitempty"`
+	HeadingBoost float64 `json:"heading_boost,omitempty"`
+	LabelBoost   float64 `json:"label_boost,omitempty"`
+	Profile      string  `json:"profile,omitempty"`
+}
+
+// confluenceLocalSearchHandler handles searching the local full-text index
+func confluenceLocalSearchHandler(ctx context.Context, request mcp.CallToolRequest, input LocalSearchInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	result, err := services.LocalSearch(ctx, client, cfg, services.LocalSearchOptions{
+		Query:   input.Query,
+		Spaces:  splitList(input.SpaceKey),
+		Refresh: input.Refresh,
+		Limit:   input.Limit,
+		Boosts: map[string]float64{
+			services.FieldTitle:    input.TitleBoost,
+			services.FieldHeadings: input.HeadingBoost,
+			services.FieldLabels:   input.LabelBoost,
+		},
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("local search failed: %v", err)), nil
+	}
+
+	responseText, err := yaml.Marshal(result)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterLocalSearchTool registers the local_search tool with the MCP server
+func RegisterLocalSearchTool(s *server.MCPServer) {
+	tool := mcp.NewTool("local_search",
+		mcp.WithDescription("Full-text search over a local index of Confluence spaces; faster than CQL and ranked by relevance. "+
+			"Supports \"quoted phrases\", field prefixes (title:, heading:, label:, body:), +required and -excluded terms. "+
+			"Results include a snippet with matches in bold. Spaces are indexed on first use and refreshed incrementally on request."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Search query, e.g. \"release process\" label:howto -draft")),
+		mcp.WithString("space_key", mcp.Description("Comma-separated space keys to search; spaces not indexed yet are crawled first (default: every indexed space)")),
+		mcp.WithBoolean("refresh", mcp.Description("Fetch pages modified since the last crawl before searching")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of results (default: 10)")),
+		mcp.WithNumber("title_boost", mcp.Description("Weight of title matches relative to the body (default: 4)")),
+		mcp.WithNumber("heading_boost", mcp.Description("Weight of heading matches relative to the body (default: 2)")),
+		mcp.WithNumber("label_boost", mcp.Description("Weight of label matches relative to the body (default: 2)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceLocalSearchHandler))
+}
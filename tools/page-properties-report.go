@@ -0,0 +1,73 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+)
+
+// PagePropertiesReportInput defines the input parameters for a Page Properties report
+type PagePropertiesReportInput struct {
+	Label    string `json:"label" validate:"required"`
+	SpaceKey string `json:"space_key,omitempty"`
+	MacroID  string `json:"macro_id,omitempty"`
+	Columns  string `json:"columns,omitempty"`
+	Where    string `json:"where,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+	Format   string `json:"format,omitempty"`
+	Profile  string `json:"profile,omitempty"`
+}
+
+// confluencePagePropertiesReportHandler handles aggregating the Page Properties macros of labelled pages
+func confluencePagePropertiesReportHandler(ctx context.Context, request mcp.CallToolRequest, input PagePropertiesReportInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	report, response, err := services.BuildPropertiesReport(ctx, client, cfg, services.PropertiesQuery{
+		Label:   input.Label,
+		Spaces:  splitList(input.SpaceKey),
+		MacroID: input.MacroID,
+		Columns: splitList(input.Columns),
+		Filters: splitList(input.Where),
+		Limit:   input.Limit,
+	})
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to build report: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to build report: %v", err)), nil
+	}
+
+	responseText, err := formatResult(report, input.Format)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(responseText), nil
+}
+
+// RegisterPagePropertiesReportTool registers the page_properties_report tool with the MCP server
+func RegisterPagePropertiesReportTool(s *server.MCPServer) {
+	tool := mcp.NewTool("page_properties_report",
+		mcp.WithDescription("Read the Page Properties (details) macro tables of all pages with a label into one table, with a row per page and a column per key, "+
+			"like the Page Properties Report macro. Use where to answer questions such as \"which ADRs are still Proposed\"."),
+		mcp.WithString("label", mcp.Required(), mcp.Description("Label of the pages to report on, e.g. adr or decision")),
+		mcp.WithString("space_key", mcp.Description("Comma-separated space keys (default: all spaces)")),
+		mcp.WithString("macro_id", mcp.Description("Only read Page Properties macros with this ID, for pages with several of them")),
+		mcp.WithString("columns", mcp.Description("Comma-separated keys to report, in order (default: every key found)")),
+		mcp.WithString("where", mcp.Description("Comma-separated filters on keys, e.g. Status=Proposed or Status!=Done, compared without case")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of pages to read (default: 500)")),
+		mcp.WithString("format", mcp.Description("Output format: yaml (default), json or csv")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluencePagePropertiesReportHandler))
+}
@@ -0,0 +1,8 @@
+package tools
+
+import "github.com/mark3labs/mcp-go/mcp"
+
+// withProfile adds the optional profile argument shared by every tool
+func withProfile() mcp.ToolOption {
+	return mcp.WithString("profile", mcp.Description("Name of the configuration profile (Confluence site) to use. Defaults to the active profile"))
+}
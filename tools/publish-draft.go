@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// PublishDraftInput defines the input parameters for publishing a draft
+type PublishDraftInput struct {
+	DraftID string `json:"draft_id" validate:"required"`
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content,omitempty"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// confluencePublishDraftHandler handles publishing a draft
+func confluencePublishDraftHandler(ctx context.Context, request mcp.CallToolRequest, input PublishDraftInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	page, response, err := services.PublishDraft(ctx, client, input.DraftID, input.Title, input.Content)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to publish draft: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to publish draft: %v", err)), nil
+	}
+
+	output := CreatePageOutput{
+		Success: true,
+		Title:   page.Title,
+		ID:      page.ID,
+	}
+	if page.Version != nil {
+		output.Version = page.Version.Number
+	}
+	if page.Links != nil {
+		output.Link = cfg.WebURL(page.Links.Webui)
+	}
+	output.Message = fmt.Sprintf("Draft published successfully!\nTitle: %s\nID: %s\nLink: %s", output.Title, output.ID, output.Link)
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterPublishDraftTool registers the publish_draft tool with the MCP server
+func RegisterPublishDraftTool(s *server.MCPServer) {
+	tool := mcp.NewTool("publish_draft",
+		mcp.WithDescription("Publish a draft as a page or blog post, e.g. once it was reviewed. The content of the draft is published as it was last saved, "+
+			"including edits made in Confluence, unless new content is given."),
+		mcp.WithString("draft_id", mcp.Required(), mcp.Description("ID of the draft, as returned by create_draft or list_drafts")),
+		mcp.WithString("title", mcp.Description("Title to publish with instead of the draft's (optional)")),
+		mcp.WithString("content", mcp.Description("Content to publish instead of the draft's, in storage format (optional)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluencePublishDraftHandler))
+}
@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// RetrievePassagesInput defines the input parameters for retrieving passages
+type RetrievePassagesInput struct {
+	Query      string `json:"query" validate:"required"`
+	SpaceKey   string `json:"space_key,omitempty"`
+	PageIDs    string `json:"page_ids,omitempty"`
+	TopK       int    `json:"top_k,omitempty"`
+	ChunkSize  int    `json:"chunk_tokens,omitempty"`
+	Candidates int    `json:"candidate_pages,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+}
+
+// confluenceRetrievePassagesHandler handles retrieving the passages most relevant to a query
+func confluenceRetrievePassagesHandler(ctx context.Context, request mcp.CallToolRequest, input RetrievePassagesInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	pageIDs, err := services.ResolvePageIDs(ctx, client, cfg, splitList(input.PageIDs))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	result, err := services.RetrievePassages(ctx, client, cfg, services.PassageOptions{
+		Query:      input.Query,
+		Spaces:     splitList(input.SpaceKey),
+		PageIDs:    pageIDs,
+		K:          input.TopK,
+		MaxTokens:  input.ChunkSize,
+		Candidates: input.Candidates,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to retrieve passages: %v", err)), nil
+	}
+
+	responseText, err := yaml.Marshal(result)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterRetrievePassagesTool registers the retrieve_passages tool with the MCP server
+func RegisterRetrievePassagesTool(s *server.MCPServer) {
+	tool := mcp.NewTool("retrieve_passages",
+		mcp.WithDescription("Retrieve the passages of Confluence pages most relevant to a question, instead of whole pages. "+
+			"Pages are split by heading into token-bounded chunks; each passage comes with its page ID, section path and link."),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Question or topic to find passages for")),
+		mcp.WithString("space_key", mcp.Description("Comma-separated space keys to search")),
+		mcp.WithString("page_ids", mcp.Description("Comma-separated pages to retrieve from instead of searching, each a page ID, URL, tiny link or SPACE:Title")),
+		mcp.WithNumber("top_k", mcp.Description("Number of passages to return (default: 5)")),
+		mcp.WithNumber("chunk_tokens", mcp.Description("Maximum size of a passage in tokens (default: 300)")),
+		mcp.WithNumber("candidate_pages", mcp.Description("Number of pages found by search to split into passages (default: 10)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceRetrievePassagesHandler))
+}
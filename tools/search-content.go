@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// SearchContentInput defines the input parameters for a structured search
+type SearchContentInput struct {
+	Text           string `json:"text,omitempty"`
+	Title          string `json:"title,omitempty"`
+	ExactTitle     bool   `json:"exact_title,omitempty"`
+	SpaceKey       string `json:"space_key,omitempty"`
+	Labels         string `json:"labels,omitempty"`
+	ExcludeLabels  string `json:"exclude_labels,omitempty"`
+	Type           string `json:"type,omitempty"`
+	Contributor    string `json:"contributor,omitempty"`
+	Creator        string `json:"creator,omitempty"`
+	Ancestor       string `json:"ancestor,omitempty"`
+	Parent         string `json:"parent,omitempty"`
+	CreatedAfter   string `json:"created_after,omitempty"`
+	CreatedBefore  string `json:"created_before,omitempty"`
+	ModifiedAfter  string `json:"modified_after,omitempty"`
+	ModifiedBefore string `json:"modified_before,omitempty"`
+	OrderBy        string `json:"order_by,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+	Cursor         string `json:"cursor,omitempty"`
+	Start          int    `json:"start,omitempty"`
+	Profile        string `json:"profile,omitempty"`
+}
+
+// confluenceSearchContentHandler builds CQL from search fields and runs it
+func confluenceSearchContentHandler(ctx context.Context, request mcp.CallToolRequest, input SearchContentInput) (*mcp.CallToolResult, error) {
+	cql, err := services.BuildCQL(services.CQLQuery{
+		Text:           input.Text,
+		Title:          input.Title,
+		ExactTitle:     input.ExactTitle,
+		Spaces:         splitList(input.SpaceKey),
+		Labels:         splitList(input.Labels),
+		ExcludeLabels:  splitList(input.ExcludeLabels),
+		Types:          splitList(input.Type),
+		Contributor:    input.Contributor,
+		Creator:        input.Creator,
+		Ancestor:       input.Ancestor,
+		Parent:         input.Parent,
+		CreatedAfter:   input.CreatedAfter,
+		CreatedBefore:  input.CreatedBefore,
+		ModifiedAfter:  input.ModifiedAfter,
+		ModifiedBefore: input.ModifiedBefore,
+		OrderBy:        input.OrderBy,
+	})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid search: %v", err)), nil
+	}
+
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+	output, err := searchPages(ctx, client, cfg, cql, &models.SearchContentOptions{Limit: limit, Start: input.Start, Cursor: input.Cursor})
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterSearchContentTool registers the search_content tool with the MCP server
+func RegisterSearchContentTool(s *server.MCPServer) {
+	tool := mcp.NewTool("search_content",
+		mcp.WithDescription("Search Confluence by fields instead of raw CQL. The CQL is built and escaped for you and returned as query; "+
+			"fields are combined with AND, and at least one is required."),
+		mcp.WithString("text", mcp.Description("Words anywhere in the content")),
+		mcp.WithString("title", mcp.Description("Words in the title")),
+		mcp.WithBoolean("exact_title", mcp.Description("Match the title exactly instead of by words")),
+		mcp.WithString("space_key", mcp.Description("Comma-separated space keys")),
+		mcp.WithString("labels", mcp.Description("Comma-separated labels; content with any of them matches")),
+		mcp.WithString("exclude_labels", mcp.Description("Comma-separated labels the content must not have")),
+		mcp.WithString("type", mcp.Description("Comma-separated content types: page, blogpost, comment, attachment")),
+		mcp.WithString("contributor", mcp.Description("Account ID (username on Data Center) of someone who edited the content, or \"me\"")),
+		mcp.WithString("creator", mcp.Description("Account ID (username on Data Center) of the creator, or \"me\"")),
+		mcp.WithString("ancestor", mcp.Description("ID of a page the content is anywhere below")),
+		mcp.WithString("parent", mcp.Description("ID of the direct parent page")),
+		mcp.WithString("created_after", mcp.Description("Date (YYYY-MM-DD) or period ago (7d, 2w, 3M, 1y)")),
+		mcp.WithString("created_before", mcp.Description("Date (YYYY-MM-DD) or period ago (7d, 2w, 3M, 1y)")),
+		mcp.WithString("modified_after", mcp.Description("Date (YYYY-MM-DD) or period ago (7d, 2w, 3M, 1y)")),
+		mcp.WithString("modified_before", mcp.Description("Date (YYYY-MM-DD) or period ago (7d, 2w, 3M, 1y)")),
+		mcp.WithString("order_by", mcp.Description("Sort order, e.g. \"lastmodified desc\" or \"title\"")),
+		mcp.WithNumber("limit", mcp.Description("Maximum number of results (default: 10)")),
+		mcp.WithString("cursor", mcp.Description("next_cursor of the previous call, to get the next page of results")),
+		mcp.WithNumber("start", mcp.Description("next_start of the previous call, to get the next page of results on sites that page by offset")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceSearchContentHandler))
+}
@@ -3,7 +3,9 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/ctreminiom/go-atlassian/confluence"
 	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -13,7 +15,10 @@ import (
 
 // SearchPageInput defines the input parameters for searching Confluence pages
 type SearchPageInput struct {
-	Query string `json:"query" validate:"required"`
+	Query   string `json:"query" validate:"required"`
+	Cursor  string `json:"cursor,omitempty"`
+	Start   int    `json:"start,omitempty"`
+	Profile string `json:"profile,omitempty"`
 }
 
 // SearchPageOutput defines the output structure for search results
@@ -22,6 +27,9 @@ type SearchPageOutput struct {
 	Results     []SearchResult `json:"results"`
 	ResultCount int          `json:"result_count"`
 	Message     string       `json:"message"`
+	Warnings    []string     `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	NextCursor  string       `json:"next_cursor,omitempty" yaml:"next_cursor,omitempty"`
+	NextStart   int          `json:"next_start,omitempty" yaml:"next_start,omitempty"`
 }
 
 // SearchResult represents a single search result
@@ -32,29 +40,60 @@ type SearchResult struct {
 	Link         string `json:"link"`
 	LastModified string `json:"last_modified"`
 	Excerpt      string `json:"excerpt"`
+
+	services.ResultDetails `yaml:",inline"`
 }
 
 // confluenceSearchHandler is a handler for the confluence search tool
 func confluenceSearchHandler(ctx context.Context, request mcp.CallToolRequest, input SearchPageInput) (*mcp.CallToolResult, error) {
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(input.Profile)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
 	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
 
-	options := &models.SearchContentOptions{
-		Limit: 5,
+	// Only queries that cannot be parsed are refused; the other findings are heuristics, such as
+	// unknown fields, which app and custom fields trigger too, so Confluence has the last word
+	lint := services.LintCQL(input.Query)
+	if syntaxErrors := lint.SyntaxErrors(); len(syntaxErrors) > 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("invalid CQL query:\n%s", strings.Join(syntaxErrors, "\n"))), nil
+	}
+
+	output, err := searchPages(ctx, client, cfg, input.Query, &models.SearchContentOptions{Limit: 5, Start: input.Start, Cursor: input.Cursor})
+	if err != nil {
+		if findings := lint.Findings(); len(findings) > 0 {
+			return mcp.NewToolResultError(fmt.Sprintf("%v\nlint_cql findings that may explain it:\n%s", err, strings.Join(findings, "\n"))), nil
+		}
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	output.Warnings = lint.Findings()
+
+	// Marshal to YAML
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
 	}
 
-	contents, response, err := client.Search.Content(ctx, input.Query, options)
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// searchPages runs a CQL query and converts its results
+func searchPages(ctx context.Context, client *confluence.Client, cfg *services.AtlassianConfig, cql string, options *models.SearchContentOptions) (*SearchPageOutput, error) {
+	options.Expand = services.SearchExpand
+
+	contents, response, err := services.SearchContent(ctx, client, cfg, cql, options)
 	if err != nil {
 		if response != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("search failed: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+			return nil, fmt.Errorf("search failed: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)
 		}
-		return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", err)), nil
+		return nil, fmt.Errorf("search failed: %v", err)
 	}
 
-	output := SearchPageOutput{
-		Query:       input.Query,
+	output := &SearchPageOutput{
+		Query:       cql,
 		Results:     make([]SearchResult, 0, len(contents.Results)),
 		ResultCount: len(contents.Results),
 	}
@@ -65,31 +104,37 @@ func confluenceSearchHandler(ctx context.Context, request mcp.CallToolRequest, i
 		// Convert results to structured format
 		for _, content := range contents.Results {
 			result := SearchResult{
-				Title:        content.Content.Title,
-				ID:           content.Content.ID,
-				Type:         content.Content.Type,
-				Link:         content.Content.Links.Self,
+				Title:        content.Title,
+				Type:         content.EntityType,
+				Link:         services.ResultLink(cfg, content),
 				LastModified: content.LastModified,
 				Excerpt:      content.Excerpt,
+
+				ResultDetails: services.SearchResultDetails(cfg, content),
+			}
+			if content.Content != nil {
+				result.Title = content.Content.Title
+				result.ID = content.Content.ID
+				result.Type = content.Content.Type
 			}
 			output.Results = append(output.Results, result)
 		}
-		output.Message = fmt.Sprintf("Found %d results for query: %s", len(contents.Results), input.Query)
+		output.Message = fmt.Sprintf("Found %d results for query: %s", len(contents.Results), cql)
 	}
-
-	// Marshal to YAML
-	responseText, err := yaml.Marshal(output)
-	if err != nil {
-		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	output.NextCursor, output.NextStart = services.NextSearchPage(contents)
+	if output.NextCursor != "" || output.NextStart > 0 {
+		output.Message += "; more results are available with next_cursor or next_start"
 	}
-
-	return mcp.NewToolResultText(string(responseText)), nil
+	return output, nil
 }
 
 func RegisterSearchPageTool(s *server.MCPServer) {
 	tool := mcp.NewTool("search_page",
-		mcp.WithDescription("Search pages in Confluence"),
-		mcp.WithString("query", mcp.Required(), mcp.Description("Atlassian Confluence Query Language (CQL)")),
+		mcp.WithDescription("Search Confluence with CQL. Results are typed: blog posts carry their author and publishing date, comments and attachments the page they belong to, attachments their media type, size and download link"),
+		mcp.WithString("query", mcp.Required(), mcp.Description("Atlassian Confluence Query Language (CQL); syntax errors are reported without sending it, and the other lint_cql findings are returned as warnings next to the results")),
+		mcp.WithString("cursor", mcp.Description("next_cursor of the previous call, to get the next page of results")),
+		mcp.WithNumber("start", mcp.Description("next_start of the previous call, to get the next page of results on sites that page by offset")),
+		withProfile(),
 	)
 	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceSearchHandler))
 } 
\ No newline at end of file
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// SetContentPropertyInput defines the input parameters for setting a content property
+type SetContentPropertyInput struct {
+	PageID  string `json:"page_id" validate:"required"`
+	Key     string `json:"key" validate:"required"`
+	Value   string `json:"value" validate:"required"`
+	Version int    `json:"version,omitempty"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// SetContentPropertyOutput is a property after it was written
+type SetContentPropertyOutput struct {
+	Success bool   `json:"success" yaml:"success"`
+	PageID  string `json:"page_id" yaml:"page_id"`
+
+	services.ContentProperty `yaml:",inline"`
+}
+
+// confluenceSetContentPropertyHandler handles creating or replacing a content property
+func confluenceSetContentPropertyHandler(ctx context.Context, request mcp.CallToolRequest, input SetContentPropertyInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	property, response, err := services.SetContentProperty(ctx, client, input.PageID, input.Key, services.PropertyValue(input.Value), input.Version)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to set content property: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to set content property: %v", err)), nil
+	}
+
+	responseText, err := yaml.Marshal(SetContentPropertyOutput{Success: true, PageID: input.PageID, ContentProperty: *property})
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterSetContentPropertyTool registers the set_content_property tool with the MCP server
+func RegisterSetContentPropertyTool(s *server.MCPServer) {
+	tool := mcp.NewTool("set_content_property",
+		mcp.WithDescription("Create or replace a content property of a page or blog post. "+
+			"Pass the version returned by get_content_properties to only write when nobody changed the property since it was read."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithString("key", mcp.Required(), mcp.Description("Property key, e.g. owner or review")),
+		mcp.WithString("value", mcp.Required(), mcp.Description("JSON value, e.g. {\"status\": \"approved\", \"due\": \"2024-06-30\"}; text that is not JSON is stored as a string")),
+		mcp.WithNumber("version", mcp.Description("Version of the property the value was based on; the write fails if the property has changed since. -1 requires the property not to exist yet")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceSetContentPropertyHandler))
+}
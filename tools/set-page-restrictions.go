@@ -0,0 +1,105 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// SetPageRestrictionsInput defines the input parameters for updating page restrictions
+type SetPageRestrictionsInput struct {
+	PageID       string `json:"page_id" validate:"required"`
+	ReadUsers    string `json:"read_users,omitempty"`
+	ReadGroups   string `json:"read_groups,omitempty"`
+	UpdateUsers  string `json:"update_users,omitempty"`
+	UpdateGroups string `json:"update_groups,omitempty"`
+	Mode         string `json:"mode,omitempty"`
+	Profile      string `json:"profile,omitempty"`
+}
+
+// restrictionChange builds a restriction change from comma-separated user and group lists
+func restrictionChange(readUsers, readGroups, updateUsers, updateGroups string) services.RestrictionChange {
+	return services.RestrictionChange{
+		Read:   services.RestrictionSubjects{Users: splitList(readUsers), Groups: splitList(readGroups)},
+		Update: services.RestrictionSubjects{Users: splitList(updateUsers), Groups: splitList(updateGroups)},
+	}
+}
+
+// confluenceSetPageRestrictionsHandler handles replacing, extending or clearing the restrictions of a page
+func confluenceSetPageRestrictionsHandler(ctx context.Context, request mcp.CallToolRequest, input SetPageRestrictionsInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	var response *models.ResponseScheme
+	change := restrictionChange(input.ReadUsers, input.ReadGroups, input.UpdateUsers, input.UpdateGroups)
+
+	switch input.Mode {
+	case "", "replace":
+		if change.Empty() {
+			return mcp.NewToolResultError("at least one user or group is required in replace mode; use mode=clear to remove all restrictions"), nil
+		}
+		response, err = services.SetPageRestrictions(ctx, client, cfg, input.PageID, change, true)
+	case "add":
+		if change.Empty() {
+			return mcp.NewToolResultError("at least one user or group is required in add mode"), nil
+		}
+		response, err = services.SetPageRestrictions(ctx, client, cfg, input.PageID, change, false)
+	case "clear":
+		response, err = services.ClearPageRestrictions(ctx, client, input.PageID)
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("unsupported mode %q (use replace, add or clear)", input.Mode)), nil
+	}
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to set page restrictions: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to set page restrictions: %v", err)), nil
+	}
+
+	restrictions, response, err := services.GetPageRestrictions(ctx, client, input.PageID)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("restrictions updated but failed to read them back: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("restrictions updated but failed to read them back: %v", err)), nil
+	}
+
+	responseText, err := yaml.Marshal(restrictions)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterSetPageRestrictionsTool registers the set_page_restrictions tool with the MCP server
+func RegisterSetPageRestrictionsTool(s *server.MCPServer) {
+	tool := mcp.NewTool("set_page_restrictions",
+		mcp.WithDescription("Set the view (read) and edit (update) restrictions of a Confluence page for users and groups. Returns the resulting restrictions, including those inherited from ancestors."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithString("read_users", mcp.Description("Comma-separated users allowed to view the page (account IDs on Cloud, usernames on Data Center)")),
+		mcp.WithString("read_groups", mcp.Description("Comma-separated group names allowed to view the page")),
+		mcp.WithString("update_users", mcp.Description("Comma-separated users allowed to edit the page (account IDs on Cloud, usernames on Data Center)")),
+		mcp.WithString("update_groups", mcp.Description("Comma-separated group names allowed to edit the page")),
+		mcp.WithString("mode", mcp.Description("replace (default): overwrites both view and edit restrictions, so the listed subjects become the only ones allowed and an operation with none listed becomes unrestricted (passing only read_users drops the existing edit restrictions); "+
+			"add: add the listed subjects to the existing restrictions; clear: remove all restrictions")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceSetPageRestrictionsHandler))
+}
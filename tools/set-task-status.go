@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// SetTaskStatusInput defines the input parameters for ticking or unticking a task
+type SetTaskStatusInput struct {
+	PageID  string `json:"page_id" validate:"required"`
+	Task    string `json:"task" validate:"required"`
+	Status  string `json:"status" validate:"required"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// SetTaskStatusOutput reports the task that changed
+type SetTaskStatusOutput struct {
+	Success bool   `json:"success" yaml:"success"`
+	PageID  string `json:"page_id" yaml:"page_id"`
+	Version int    `json:"version" yaml:"version"`
+	Link    string `json:"link,omitempty" yaml:"link,omitempty"`
+	TaskID  string `json:"task_id" yaml:"task_id"`
+	Text    string `json:"text" yaml:"text"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
+// confluenceSetTaskStatusHandler handles changing the status of one inline task of a page
+func confluenceSetTaskStatusHandler(ctx context.Context, request mcp.CallToolRequest, input SetTaskStatusInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	status := strings.ToLower(input.Status)
+	switch status {
+	case "done":
+		status = services.TaskComplete
+	case "open":
+		status = services.TaskIncomplete
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	page, response, err := client.Content.Get(ctx, input.PageID, []string{"body.storage", "version"}, 0)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %v", err)), nil
+	}
+
+	current := services.ContentBody(page)
+	body, task, err := services.SetTaskStatus(current, input.Task, status)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	output := SetTaskStatusOutput{Success: true, PageID: page.ID, TaskID: task.ID, Text: task.Text, Status: task.Status}
+	if page.Version != nil {
+		output.Version = page.Version.Number
+	}
+	if page.Links != nil {
+		output.Link = cfg.WebURL(page.Links.Webui)
+	}
+
+	if body == current {
+		output.Message = "Task already has this status; the page was not changed"
+	} else {
+		payload := &models.ContentScheme{
+			ID:      page.ID,
+			Type:    page.Type,
+			Title:   page.Title,
+			Version: &models.ContentVersionScheme{Number: output.Version + 1},
+			Body: &models.BodyScheme{
+				Storage: &models.BodyNodeScheme{
+					Value:          body,
+					Representation: "storage",
+				},
+			},
+		}
+		updated, response, err := client.Content.Update(ctx, page.ID, payload)
+		if err != nil {
+			if response != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to update page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+			}
+			return mcp.NewToolResultError(fmt.Sprintf("failed to update page: %v", err)), nil
+		}
+		if updated.Version != nil {
+			output.Version = updated.Version.Number
+		}
+	}
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterSetTaskStatusTool registers the set_task_status tool with the MCP server
+func RegisterSetTaskStatusTool(s *server.MCPServer) {
+	tool := mcp.NewTool("set_task_status",
+		mcp.WithDescription("Tick or untick one inline task of a Confluence page. Only the status of that task changes; the rest of the page markup is left as it is. "+
+			"Use list_tasks to find task IDs."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithString("task", mcp.Required(), mcp.Description("Task ID, or text that only one task of the page contains")),
+		mcp.WithString("status", mcp.Required(), mcp.Description("complete (or done) or incomplete (or open)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceSetTaskStatusHandler))
+}
@@ -0,0 +1,115 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// UpdateBlogPostInput defines the input parameters for updating a blog post
+type UpdateBlogPostInput struct {
+	BlogPostID    string `json:"blog_post_id" validate:"required"`
+	Title         string `json:"title,omitempty"`
+	Content       string `json:"content,omitempty"`
+	VersionNumber string `json:"version_number,omitempty"`
+	Profile       string `json:"profile,omitempty"`
+}
+
+// confluenceUpdateBlogPostHandler handles updating an existing blog post
+func confluenceUpdateBlogPostHandler(ctx context.Context, request mcp.CallToolRequest, input UpdateBlogPostInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	input.BlogPostID, err = resolvePage(ctx, client, input.Profile, input.BlogPostID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve blog post: %v", err)), nil
+	}
+
+	current, body, response, err := services.GetBlogPost(ctx, client, cfg, input.BlogPostID)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get current blog post: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get current blog post: %v", err)), nil
+	}
+
+	// Blog posts are updated as a whole, so the current title and body are kept unless replaced
+	payload := &models.ContentScheme{
+		ID:      current.ID,
+		Type:    services.ContentTypeBlogPost,
+		Title:   current.Title,
+		Version: &models.ContentVersionScheme{Number: current.Version + 1},
+		Body: &models.BodyScheme{
+			Storage: &models.BodyNodeScheme{
+				Value:          body,
+				Representation: "storage",
+			},
+		},
+	}
+	if input.Title != "" {
+		payload.Title = input.Title
+	}
+	if input.Content != "" {
+		payload.Body.Storage.Value = input.Content
+	}
+	if input.VersionNumber != "" {
+		version, err := strconv.Atoi(input.VersionNumber)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid version_number: %v", err)), nil
+		}
+		payload.Version.Number = version
+	}
+
+	updated, response, err := client.Content.Update(ctx, current.ID, payload)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to update blog post: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to update blog post: %v", err)), nil
+	}
+
+	output := UpdatePageOutput{
+		Success: true,
+		Title:   updated.Title,
+		ID:      updated.ID,
+	}
+	if updated.Version != nil {
+		output.Version = updated.Version.Number
+	}
+	if updated.Links != nil {
+		output.Link = cfg.WebURL(updated.Links.Webui)
+	}
+	output.Message = fmt.Sprintf("Blog post updated successfully!\nTitle: %s\nID: %s\nVersion: %d\nLink: %s", output.Title, output.ID, output.Version, output.Link)
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterUpdateBlogPostTool registers the update_blog_post tool with the MCP server
+func RegisterUpdateBlogPostTool(s *server.MCPServer) {
+	tool := mcp.NewTool("update_blog_post",
+		mcp.WithDescription("Update the title or content of an existing Confluence blog post"),
+		mcp.WithString("blog_post_id", mcp.Required(), mcp.Description("Blog post ID or URL")),
+		mcp.WithString("title", mcp.Description("New title of the blog post (optional)")),
+		mcp.WithString("content", mcp.Description("New content of the blog post in storage format (XHTML)")),
+		mcp.WithString("version_number", mcp.Description("Version number for optimistic locking (optional)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceUpdateBlogPostHandler))
+}
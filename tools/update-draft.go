@@ -0,0 +1,65 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// UpdateDraftInput defines the input parameters for updating a draft
+type UpdateDraftInput struct {
+	DraftID string `json:"draft_id" validate:"required"`
+	Title   string `json:"title,omitempty"`
+	Content string `json:"content,omitempty"`
+	Profile string `json:"profile,omitempty"`
+}
+
+// confluenceUpdateDraftHandler handles replacing the title or content of a draft
+func confluenceUpdateDraftHandler(ctx context.Context, request mcp.CallToolRequest, input UpdateDraftInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	if input.Title == "" && input.Content == "" {
+		return mcp.NewToolResultError("title or content is required"), nil
+	}
+
+	draft, response, err := services.UpdateDraft(ctx, client, input.DraftID, input.Title, input.Content)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to update draft: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to update draft: %v", err)), nil
+	}
+
+	output := DraftOutput{Success: true, Draft: *services.NewDraft(cfg, draft)}
+	output.Message = fmt.Sprintf("Draft updated; it is still not published.\nTitle: %s\nID: %s\nReview: %s", output.Title, output.ID, output.EditURL)
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterUpdateDraftTool registers the update_draft tool with the MCP server
+func RegisterUpdateDraftTool(s *server.MCPServer) {
+	tool := mcp.NewTool("update_draft",
+		mcp.WithDescription("Replace the title or content of a draft without publishing it"),
+		mcp.WithString("draft_id", mcp.Required(), mcp.Description("ID of the draft, as returned by create_draft or list_drafts")),
+		mcp.WithString("title", mcp.Description("New title of the draft (optional)")),
+		mcp.WithString("content", mcp.Description("New content of the draft in storage format (XHTML)")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceUpdateDraftHandler))
+}
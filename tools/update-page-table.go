@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ctreminiom/go-atlassian/pkg/infra/models"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/nguyenvanduocit/confluence-mcp/services"
+	"gopkg.in/yaml.v3"
+)
+
+// UpdatePageTableInput defines the input parameters for editing a table of a page
+type UpdatePageTableInput struct {
+	PageID     string `json:"page_id" validate:"required"`
+	Table      int    `json:"table,omitempty"`
+	WithColumn string `json:"table_with_column,omitempty"`
+	Updates    string `json:"updates,omitempty"`
+	AppendRows string `json:"append_rows,omitempty"`
+	Storage    bool   `json:"storage_format,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+}
+
+// UpdatePageTableOutput reports the changes made to a table
+type UpdatePageTableOutput struct {
+	Success bool   `json:"success" yaml:"success"`
+	PageID  string `json:"page_id" yaml:"page_id"`
+	Version int    `json:"version" yaml:"version"`
+	Link    string `json:"link,omitempty" yaml:"link,omitempty"`
+
+	services.TableEditResult `yaml:",inline"`
+}
+
+// confluenceUpdatePageTableHandler handles editing cells of a table and appending rows to it
+func confluenceUpdatePageTableHandler(ctx context.Context, request mcp.CallToolRequest, input UpdatePageTableInput) (*mcp.CallToolResult, error) {
+	client, err := services.ConfluenceClientFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
+	}
+	cfg, err := services.ConfigFor(input.Profile)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load configuration: %v", err)), nil
+	}
+
+	edit := services.TableEdit{Table: input.Table, WithColumn: input.WithColumn, Storage: input.Storage}
+	if input.Updates != "" {
+		if err := json.Unmarshal([]byte(input.Updates), &edit.Updates); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("updates must be a JSON array of {\"row\", \"column\", \"value\"} objects: %v", err)), nil
+		}
+	}
+	if input.AppendRows != "" {
+		if err := json.Unmarshal([]byte(input.AppendRows), &edit.Append); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("append_rows must be a JSON array of objects keyed by column header: %v", err)), nil
+		}
+	}
+	if len(edit.Updates) == 0 && len(edit.Append) == 0 {
+		return mcp.NewToolResultError("updates or append_rows is required"), nil
+	}
+
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
+	page, response, err := client.Content.Get(ctx, input.PageID, []string{"body.storage", "version"}, 0)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to get page: %v", err)), nil
+	}
+
+	body, result, err := services.EditTable(services.ContentBody(page), edit)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	version := 1
+	if page.Version != nil {
+		version = page.Version.Number + 1
+	}
+	payload := &models.ContentScheme{
+		ID:      page.ID,
+		Type:    page.Type,
+		Title:   page.Title,
+		Version: &models.ContentVersionScheme{Number: version},
+		Body: &models.BodyScheme{
+			Storage: &models.BodyNodeScheme{
+				Value:          body,
+				Representation: "storage",
+			},
+		},
+	}
+	updated, response, err := client.Content.Update(ctx, page.ID, payload)
+	if err != nil {
+		if response != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to update page: %s (endpoint: %s)", response.Bytes.String(), response.Endpoint)), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("failed to update page: %v", err)), nil
+	}
+
+	output := UpdatePageTableOutput{Success: true, PageID: updated.ID, TableEditResult: *result}
+	if updated.Version != nil {
+		output.Version = updated.Version.Number
+	}
+	if updated.Links != nil {
+		output.Link = cfg.WebURL(updated.Links.Webui)
+	}
+
+	responseText, err := yaml.Marshal(output)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to marshal result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(responseText)), nil
+}
+
+// RegisterUpdatePageTableTool registers the update_page_table tool with the MCP server
+func RegisterUpdatePageTableTool(s *server.MCPServer) {
+	tool := mcp.NewTool("update_page_table",
+		mcp.WithDescription("Edit cells of a table of a Confluence page or append rows to it, leaving the rest of the page markup untouched. "+
+			"Use get_page_tables first to see the table indexes and headers."),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page: "+services.PageReferenceHelp)),
+		mcp.WithNumber("table", mcp.Description("Index of the table, 0 being the first table of the page (default: 0)")),
+		mcp.WithString("table_with_column", mcp.Description("Select the first table that has this column header instead of using an index")),
+		mcp.WithString("updates", mcp.Description("JSON array of cell changes: [{\"row\": \"Service=billing\", \"column\": \"Owner\", \"value\": \"Jane\"}]. "+
+			"row is a row index (0 is the first row below the headers) or Key=Value; column is a header or a column index")),
+		mcp.WithString("append_rows", mcp.Description("JSON array of rows to add at the end, keyed by column header: [{\"Service\": \"search\", \"Owner\": \"Ann\"}]")),
+		mcp.WithBoolean("storage_format", mcp.Description("Values are storage format XHTML instead of text")),
+		withProfile(),
+	)
+	s.AddTool(tool, mcp.NewTypedToolHandler(confluenceUpdatePageTableHandler))
+}
@@ -18,6 +18,7 @@ type UpdatePageInput struct {
 	Title         string `json:"title,omitempty"`
 	Content       string `json:"content,omitempty"`
 	VersionNumber string `json:"version_number,omitempty"`
+	Profile       string `json:"profile,omitempty"`
 }
 
 // UpdatePageOutput defines the output structure for page update results
@@ -32,11 +33,16 @@ type UpdatePageOutput struct {
 
 // confluenceUpdatePageHandler handles updating existing Confluence pages
 func confluenceUpdatePageHandler(ctx context.Context, request mcp.CallToolRequest, input UpdatePageInput) (*mcp.CallToolResult, error) {
-	client, err := services.ConfluenceClient()
+	client, err := services.ConfluenceClientFor(input.Profile)
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("failed to initialize Confluence client: %v", err)), nil
 	}
 
+	input.PageID, err = resolvePage(ctx, client, input.Profile, input.PageID)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to resolve page: %v", err)), nil
+	}
+
 	// Get the latest version of the page
 	currentPage, response, err := client.Content.Get(ctx, input.PageID, []string{"version"}, 0)
 	if err != nil {
@@ -134,10 +140,11 @@ func confluenceUpdatePageHandler(ctx context.Context, request mcp.CallToolReques
 func RegisterUpdatePageTool(s *server.MCPServer) {
 	updatePageTool := mcp.NewTool("update_page",
 		mcp.WithDescription("Update an existing Confluence page"),
-		mcp.WithString("page_id", mcp.Required(), mcp.Description("ID of the page to update")),
+		mcp.WithString("page_id", mcp.Required(), mcp.Description("Page to update: "+services.PageReferenceHelp)),
 		mcp.WithString("title", mcp.Description("New title of the page (optional)")),
 		mcp.WithString("content", mcp.Description("New content of the page in storage format (XHTML)")),
 		mcp.WithString("version_number", mcp.Description("Version number for optimistic locking (optional)")),
+		withProfile(),
 	)
 	s.AddTool(updatePageTool, mcp.NewTypedToolHandler(confluenceUpdatePageHandler))
 } 
\ No newline at end of file